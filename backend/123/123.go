@@ -0,0 +1,1419 @@
+// Package _123 provides an interface to the 123 Pan (123pan.com) cloud
+// storage service.
+package _123
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	defaultEndpoint = "https://open-api.123pan.com"
+	minSleep        = 10 * time.Millisecond
+	maxSleep        = 2 * time.Second
+	decayConstant   = 2
+	rootID          = int64(0) // the API uses 0 as the root directory's parentFileID
+	listChunkSize   = 100
+
+	// defaultListAllPagesLimit is used when --123-list-all-pages-limit is
+	// left at its zero value.
+	defaultListAllPagesLimit = 10000
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "123",
+		Description: "123 Pan",
+		NewFs:       NewFs,
+		CommandHelp: commandHelp,
+		Options: []fs.Option{{
+			Name:     "client_id",
+			Help:     "Client ID for 123 Pan Open Platform.",
+			Required: true,
+		}, {
+			Name:       "client_secret",
+			Help:       "Client secret for 123 Pan Open Platform.",
+			IsPassword: true,
+			Required:   true,
+		}, {
+			Name:     "root_folder_id",
+			Help:     "ID of the root folder to use, leave blank for the account root.",
+			Advanced: true,
+		}, {
+			Name:     "upload_cutoff",
+			Help:     "Cutoff for switching to multipart (sliced) upload.",
+			Default:  fs.SizeSuffix(100 * 1024 * 1024),
+			Advanced: true,
+		}, {
+			Name:     "chunk_size",
+			Help:     "Slice size to use for multipart uploads.",
+			Default:  fs.SizeSuffix(16 * 1024 * 1024),
+			Advanced: true,
+		}, {
+			Name: "copy_buffer_size",
+			Help: `Size of the buffer used to copy data to temporary files during
+cross-cloud transfers and hashing.
+
+The default io.Copy buffer size of 32KB incurs a syscall per 32KB on
+multi-GB transfers; a larger buffer reduces that overhead.`,
+			Default:  fs.SizeSuffix(1024 * 1024),
+			Advanced: true,
+		}, {
+			Name: "download_buffer_size",
+			Help: `Size of the buffer used when copying downloaded data to disk.
+
+As with --123-copy-buffer-size, a larger buffer reduces syscall overhead
+on high-bandwidth links at the cost of more memory per download in
+flight. Must be between 32KB and 64MB; out of range values are clamped.`,
+			Default:  fs.SizeSuffix(1024 * 1024),
+			Advanced: true,
+		}, {
+			Name: "download_chunk_size",
+			Help: `Size of each range fetched by the "download" command.
+
+Applies only to the resumable "download" backend command's ranged
+fetches, not to the single-GET path used by Object.Open during a normal
+copy/sync. 0 uses the default of 16M.`,
+			Default:  fs.SizeSuffix(0),
+			Advanced: true,
+		}, {
+			Name: "max_concurrent_downloads",
+			Help: `Number of chunks to fetch at once when the "download" command
+resumes or starts a download.
+
+Chunks of a single file are fetched by a worker pool of this size, each
+writing its chunk to the correct offset in the destination file
+independently. 1 downloads chunks one at a time, matching the old
+sequential behaviour. Clamped to a sane range; 0 or unset falls back to
+--checkers.`,
+			Default:  0,
+			Advanced: true,
+		}, {
+			Name: "slice_upload_timeout",
+			Help: `Maximum time to wait for a single multipart slice upload.
+
+This is applied per-slice, separately from the global --timeout, so a
+slice stuck on a flaky connection is aborted and retried quickly rather
+than blocking the whole upload until the much longer global timeout
+fires. Set to 0 to rely on the global timeout only.`,
+			Default:  fs.Duration(60 * time.Second),
+			Advanced: true,
+		}, {
+			Name: "complete_poll_base",
+			Help: `Initial delay between upload_complete polls while the
+server finishes assembling an uploaded file.
+
+completeUpload polls upload_complete until the server reports the file
+as assembled, doubling the delay after each attempt up to
+--123-complete-poll-max. 0 uses the default of 1s.`,
+			Default:  fs.Duration(time.Second),
+			Advanced: true,
+		}, {
+			Name: "complete_poll_max",
+			Help: `Maximum delay between upload_complete polls.
+
+Caps the exponential backoff started at --123-complete-poll-base, so a
+huge file being assembled for minutes doesn't end up polling once a
+second the whole time. 0 uses the default of 15s.`,
+			Default:  fs.Duration(15 * time.Second),
+			Advanced: true,
+		}, {
+			Name: "complete_poll_min_attempts",
+			Help: `Minimum number of upload_complete polls before giving up.
+
+completeUpload gives up and returns an error once it has polled this
+many times without the server reporting the file as assembled. The
+default attempt count scales with file size, but a small file stuck
+behind a slow server-side verification queue can still need more
+patience than its size alone would suggest - raise this to cover that
+case without affecting large-file behaviour. 0 uses the default of 20.`,
+			Default:  0,
+			Advanced: true,
+		}, {
+			Name: "complete_poll_max_attempts",
+			Help: `Maximum number of upload_complete polls before giving up.
+
+Caps the size-scaled default attempt count so an enormous file doesn't
+end up polling for an unreasonable number of attempts. 0 uses the
+default of 600.`,
+			Default:  0,
+			Advanced: true,
+		}, {
+			Name:     "parent_cache_ttl",
+			Help:     "How long to trust a cached child->parent fileID mapping. 0 uses the default of 5m.",
+			Default:  fs.Duration(0),
+			Advanced: true,
+		}, {
+			Name:     "dir_cache_ttl",
+			Help:     "How long to trust a cached directory listing. 0 uses the default of 5m.",
+			Default:  fs.Duration(0),
+			Advanced: true,
+		}, {
+			Name:     "path_cache_ttl",
+			Help:     "How long to trust a cached path->fileID mapping. 0 uses the default of 5m.",
+			Default:  fs.Duration(0),
+			Advanced: true,
+		}, {
+			Name: "review_window",
+			Help: `How long to keep retrying NewObject for a file that was just
+uploaded but is still in the server's "under review" window.
+
+Right after a successful upload, upload_complete may return success
+while the file is still propagating, so an immediate lookup can return
+a spurious not-found error. Set to 0 to disable the retry.`,
+			Default:  fs.Duration(3 * time.Second),
+			Advanced: true,
+		}, {
+			Name: "list_modtime_source",
+			Help: `Which server-side timestamp populates Object.ModTime.
+
+Backup workflows generally want the file's creation time to stay stable,
+while sync workflows care about when the content last changed.`,
+			Default: "modified",
+			Examples: []fs.OptionExample{{
+				Value: "modified",
+				Help:  "Use the file's last-modified time (updateAt)",
+			}, {
+				Value: "created",
+				Help:  "Use the file's creation time (createAt)",
+			}},
+			Advanced: true,
+		}, {
+			Name: "fail_on_review_reject",
+			Help: `Fail an upload if the server's post-upload content review
+rejects the file.
+
+Normally upload_complete reporting success means rclone considers the
+transfer done, but a file can still be flagged by content review shortly
+afterwards, leaving behind an entry that looks uploaded but can't
+actually be used. When this is set, the resulting file's Status is
+checked right after upload and the transfer is failed if it indicates a
+review rejection, so "rclone copy" reports the failure instead of
+silently producing an inaccessible file.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "upload_concurrency",
+			Help: `Number of file slices to upload at once.
+
+Slices of a single large file are uploaded to a worker pool of this
+size, independently of --transfers which controls how many files upload
+at once. 0 or unset falls back to --transfers, so the two stay
+consistent unless this is set explicitly. 1 uploads slices one at a
+time, matching the old sequential behaviour. Clamped to a sane range so
+a misconfigured value can't fire an unbounded number of simultaneous
+requests against the slice API.`,
+			Default:  0,
+			Advanced: true,
+		}, {
+			Name:     "enable_performance_log",
+			Help:     "Periodically log cache and memory-manager statistics in the background.",
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "performance_log_interval",
+			Help: `How often to log performance statistics when
+enable_performance_log is set. 0 uses the default of 1m.`,
+			Default:  fs.Duration(0),
+			Advanced: true,
+		}, {
+			Name: "avoid_name_collisions",
+			Help: `Append a numeric suffix to an uploaded file's name if the
+destination directory already has an entry with that name.
+
+123 Pan itself tolerates multiple entries sharing a name in the same
+directory, so this is purely a client-side convenience for dedup-minded
+workflows that expect names to be unique. Enabling it costs one extra
+directory listing per upload into an already-populated directory,
+regardless of how many colliding names there are.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "skip_if_exists",
+			Help: `Skip uploading if an entry with the same name and a matching etag
+already exists in the destination directory.
+
+This is checked with a single directory listing right before
+createUpload, so a re-run of an already-completed upload skips even the
+create call - stronger than the server's own 秒传 instant-upload, which
+still makes that round trip. Useful for workflows that re-run the same
+upload idempotently.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "duplicate_policy",
+			Help: `What the server should do if the upload target directory already
+has an entry with the same name: "rename" (the API's policy 1) keeps
+both, auto-suffixing the new upload; "overwrite" (policy 2) replaces
+the existing entry in place.
+
+This is a server-side decision made when the upload session is
+created, distinct from avoid_name_collisions/skip_if_exists, which are
+client-side checks made beforehand by listing the directory. Setting
+this to "overwrite" skips the avoid_name_collisions suffixing logic
+entirely, since there's no longer a collision to avoid: the upload is
+meant to replace whatever is already there. It has no effect on
+skip_if_exists, which can still skip the upload outright when a
+matching etag is found.
+
+Interacts with rclone's own --ignore-existing/--no-traverse: those
+flags decide whether rclone attempts the upload at all by comparing
+local and remote listings beforehand, while duplicate_policy only
+decides what happens to a name collision the server sees once the
+upload is attempted, so "overwrite" is what makes a plain idempotent
+re-sync (no --ignore-existing) land on one file instead of
+accumulating auto-suffixed duplicates.`,
+			Default: "rename",
+			Examples: []fs.OptionExample{{
+				Value: "rename",
+				Help:  "Keep both; auto-suffix the new upload (API policy 1, the default)",
+			}, {
+				Value: "overwrite",
+				Help:  "Replace the existing entry in place (API policy 2)",
+			}},
+			Advanced: true,
+		}, {
+			Name: "compute_sha1_on_upload",
+			Help: `Compute a SHA1 alongside the MD5 already needed for every upload,
+advertising it through Hashes()/Hash().
+
+123 Pan's API carries no server-side SHA1 (only the MD5 etag), so this
+is purely a local computation made during the same read already used for
+MD5, with no extra pass over the data. The SHA1 is only available for
+objects this process itself uploaded, and only for the life of the
+process - it is not persisted anywhere, so a fresh NewObject (e.g. after
+a restart) can't report one.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "no_cross_cloud_download",
+			Help: `Refuse an upload whose source isn't local instead of buffering it
+through a temporary file first.
+
+A Put/Update from anything other than a local, seekable source -
+another cloud remote, a union, a crypt-wrapped remote, and so on - goes
+through memoryBufferedCrossCloudTransfer: the content is downloaded
+from the source and buffered to a temp file here before it can be
+uploaded, since 123 Pan's multipart API needs random access to compute
+slice boundaries that a forward-only stream can't provide. That hop
+costs bandwidth and disk on whatever machine rclone is running on,
+which is easy to trigger by accident with a remote-to-remote copy.
+Setting this returns an error instead of performing that download, so
+a cross-cloud copy has to be done deliberately (e.g. via an explicit
+local intermediate) rather than happening as a side effect.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "upload_verify_size",
+			Help: `Verify the uploaded file's server-side size matches the source after
+a multipart upload completes.
+
+upload_complete reporting success doesn't rule out a dropped slice that
+still passed the server's checks; this adds one follow-up detail call to
+catch that before the transfer is considered done, removing the
+now-known-bad file on a mismatch.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "ignore_etag_mismatch",
+			Help: `Don't fail an upload if the server's etag disagrees with the locally
+computed MD5.
+
+By default, once upload_complete reports success the uploaded file's
+etag is compared against the MD5 computed while reading the source; a
+mismatch removes the now-known-bad file and fails the transfer so
+rclone retries it, rather than returning a silently corrupted upload as
+successful. Set this for servers that return a normalized or blank
+etag that doesn't correspond to the plain file MD5.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "no_partial",
+			Help: `No-op, kept for compatibility with workflows that pass --123-no-partial.
+
+This backend has no ".partial" staging scheme: an upload always targets
+its final destination filename directly via the create-session call,
+with no intermediate name and no rename-on-completion step. There is
+nothing for this option to disable.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "list_cache_negative",
+			Help: `Cache "not found" path lookups for a few seconds, not just successful ones.
+
+pathToFileID normally only caches a path once it resolves to a fileID, so
+repeated lookups of a path that doesn't exist (common in sync when
+checking whether a destination file is already there) each walk the
+directory again. With this enabled, a lookup that comes back not-found is
+remembered for a short, fixed TTL, and is invalidated immediately on any
+create under that directory.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "upload_domains",
+			Help: `Comma-separated list of upload domains to use instead of the
+built-in defaults.
+
+A create-session call normally returns its own list of upload domains to
+slice-upload against; this is only consulted when that list comes back
+empty. Set this if the built-in defaults (` + strings.Join(defaultUploadDomains, ", ") + `)
+are blocked on your network, e.g. by a regional firewall, and you have an
+alternative (proxied or region-specific) domain to use instead.`,
+			Default:  "",
+			Advanced: true,
+		}, {
+			Name: "list_all_pages_limit",
+			Help: `Maximum number of pages to fetch when listing a single
+directory.
+
+listDir pages through the API until it sees a terminating lastFileId of
+-1. A buggy or adversarial response that never returns that terminator
+would otherwise page forever; this caps the number of pages fetched per
+directory, returning an error instead of hanging once exceeded. The
+default is high enough that it should never be hit by a real directory.`,
+			Default:  10000,
+			Advanced: true,
+		}, {
+			Name: "progress_dir",
+			Help: `Directory used for resume records and for buffering chunk/cross-cloud
+transfers, instead of the system temp directory.
+
+memoryBufferedCrossCloudTransfer and the slice-upload resume records
+normally land in the OS temp directory, which on some systems is a
+small tmpfs that a large transfer can fill. Set this to a directory on
+a filesystem with enough room. Left unset, it falls back to the
+system temp directory. The directory is created if missing, and
+checked for writability at startup.`,
+			Default:  "",
+			Advanced: true,
+		}, {
+			Name: "show_rejected",
+			Help: `Show files that have been rejected by content review.
+
+The API's status field reports more than just trashed: a value of
+` + strconv.Itoa(reviewRejectedStatus) + ` or higher means the server's content review rejected the
+file, which can leave it listed but undownloadable. By default List and
+ListR hide these files, the same as trashed ones, so "rclone copy"
+doesn't stumble into a confusing download failure on something 123pan
+has already blocked. Set this to list them anyway; Open still refuses
+to download them, returning a clear "rejected by content review" error
+rather than attempting a download doomed to fail.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "md5_cache_max_entries",
+			Help: `Maximum number of entries kept in the cross-cloud MD5 cache.
+
+A cross-cloud transfer (copying from a non-local remote) hashes its
+source content while buffering it to a temporary file; that hash is
+cached so a retried upload of the same source doesn't need a second
+hashing pass. The cache is shared by every 123 remote in the process and
+bounded by this setting, evicting the least-recently-used entry once
+full, so a long-running process that sees many distinct sources doesn't
+grow it without bound. 0 or unset uses a default of ` + strconv.Itoa(defaultMD5CacheMaxEntries) + `.`,
+			Default:  0,
+			Advanced: true,
+		}, {
+			Name: "metrics_file",
+			Help: `File to periodically dump performance metrics to, as JSON.
+
+Only takes effect when enable_performance_log is also set. Instead of
+(or as well as) the periodic debug-log line logPerformanceStats already
+emits, startPerformanceMonitoring marshals the same cache, memory and
+upload/download concurrency stats to this path every
+performance_log_interval, so a long-running mount can be scraped by an
+external process. Each write is atomic - a temp file in the same
+directory is written and renamed over the target - so a reader never
+sees a partial file. Left unset, no file is written.`,
+			Default:  "",
+			Advanced: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			// 123pan rejects filenames containing "\/:*?|><, so these
+			// are quoted rather than silently dropped or replaced.
+			Default: (encoder.Base |
+				encoder.EncodeSlash |
+				encoder.EncodeBackSlash |
+				encoder.EncodeWin | // :?"*<>|
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	ClientID                string               `config:"client_id"`
+	ClientSecret            string               `config:"client_secret"`
+	RootFolderID            string               `config:"root_folder_id"`
+	UploadCutoff            fs.SizeSuffix        `config:"upload_cutoff"`
+	ChunkSize               fs.SizeSuffix        `config:"chunk_size"`
+	CopyBufferSize          fs.SizeSuffix        `config:"copy_buffer_size"`
+	DownloadBufferSize      fs.SizeSuffix        `config:"download_buffer_size"`
+	DownloadChunkSize       fs.SizeSuffix        `config:"download_chunk_size"`
+	MaxConcurrentDownloads  int                  `config:"max_concurrent_downloads"`
+	SliceUploadTimeout      fs.Duration          `config:"slice_upload_timeout"`
+	CompletePollBase        fs.Duration          `config:"complete_poll_base"`
+	CompletePollMax         fs.Duration          `config:"complete_poll_max"`
+	CompletePollMinAttempts int                  `config:"complete_poll_min_attempts"`
+	CompletePollMaxAttempts int                  `config:"complete_poll_max_attempts"`
+	ParentCacheTTL          fs.Duration          `config:"parent_cache_ttl"`
+	DirCacheTTL             fs.Duration          `config:"dir_cache_ttl"`
+	PathCacheTTL            fs.Duration          `config:"path_cache_ttl"`
+	ReviewWindow            fs.Duration          `config:"review_window"`
+	ListModTimeSource       string               `config:"list_modtime_source"`
+	FailOnReviewReject      bool                 `config:"fail_on_review_reject"`
+	UploadConcurrency       int                  `config:"upload_concurrency"`
+	EnablePerformanceLog    bool                 `config:"enable_performance_log"`
+	PerformanceLogInterval  fs.Duration          `config:"performance_log_interval"`
+	AvoidNameCollisions     bool                 `config:"avoid_name_collisions"`
+	SkipIfExists            bool                 `config:"skip_if_exists"`
+	DuplicatePolicy         string               `config:"duplicate_policy"`
+	ComputeSHA1OnUpload     bool                 `config:"compute_sha1_on_upload"`
+	NoCrossCloudDownload    bool                 `config:"no_cross_cloud_download"`
+	UploadVerifySize        bool                 `config:"upload_verify_size"`
+	IgnoreEtagMismatch      bool                 `config:"ignore_etag_mismatch"`
+	NoPartial               bool                 `config:"no_partial"`
+	ListCacheNegative       bool                 `config:"list_cache_negative"`
+	UploadDomains           string               `config:"upload_domains"`
+	ListAllPagesLimit       int                  `config:"list_all_pages_limit"`
+	Enc                     encoder.MultiEncoder `config:"encoding"`
+	ProgressDir             string               `config:"progress_dir"`
+	ShowRejected            bool                 `config:"show_rejected"`
+	MD5CacheMaxEntries      int                  `config:"md5_cache_max_entries"`
+	MetricsFile             string               `config:"metrics_file"`
+}
+
+// Fs represents a remote 123 Pan drive
+type Fs struct {
+	name            string
+	root            string
+	opt             Options
+	features        *fs.Features
+	srv             *rest.Client
+	pacer           *fs.Pacer
+	m               configmap.Mapper
+	cache           *cacheState
+	cacheConfig     CacheConfig
+	rootFolderID    int64
+	memoryManager   *memoryManager
+	bufPool         *sync.Pool
+	downloadBufPool *sync.Pool
+
+	resourceExhaustedUntil int64 // unix nano; read/written via atomic, see throttleConcurrency
+
+	tokenMu          sync.Mutex
+	token            string
+	tokenExpiry      time.Time
+	tokenRenewerMu   sync.Mutex
+	tokenRenewerOnce sync.Once
+	tokenRenewerDone chan struct{}
+
+	performanceMonitorMu   sync.Mutex
+	performanceMonitorOnce sync.Once
+	performanceMonitorDone chan struct{}
+
+	ttfbMu        sync.Mutex
+	ttfbSamplesMs []float64 // time-to-first-byte samples from Object.Open, in milliseconds
+
+	selectedUploadDomainMu  sync.Mutex
+	selectedUploadDomain    string    // sticky upload domain last seen to accept a slice, see preferSelectedUploadDomain
+	selectedUploadDomainSet time.Time // when selectedUploadDomain was last set, for uploadDomainCacheTTL expiry
+
+	userInfoCacheMu  sync.Mutex
+	userInfoCache    *api.UserInfoResponse
+	userInfoCachedAt time.Time
+
+	resumeKeysMu     sync.Mutex
+	resumeKeysCache  map[string]bool // etag -> present; nil until the first getAllResumeKeys call populates it
+	resumeKeysLoaded bool
+
+	serverTime serverTimeState // clock skew vs the API server, derived from Date response headers
+}
+
+// Object describes a 123 Pan object
+type Object struct {
+	fs          *Fs
+	remote      string
+	mu          sync.Mutex
+	id          int64
+	size        int64
+	md5sum      string
+	sha1sum     string // only populated when --123-compute-sha1-on-upload computed it locally this session; the API itself carries no SHA1
+	modTime     time.Time
+	altModTime  time.Time // whichever of createAt/updateAt wasn't selected as modTime, surfaced via Metadata
+	category    int       // 0 = other, see api.FileInfo.Category; surfaced via Metadata
+	status      int       // review state, see api.FileInfo.Status; surfaced via Metadata
+	punishFlag  int       // non-zero if penalised for a content violation; surfaced via Metadata
+	trashed     int       // non-zero if in the trash; surfaced via Metadata
+	hasMetaData bool
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string { return f.name }
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string { return f.root }
+
+// String converts this Fs to a string
+func (f *Fs) String() string { return fmt.Sprintf("123 Pan root '%s'", f.root) }
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features { return f.features }
+
+// Precision of the remote
+func (f *Fs) Precision() time.Duration { return time.Second }
+
+// Hashes returns the supported hash sets. The API itself only ever
+// provides MD5 (as the file's etag); SHA1 is advertised too only when
+// --123-compute-sha1-on-upload is set, since in that case it's computed
+// locally during upload and held in memory for the rest of the session.
+func (f *Fs) Hashes() hash.Set {
+	if f.opt.ComputeSHA1OnUpload {
+		return hash.NewHashSet(hash.MD5, hash.SHA1)
+	}
+	return hash.Set(hash.MD5)
+}
+
+// validateOptions sanity-checks option combinations that configstruct
+// can't enforce on its own.
+func validateOptions(opt *Options) error {
+	if opt.EnablePerformanceLog && time.Duration(opt.PerformanceLogInterval) < 0 {
+		return errors.New("performance_log_interval must not be negative")
+	}
+	if _, err := duplicatePolicyValue(opt.DuplicatePolicy); err != nil {
+		return err
+	}
+	return nil
+}
+
+// duplicatePolicyValue maps a duplicate_policy option string to the API's
+// "duplicate" value (1 = keep both and auto-suffix, 2 = overwrite), the
+// only two values the upload/v1/file/create endpoint accepts. An empty
+// policy (the zero value configstruct would produce for a blank override)
+// is treated the same as the documented default of "rename".
+func duplicatePolicyValue(policy string) (int, error) {
+	switch policy {
+	case "", "rename":
+		return 1, nil
+	case "overwrite":
+		return 2, nil
+	default:
+		return 0, errors.Errorf("duplicate_policy must be \"rename\" or \"overwrite\", got %q", policy)
+	}
+}
+
+// NewFs constructs an Fs from the path, container:path
+func NewFs(name, root string, m configmap.Mapper) (fs.Fs, error) {
+	ctx := context.Background()
+	opt := new(Options)
+	if err := configstruct.Set(m, opt); err != nil {
+		return nil, err
+	}
+	if err := validateOptions(opt); err != nil {
+		return nil, err
+	}
+	root = strings.Trim(root, "/")
+
+	var rootFolderID int64
+	if opt.RootFolderID != "" {
+		var err error
+		rootFolderID, err = strconv.ParseInt(opt.RootFolderID, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid root_folder_id")
+		}
+	}
+
+	cacheConfig, err := cacheConfigFromOptions(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	copyBufferSize := int(opt.CopyBufferSize)
+	if copyBufferSize <= 0 {
+		copyBufferSize = 1024 * 1024
+	}
+
+	downloadBufferSize := clampDownloadBufferSize(int(opt.DownloadBufferSize))
+
+	f := &Fs{
+		name:          name,
+		root:          root,
+		opt:           *opt,
+		m:             m,
+		cache:         newCacheState(),
+		cacheConfig:   cacheConfig,
+		rootFolderID:  rootFolderID,
+		memoryManager: newMemoryManager(0),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, copyBufferSize) },
+		},
+		downloadBufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, downloadBufferSize) },
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+	}
+	f.srv.SetRoot(defaultEndpoint)
+
+	f.features = (&fs.Features{
+		CaseInsensitive:         false,
+		CanHaveEmptyDirectories: true,
+		Move:                    f.Move,
+		Copy:                    f.Copy,
+		DirMove:                 f.DirMove,
+		Purge:                   f.Purge,
+	}).Fill(f)
+
+	f.loadToken()
+	if err := f.refreshTokenIfNecessary(ctx); err != nil {
+		return nil, err
+	}
+	f.startTokenRenewer()
+	if f.opt.EnablePerformanceLog {
+		f.startPerformanceMonitoring(ctx)
+	}
+
+	if err := f.verifyRootFolderID(ctx); err != nil {
+		return nil, err
+	}
+	if err := f.verifyProgressDirWritable(); err != nil {
+		return nil, err
+	}
+
+	if f.root == "" {
+		return f, nil
+	}
+
+	// If root points at a file rather than a directory, adjust root and
+	// signal ErrorIsFile, following the convention used by every other
+	// backend's NewFs.
+	if fileID, err := f.pathToFileID(ctx, f.root, false); err == nil {
+		if info, err := f.getFileInfo(ctx, fileID); err == nil && info.Type == 0 {
+			newRoot, _ := path.Split(f.root)
+			f.root = strings.TrimSuffix(newRoot, "/")
+			return f, fs.ErrorIsFile
+		}
+	}
+	return f, nil
+}
+
+// verifyRootFolderID confirms a configured root_folder_id actually exists
+// and is a directory, so a typo surfaces immediately at startup with a
+// clear message instead of showing up later as a confusing "parentFileID
+// not found" error from deep inside an upload. "0" (the true root) is
+// never checked since it's always valid.
+func (f *Fs) verifyRootFolderID(ctx context.Context) error {
+	if f.rootFolderID == 0 {
+		return nil
+	}
+	info, err := f.getFileInfo(ctx, f.rootFolderID)
+	if err != nil {
+		return errors.Wrapf(err, "root_folder_id %d", f.rootFolderID)
+	}
+	if info.Type != 1 {
+		return errors.Errorf("root_folder_id %d is not a directory", f.rootFolderID)
+	}
+	return nil
+}
+
+// verifyProgressDirWritable confirms progress_dir (or, if unset, the
+// system temp directory) can actually be written to, so a misconfigured
+// or read-only directory is caught immediately at startup rather than
+// surfacing deep inside a transfer as a confusing "permission denied"
+// from memoryBufferedCrossCloudTransfer or saveResumeRecord.
+func (f *Fs) verifyProgressDirWritable() error {
+	dir, err := f.progressDir()
+	if err != nil {
+		return errors.Wrap(err, "progress_dir")
+	}
+	probe, err := ioutil.TempFile(dir, ".123-progress-dir-check-*")
+	if err != nil {
+		return errors.Wrap(err, "progress_dir is not writable")
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	return nil
+}
+
+// List the objects and directories in dir into entries
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	dirID, err := f.pathToFileID(ctx, path.Join(f.root, dir), true)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, err
+	}
+	infos, err := f.listDir(ctx, dirID)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Trashed != 0 {
+			continue
+		}
+		remote := path.Join(dir, f.opt.Enc.ToStandardName(info.Filename))
+		if info.Type != 1 && f.skipRejectedListEntry(remote, &info) {
+			continue
+		}
+		if info.Type == 1 {
+			dirModTime, _ := f.parseTime(info)
+			d := fs.NewDir(remote, dirModTime).SetID(strconv.FormatInt(info.FileID, 10))
+			entries = append(entries, d)
+		} else {
+			o, err := f.newObjectFromInfo(remote, &info)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, o)
+		}
+	}
+	return entries, nil
+}
+
+// listDir lists every file under parentID, paging through the API and
+// caching the result.
+func (f *Fs) listDir(ctx context.Context, parentID int64) ([]api.FileInfo, error) {
+	var all []api.FileInfo
+	lastFileID := int64(0)
+	pagesLimit := f.opt.ListAllPagesLimit
+	if pagesLimit <= 0 {
+		pagesLimit = defaultListAllPagesLimit
+	}
+	for page := 0; ; page++ {
+		if page >= pagesLimit {
+			return nil, errors.Errorf("aborting list of directory %d after %d pages without a terminating lastFileId - increase --123-list-all-pages-limit if this directory is genuinely that large", parentID, pagesLimit)
+		}
+		var result api.FileListResponse
+		req := api.FileListRequest{
+			ParentFileID: parentID,
+			Limit:        listChunkSize,
+			LastFileID:   lastFileID,
+		}
+		opts := rest.Opts{
+			Method: "POST",
+			Path:   "/api/v2/file/list",
+		}
+		err := f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+			return f.shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list directory")
+		}
+		if !result.OK() {
+			return nil, errors.Errorf("failed to list directory: %s", result.Message)
+		}
+		all = append(all, result.Data.FileList...)
+		if result.Data.LastFileID < 0 || len(result.Data.FileList) == 0 {
+			break
+		}
+		lastFileID = result.Data.LastFileID
+	}
+	ids := make([]int64, 0, len(all))
+	for _, info := range all {
+		ids = append(ids, info.FileID)
+	}
+	f.saveDirListToCache(parentID, ids)
+	return all, nil
+}
+
+// listRDir is a directory discovered while walking, carrying enough
+// context to build remotes and recurse into children.
+type listRDir struct {
+	id     int64
+	remote string
+}
+
+// ListR lists the objects and directories under dir recursively in one
+// pass, using a breadth-first queue so that every directory at a given
+// depth is listed (via listDir, and its cache) before any directory one
+// level deeper, rather than through repeated top-down List calls.
+//
+// Each discovered directory's path->fileID and fileID->parent mappings
+// are warmed in the same caches pathToFileID itself populates, so
+// operations that follow a ListR-backed sync (e.g. resolving a single
+// path to check a move's destination) stay served from cache instead of
+// re-walking.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	dirID, err := f.pathToFileID(ctx, path.Join(f.root, dir), false)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return fs.ErrorDirNotFound
+		}
+		return err
+	}
+
+	queue := []listRDir{{id: dirID, remote: dir}}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		current := queue[0]
+		queue = queue[1:]
+
+		infos, err := f.listDir(ctx, current.id)
+		if err != nil {
+			return err
+		}
+		var entries fs.DirEntries
+		for _, info := range infos {
+			if info.Trashed != 0 {
+				continue
+			}
+			remote := path.Join(current.remote, f.opt.Enc.ToStandardName(info.Filename))
+			if info.Type != 1 && f.skipRejectedListEntry(remote, &info) {
+				continue
+			}
+			fullPath := strings.ToLower(path.Join(f.root, remote))
+			if info.Type == 1 {
+				dirModTime, _ := f.parseTime(info)
+				d := fs.NewDir(remote, dirModTime).SetID(strconv.FormatInt(info.FileID, 10))
+				entries = append(entries, d)
+				queue = append(queue, listRDir{id: info.FileID, remote: remote})
+				f.savePathToIDToCache(fullPath, info.FileID)
+				f.saveParentToCache(info.FileID, current.id)
+			} else {
+				o, err := f.newObjectFromInfo(remote, &info)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, o)
+				f.saveParentToCache(info.FileID, current.id)
+			}
+		}
+		if len(entries) > 0 {
+			if err := callback(entries); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseFileTime parses a single 123 Pan timestamp, as found in CreateAt
+// or UpdateAt, falling back to now if it can't be parsed.
+func parseFileTime(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// parseTime returns (modTime, otherTime) for info, where modTime is
+// selected by --123-list-modtime-source (CreateAt or UpdateAt, default
+// UpdateAt) and otherTime is whichever of the two wasn't selected, kept
+// around so it can still be surfaced via Object.Metadata.
+func (f *Fs) parseTime(info api.FileInfo) (modTime, otherTime time.Time) {
+	created := parseFileTime(info.CreateAt)
+	updated := parseFileTime(info.UpdateAt)
+	if f.opt.ListModTimeSource == "created" {
+		return created, updated
+	}
+	return updated, created
+}
+
+// newObjectFromInfo creates an fs.Object from an api.FileInfo
+func (f *Fs) newObjectFromInfo(remote string, info *api.FileInfo) (fs.Object, error) {
+	modTime, otherTime := f.parseTime(*info)
+	o := &Object{
+		fs:          f,
+		remote:      remote,
+		id:          info.FileID,
+		size:        info.Size,
+		md5sum:      info.Etag,
+		modTime:     modTime,
+		altModTime:  otherTime,
+		category:    info.Category,
+		status:      info.Status,
+		punishFlag:  info.PunishFlag,
+		trashed:     info.Trashed,
+		hasMetaData: true,
+	}
+	return o, nil
+}
+
+// NewObject finds the Object at remote
+//
+// Immediately after a successful upload the file can briefly be in a
+// server-side "under review" window where upload_complete already
+// reported success but a lookup still returns ErrorObjectNotFound. To
+// smooth over that window this retries the lookup for up to
+// --123-review-window before giving up.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	fullPath := path.Join(f.root, remote)
+	info, err := retryNotFound(ctx, time.Now().Add(time.Duration(f.opt.ReviewWindow)), func() (*api.FileInfo, error) {
+		return f.lookupFileInfo(ctx, fullPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info.Type == 1 {
+		return nil, fs.ErrorNotAFile
+	}
+	return f.newObjectFromInfo(remote, info)
+}
+
+// objectByID resolves fileID directly via getFileInfo, skipping the
+// directory-listing walk pathToFileID needs when only a path is known.
+// This is for callers that already hold a fileID from a prior list or
+// from another backend command; the resulting Object's remote is filled
+// in via idToPath so it behaves exactly like one NewObject would return.
+func (f *Fs) objectByID(ctx context.Context, fileID int64) (fs.Object, error) {
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if info.Type == 1 {
+		return nil, fs.ErrorNotAFile
+	}
+	remote, err := f.idToPath(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObjectFromInfo(remote, info)
+}
+
+// lookupFileInfo resolves fullPath to a fileID and fetches its metadata
+func (f *Fs) lookupFileInfo(ctx context.Context, fullPath string) (*api.FileInfo, error) {
+	fileID, err := f.pathToFileID(ctx, fullPath, false)
+	if err != nil {
+		return nil, err
+	}
+	return f.getFileInfo(ctx, fileID)
+}
+
+// retryNotFound calls fn, retrying with calculateRetryDelay's jittered
+// exponential backoff as long as it keeps returning
+// fs.ErrorObjectNotFound and deadline hasn't passed yet. This smooths
+// over the "file under review" window right after a successful upload.
+func retryNotFound(ctx context.Context, deadline time.Time, fn func() (*api.FileInfo, error)) (*api.FileInfo, error) {
+	for attempt := 0; ; attempt++ {
+		info, err := fn()
+		if err != fs.ErrorObjectNotFound || time.Now().After(deadline) {
+			return info, err
+		}
+		select {
+		case <-time.After(calculateRetryDelay(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// getFileInfo fetches the metadata for a single fileID. Like
+// /api/v1/file/rename, /api/v1/file/detail has no documented QPS limit of
+// its own, so it's paced the same way as every other call.
+func (f *Fs) getFileInfo(ctx context.Context, fileID int64) (*api.FileInfo, error) {
+	var result struct {
+		api.Response
+		Data api.FileInfo `json:"data"`
+	}
+	opts := rest.Opts{
+		Method:     "GET",
+		Path:       "/api/v1/file/detail",
+		Parameters: map[string][]string{"fileID": {strconv.FormatInt(fileID, 10)}},
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file metadata")
+	}
+	if !result.OK() {
+		return nil, fs.ErrorObjectNotFound
+	}
+	return &result.Data, nil
+}
+
+// reviewRejectedStatus is the threshold at or above which FileInfo.Status
+// indicates the server's content review rejected the file, rather than it
+// merely still being under review.
+const reviewRejectedStatus = 100
+
+// skipRejectedListEntry reports whether a content-review-rejected file
+// should be omitted from List/ListR output, the same as a trashed one.
+// With --123-show-rejected set it's kept instead, with a warning logged
+// so a listed-but-undownloadable file isn't mysterious.
+func (f *Fs) skipRejectedListEntry(remote string, info *api.FileInfo) bool {
+	if info.Status < reviewRejectedStatus {
+		return false
+	}
+	if !f.opt.ShowRejected {
+		return true
+	}
+	fs.Logf(f, "%s: rejected by content review (status %d), listed because show_rejected is set", remote, info.Status)
+	return false
+}
+
+// checkReviewStatus fetches fileID's metadata and returns an error if
+// content review has rejected it. Used after a successful upload when
+// --123-fail-on-review-reject is set, since upload_complete can report
+// success for a file that review later marks unusable.
+func (f *Fs) checkReviewStatus(ctx context.Context, fileID int64) error {
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return errors.Wrap(err, "failed to check review status")
+	}
+	if info.Status >= reviewRejectedStatus {
+		return errors.Errorf("upload rejected by content review (status %d)", info.Status)
+	}
+	return nil
+}
+
+// Mkdir creates the directory if it doesn't exist
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	_, err := f.pathToFileID(ctx, path.Join(f.root, dir), true)
+	return err
+}
+
+// Rmdir removes the directory if empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	dirID, err := f.pathToFileID(ctx, path.Join(f.root, dir), false)
+	if err != nil {
+		return err
+	}
+	infos, err := f.listDir(ctx, dirID)
+	if err != nil {
+		return err
+	}
+	if len(infos) > 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	return f.deleteFiles(ctx, []int64{dirID})
+}
+
+// deleteBatchSize caps how many fileIDs deleteFiles sends to
+// /api/v1/file/trash in a single call, so purging a large directory tree
+// doesn't exceed the API's per-request limit.
+const deleteBatchSize = listChunkSize
+
+// deleteFile trashes a single fileID, the common case of deleteFiles.
+func (f *Fs) deleteFile(ctx context.Context, fileID int64) error {
+	return f.deleteFiles(ctx, []int64{fileID})
+}
+
+// deleteFiles trashes the given fileIDs, deleteBatchSize at a time.
+func (f *Fs) deleteFiles(ctx context.Context, fileIDs []int64) error {
+	for start := 0; start < len(fileIDs); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(fileIDs) {
+			end = len(fileIDs)
+		}
+		if err := f.deleteFilesOnce(ctx, fileIDs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFilesOnce sends a single /api/v1/file/trash call for up to
+// deleteBatchSize fileIDs.
+func (f *Fs) deleteFilesOnce(ctx context.Context, fileIDs []int64) error {
+	req := api.DeleteRequest{FileIDs: fileIDs}
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/api/v1/file/trash",
+		NoResponse: true,
+	}
+	var result api.Response
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete files")
+	}
+	// A batch can span fileIDs from any number of unrelated parents, so
+	// unlike Move/Copy/Object.Update (see invalidateCachesForMove) there's
+	// no small, cheaply-known set of directories to target precisely -
+	// the full flush is the correct trade-off here.
+	f.clearCache()
+	return nil
+}
+
+// Put uploads content to remote, creating it if necessary
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	existingObj, err := f.NewObject(ctx, src.Remote())
+	switch err {
+	case nil:
+		return existingObj, existingObj.(*Object).Update(ctx, in, src, options...)
+	case fs.ErrorObjectNotFound:
+		return f.putUnchecked(ctx, in, src)
+	default:
+		return nil, err
+	}
+}
+
+// putUnchecked uploads content assuming it doesn't already exist at this path
+func (f *Fs) putUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo) (fs.Object, error) {
+	remote := src.Remote()
+	fullPath := path.Join(f.root, remote)
+	dir, leaf := path.Split(fullPath)
+	parentID, err := f.pathToFileID(ctx, strings.TrimSuffix(dir, "/"), true)
+	if err != nil {
+		return nil, err
+	}
+	o := &Object{fs: f, remote: remote}
+	if err := o.upload(ctx, in, src, parentID, f.opt.Enc.FromStandardName(leaf)); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// PutStream uploads content of unknown size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return f.Put(ctx, in, src, options...)
+}
+
+// getUserInfo fetches the account's quota and VIP status
+func (f *Fs) getUserInfo(ctx context.Context) (*api.UserInfoResponse, error) {
+	var result api.UserInfoResponse
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/api/v1/user/info",
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read quota")
+	}
+	if !result.OK() {
+		return nil, errors.Errorf("failed to read quota: %s", result.Message)
+	}
+	return &result, nil
+}
+
+// userInfoCacheTTL is how long getUserInfoCached trusts a previous
+// user/info response before calling getUserInfo again. It's deliberately
+// just long enough to absorb a tight burst of calls (e.g. the "quota"
+// command run a few times in a row) without going stale against the
+// underlying endpoint's own ~4 QPS server-side rate limit.
+const userInfoCacheTTL = 500 * time.Millisecond
+
+// getUserInfoCached returns a recent user/info response, calling
+// getUserInfo again only if the cached one is older than userInfoCacheTTL.
+func (f *Fs) getUserInfoCached(ctx context.Context) (*api.UserInfoResponse, error) {
+	f.userInfoCacheMu.Lock()
+	if f.userInfoCache != nil && time.Since(f.userInfoCachedAt) < userInfoCacheTTL {
+		cached := f.userInfoCache
+		f.userInfoCacheMu.Unlock()
+		return cached, nil
+	}
+	f.userInfoCacheMu.Unlock()
+
+	info, err := f.getUserInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.userInfoCacheMu.Lock()
+	f.userInfoCache = info
+	f.userInfoCachedAt = time.Now()
+	f.userInfoCacheMu.Unlock()
+	return info, nil
+}
+
+// spaceTempExpiry parses data.spaceTempExpr, which is an RFC3339
+// timestamp, or the zero time and false if there's no temporary quota
+// (spaceTempExpr is empty) or it can't be parsed.
+func spaceTempExpiry(data *api.UserInfoResponse) (time.Time, bool) {
+	if data.Data.SpaceTempExpr == "" {
+		return time.Time{}, false
+	}
+	expiry, err := time.Parse(time.RFC3339, data.Data.SpaceTempExpr)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiry, true
+}
+
+// usableSpaceTemp returns data.SpaceTemp, or 0 if it has expired
+// according to spaceTempExpr, so that expired temporary (VIP/trial)
+// quota isn't counted towards Free.
+func usableSpaceTemp(data *api.UserInfoResponse) int64 {
+	expiry, ok := spaceTempExpiry(data)
+	if ok && !expiry.After(time.Now()) {
+		return 0
+	}
+	return data.Data.SpaceTemp
+}
+
+// About reports quota information. Temporary (VIP/trial) space that has
+// already expired per spaceTempExpr is not counted towards Total/Free.
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	result, err := f.getUserInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	used := result.Data.SpaceUsed
+	total := result.Data.SpacePermanent + usableSpaceTemp(result)
+	free := total - used
+	return &fs.Usage{
+		Total: fs.NewUsageValue(total),
+		Used:  fs.NewUsageValue(used),
+		Free:  fs.NewUsageValue(free),
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Object
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info { return o.fs }
+
+// Remote returns the remote path
+func (o *Object) Remote() string { return o.remote }
+
+// String returns a description of the Object
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// ModTime returns the modification time of the object
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.modTime
+}
+
+// Size returns the size of the object
+func (o *Object) Size() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.size
+}
+
+// Storable returns whether this object is storable
+func (o *Object) Storable() bool { return true }
+
+// Hash returns the MD5 of an object, or its SHA1 if
+// --123-compute-sha1-on-upload computed one locally during this session's
+// upload of it - the API itself carries no server-side SHA1 to fall back
+// to, so a SHA1 request for an object this process didn't itself upload
+// is unsupported.
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	if t == hash.SHA1 {
+		o.mu.Lock()
+		sha1sum := o.sha1sum
+		o.mu.Unlock()
+		if sha1sum == "" {
+			return "", hash.ErrUnsupported
+		}
+		return sha1sum, nil
+	}
+	if t != hash.MD5 {
+		return "", hash.ErrUnsupported
+	}
+	o.mu.Lock()
+	md5sum, id := o.md5sum, o.id
+	o.mu.Unlock()
+	if md5sum != "" || id == 0 {
+		return md5sum, nil
+	}
+	// The listing that produced this Object carried no Etag, which
+	// happens for a file that's still processing server-side right after
+	// upload. Fetch its metadata directly rather than returning a
+	// perpetually empty hash, since that would silently break
+	// --checksum-based sync.
+	info, err := o.fs.getFileInfo(ctx, id)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fetch md5 lazily")
+	}
+	o.mu.Lock()
+	o.md5sum = info.Etag
+	o.mu.Unlock()
+	return info.Etag, nil
+}
+
+// SetModTime is not supported by the 123 Pan API
+func (o *Object) SetModTime(ctx context.Context, t time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// ID returns the file ID of the Object
+func (o *Object) ID() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return strconv.FormatInt(o.id, 10)
+}
+
+// Remove deletes the object
+func (o *Object) Remove(ctx context.Context) error {
+	o.mu.Lock()
+	id := o.id
+	o.mu.Unlock()
+	return o.fs.deleteFile(ctx, id)
+}
+
+// Metadata returns 123 Pan's category, review status, punishFlag and
+// trashed fields alongside the timestamp not selected by
+// --123-list-modtime-source as "created" or "modified" (whichever one
+// ModTime didn't already use). All of it was already present on the
+// api.FileInfo this Object was built from, so this never makes a
+// further API call.
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	o.mu.Lock()
+	altModTime := o.altModTime
+	category, status, punishFlag, trashed := o.category, o.status, o.punishFlag, o.trashed
+	hasMetaData := o.hasMetaData
+	o.mu.Unlock()
+	if !hasMetaData {
+		return nil, nil
+	}
+	md := map[string]string{
+		"category":   strconv.Itoa(category),
+		"status":     strconv.Itoa(status),
+		"punishFlag": strconv.Itoa(punishFlag),
+		"trashed":    strconv.Itoa(trashed),
+	}
+	if !altModTime.IsZero() {
+		key := "created"
+		if o.fs.opt.ListModTimeSource == "created" {
+			key = "modified"
+		}
+		md[key] = altModTime.Format(time.RFC3339)
+	}
+	return md, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs        = (*Fs)(nil)
+	_ fs.Commander = (*Fs)(nil)
+	_ fs.Abouter   = (*Fs)(nil)
+	_ fs.ListRer   = (*Fs)(nil)
+	_ fs.Object    = (*Object)(nil)
+	_ fs.IDer      = (*Object)(nil)
+)
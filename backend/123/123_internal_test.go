@@ -0,0 +1,51 @@
+package _123
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashVerifyReadCloser checks that opening with an MD5 HashesOption
+// computes the correct hash once the stream has been fully read.
+func TestHashVerifyReadCloser(t *testing.T) {
+	const content = "The quick brown fox jumps over the lazy dog"
+	const wantMD5 = "9e107d9d372bb6826bd81d3542a419d6"
+
+	o := &Object{md5sum: wantMD5, size: int64(len(content))}
+	hasher, err := hash.NewMultiHasherTypes(hash.NewHashSet(hash.MD5))
+	require.NoError(t, err)
+
+	rc := &hashVerifyReadCloser{
+		o:      o,
+		in:     ioutil.NopCloser(strings.NewReader(content)),
+		hasher: hasher,
+	}
+	data, err := ioutil.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.NoError(t, rc.Close())
+}
+
+// TestHashVerifyReadCloserMismatch checks that a mismatched MD5 is reported
+// as an error on Close.
+func TestHashVerifyReadCloserMismatch(t *testing.T) {
+	const content = "The quick brown fox jumps over the lazy dog"
+
+	o := &Object{md5sum: "deadbeefdeadbeefdeadbeefdeadbeef", size: int64(len(content))}
+	hasher, err := hash.NewMultiHasherTypes(hash.NewHashSet(hash.MD5))
+	require.NoError(t, err)
+
+	rc := &hashVerifyReadCloser{
+		o:      o,
+		in:     ioutil.NopCloser(strings.NewReader(content)),
+		hasher: hasher,
+	}
+	_, err = ioutil.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Error(t, rc.Close())
+}
@@ -0,0 +1,17 @@
+// Test 123 Pan filesystem interface
+package _123_test
+
+import (
+	"testing"
+
+	_123 "github.com/rclone/rclone/backend/123"
+	"github.com/rclone/rclone/fstest/fstests"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestPan123:",
+		NilObject:  (*_123.Object)(nil),
+	})
+}
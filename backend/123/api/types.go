@@ -0,0 +1,228 @@
+// Package api provides types used by the 123 Pan (123pan.com) cloud
+// storage API.
+package api
+
+// Response is the common envelope wrapping every 123 Pan API response.
+type Response struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	XTraceID string `json:"x-traceID,omitempty"`
+}
+
+// OK returns whether the response indicates success
+func (r *Response) OK() bool {
+	return r.Code == 0
+}
+
+// TokenRequest is the body sent to obtain an access token
+type TokenRequest struct {
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+}
+
+// TokenResponse is returned by the access_token endpoint
+type TokenResponse struct {
+	Response
+	Data struct {
+		AccessToken string `json:"accessToken"`
+		ExpiredAt   string `json:"expiredAt"` // RFC3339 timestamp
+	} `json:"data"`
+}
+
+// FileInfo describes a single file or directory as returned by the API
+type FileInfo struct {
+	FileID       int64  `json:"fileID"`
+	Filename     string `json:"filename"`
+	ParentFileID int64  `json:"parentFileID"`
+	Type         int    `json:"type"` // 0 = file, 1 = directory
+	Etag         string `json:"etag"` // MD5
+	Size         int64  `json:"size"`
+	Category     int    `json:"category"`
+	Status       int    `json:"status"` // 0 = normal, other = under review/rejected
+	Trashed      int    `json:"trashed"`
+	PunishFlag   int    `json:"punishFlag"` // non-zero if the file was penalised for a content violation
+	CreateAt     string `json:"createAt"`
+	UpdateAt     string `json:"updateAt"`
+}
+
+// FileListRequest is used to page through a directory listing
+type FileListRequest struct {
+	ParentFileID int64  `json:"parentFileId"`
+	Limit        int    `json:"limit"`
+	SearchData   string `json:"searchData,omitempty"`
+	SearchMode   int    `json:"searchMode,omitempty"`
+	LastFileID   int64  `json:"lastFileId,omitempty"`
+}
+
+// FileListResponse is the paginated response of a directory listing
+type FileListResponse struct {
+	Response
+	Data struct {
+		LastFileID int64      `json:"lastFileId"` // -1 means no more pages
+		FileList   []FileInfo `json:"fileList"`
+	} `json:"data"`
+}
+
+// MkdirRequest creates a new directory
+type MkdirRequest struct {
+	Name     string `json:"name"`
+	ParentID int64  `json:"parentID"`
+}
+
+// MkdirResponse is returned after creating a directory
+type MkdirResponse struct {
+	Response
+	Data struct {
+		DirID int64 `json:"dirID"`
+	} `json:"data"`
+}
+
+// UploadCreateRequest starts a new upload (preupload) session
+type UploadCreateRequest struct {
+	ParentFileID int64  `json:"parentFileID"`
+	Filename     string `json:"filename"`
+	Etag         string `json:"etag"` // MD5 of the whole file, used for instant upload
+	Size         int64  `json:"size"`
+	Duplicate    int    `json:"duplicate,omitempty"` // 1 = keep both, 2 = overwrite
+}
+
+// UploadCreateResponse is returned when a preupload session is created
+type UploadCreateResponse struct {
+	Response
+	Data struct {
+		FileID      int64    `json:"fileID"` // set when the file already exists server-side (instant upload)
+		PreuploadID string   `json:"preuploadID"`
+		Reuse       bool     `json:"reuse"` // true if this was an instant ("秒传") upload
+		SliceSize   int64    `json:"sliceSize"`
+		Servers     []string `json:"servers"`
+	} `json:"data"`
+}
+
+// UploadSliceResponse is returned after a slice has been uploaded
+type UploadSliceResponse struct {
+	Response
+}
+
+// UploadCompleteRequest finalizes a multipart upload
+type UploadCompleteRequest struct {
+	PreuploadID string `json:"preuploadID"`
+}
+
+// UploadCompleteResponse reports whether the file is ready to use yet
+type UploadCompleteResponse struct {
+	Response
+	Data struct {
+		Completed bool  `json:"completed"`
+		FileID    int64 `json:"fileID"`
+	} `json:"data"`
+}
+
+// UploadAbortRequest cancels an in-progress preupload session
+type UploadAbortRequest struct {
+	PreuploadID string `json:"preuploadID"`
+}
+
+// DeleteRequest trashes or permanently deletes one or more files
+type DeleteRequest struct {
+	FileIDs []int64 `json:"fileIDs"`
+}
+
+// MoveRequest moves one or more files to a new parent directory
+type MoveRequest struct {
+	FileIDs        []int64 `json:"fileIDs"`
+	ToParentFileID int64   `json:"toParentFileID"`
+}
+
+// RestoreRequest restores one or more trashed files to their original parent
+type RestoreRequest struct {
+	FileIDs []int64 `json:"fileIDs"`
+}
+
+// RenameRequest renames a single file
+type RenameRequest struct {
+	FileID int64  `json:"fileId"`
+	Name   string `json:"fileName"`
+}
+
+// CopyRequest copies one or more files to a new parent directory
+type CopyRequest struct {
+	FileIDs        []int64 `json:"fileIDs"`
+	ToParentFileID int64   `json:"toParentFileID"`
+}
+
+// UserInfoResponse reports account quota and VIP status
+type UserInfoResponse struct {
+	Response
+	Data struct {
+		UID            int64  `json:"uid"`
+		Nickname       string `json:"nickname"`
+		SpaceUsed      int64  `json:"spaceUsed"`
+		SpacePermanent int64  `json:"spacePermanent"`
+		SpaceTemp      int64  `json:"spaceTemp"`
+		SpaceTempExpr  string `json:"spaceTempExpr"` // RFC3339 expiry of the temporary quota, empty if none
+		VIP            bool   `json:"vip"`
+	} `json:"data"`
+}
+
+// DownloadInfoResponse contains a short-lived download URL for a file.
+// ExpireAt, when present, is an RFC3339 timestamp of when DownloadURL
+// stops working - not every deployment of the API returns it, so callers
+// must tolerate it being empty.
+type DownloadInfoResponse struct {
+	Response
+	Data struct {
+		DownloadURL string `json:"downloadUrl"`
+		ExpireAt    string `json:"expireAt"`
+	} `json:"data"`
+}
+
+// ShareCreateRequest creates a public share link for one or more files
+// or directories. ShareExpire is in days - the API only accepts 1, 7, 30
+// or 0 (permanent) - and SharePwd is left empty for a link with no
+// extraction code.
+type ShareCreateRequest struct {
+	ShareName   string `json:"shareName"`
+	ShareExpire int    `json:"shareExpire"`
+	FileIDList  string `json:"fileIDList"`
+	SharePwd    string `json:"sharePwd,omitempty"`
+}
+
+// ShareCreateResponse returns the new share's key, which combines with
+// the site's fixed share URL prefix to form the shareable link
+type ShareCreateResponse struct {
+	Response
+	Data struct {
+		ShareID  int64  `json:"shareID"`
+		ShareKey string `json:"shareKey"`
+	} `json:"data"`
+}
+
+// OfflineDownloadRequest starts an offline (cloud) download of a magnet
+// link or HTTP(S) URL directly into the user's drive, without it passing
+// through the uploading machine.
+type OfflineDownloadRequest struct {
+	URL      string `json:"url"`
+	FileName string `json:"fileName,omitempty"`
+	DirID    int64  `json:"dirID"`
+}
+
+// OfflineDownloadResponse returns the new task's ID, used to poll its
+// progress via OfflineTaskProcessResponse.
+type OfflineDownloadResponse struct {
+	Response
+	Data struct {
+		TaskID int64 `json:"taskID"`
+	} `json:"data"`
+}
+
+// OfflineTaskProcessResponse reports an offline-download task's progress.
+// Status: 0 = pending/downloading, 1 = done, 2 = failed. Process is a
+// percentage (0-100).
+type OfflineTaskProcessResponse struct {
+	Response
+	Data struct {
+		Status  int   `json:"status"`
+		Process int   `json:"process"`
+		FileID  int64 `json:"fileID"`
+	} `json:"data"`
+}
@@ -0,0 +1,432 @@
+package _123
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// CacheConfig controls how long the various in-memory lookup caches are
+// trusted before the backend falls back to the API.
+//
+// All three TTLs default to a unified 5 minutes, but can be tuned
+// independently via --123-parent-cache-ttl, --123-dir-cache-ttl and
+// --123-path-cache-ttl for users with different freshness/performance
+// needs.
+type CacheConfig struct {
+	ParentCacheTTL time.Duration // how long a child->parent fileID mapping is trusted
+	DirCacheTTL    time.Duration // how long a directory listing is trusted
+	PathCacheTTL   time.Duration // how long a path->fileID mapping is trusted
+}
+
+// DefaultCacheConfig returns the default cache TTLs used when the backend
+// is not configured otherwise.
+func DefaultCacheConfig() CacheConfig {
+	const unifiedTTL = 5 * time.Minute
+	return CacheConfig{
+		ParentCacheTTL: unifiedTTL,
+		DirCacheTTL:    unifiedTTL,
+		PathCacheTTL:   unifiedTTL,
+	}
+}
+
+// cacheConfigFromOptions builds a CacheConfig from the --123-parent-cache-ttl,
+// --123-dir-cache-ttl and --123-path-cache-ttl options, each falling back to
+// the unified 5 minute default when left at 0.
+func cacheConfigFromOptions(opt *Options) (CacheConfig, error) {
+	cfg := DefaultCacheConfig()
+	for name, ttl := range map[string]fs.Duration{
+		"parent_cache_ttl": opt.ParentCacheTTL,
+		"dir_cache_ttl":    opt.DirCacheTTL,
+		"path_cache_ttl":   opt.PathCacheTTL,
+	} {
+		if ttl < 0 {
+			return CacheConfig{}, errors.Errorf("%s must not be negative", name)
+		}
+	}
+	if opt.ParentCacheTTL > 0 {
+		cfg.ParentCacheTTL = time.Duration(opt.ParentCacheTTL)
+	}
+	if opt.DirCacheTTL > 0 {
+		cfg.DirCacheTTL = time.Duration(opt.DirCacheTTL)
+	}
+	if opt.PathCacheTTL > 0 {
+		cfg.PathCacheTTL = time.Duration(opt.PathCacheTTL)
+	}
+	return cfg, nil
+}
+
+// dirCacheEntry is a cached directory listing
+type dirCacheEntry struct {
+	fileIDs  []int64
+	cachedAt time.Time
+}
+
+// pathCacheEntry is a cached path -> fileID lookup
+type pathCacheEntry struct {
+	fileID   int64
+	cachedAt time.Time
+}
+
+// parentCacheEntry is a cached fileID -> parent fileID lookup
+type parentCacheEntry struct {
+	parentID int64
+	cachedAt time.Time
+}
+
+// treeSizeEntry is a cached recursive total size, file count and directory
+// count for a directory's subtree, as computed by commandTreeSize.
+type treeSizeEntry struct {
+	totalSize int64
+	fileCount int64
+	dirCount  int64
+	cachedAt  time.Time
+}
+
+// downloadURLEntry is a cached download_info result for a single fileID.
+type downloadURLEntry struct {
+	url       string
+	expiresAt time.Time
+}
+
+// negativePathCacheTTL is how long a "this path doesn't exist" tombstone
+// is trusted. It is deliberately short and fixed, unlike the positive
+// path cache's configurable TTL, since a false negative (missing a file
+// that was just created by someone else) is more costly than a false
+// positive re-walk.
+const negativePathCacheTTL = 10 * time.Second
+
+// negativePathCacheEntry is a cached "this path doesn't exist" tombstone.
+// parentID is recorded alongside the timestamp so a create under that
+// parent can invalidate just the tombstones it might affect.
+type negativePathCacheEntry struct {
+	parentID int64
+	cachedAt time.Time
+}
+
+// cacheState holds all the in-memory caches used to avoid re-walking
+// paths and re-listing directories on every operation.
+type cacheState struct {
+	mu                sync.Mutex
+	dirCache          map[int64]dirCacheEntry           // parentFileID -> children
+	pathCache         map[string]pathCacheEntry         // lower-cased full path -> fileID
+	negativePathCache map[string]negativePathCacheEntry // lower-cased full path -> tombstone
+	parentCache       map[int64]parentCacheEntry        // fileID -> parent fileID
+	treeSizeCache     map[int64]treeSizeEntry           // dirFileID -> recursive size/count
+	downloadURLCache  map[int64]downloadURLEntry        // fileID -> cached download URL
+}
+
+func newCacheState() *cacheState {
+	return &cacheState{
+		dirCache:          make(map[int64]dirCacheEntry),
+		pathCache:         make(map[string]pathCacheEntry),
+		negativePathCache: make(map[string]negativePathCacheEntry),
+		parentCache:       make(map[int64]parentCacheEntry),
+		treeSizeCache:     make(map[int64]treeSizeEntry),
+		downloadURLCache:  make(map[int64]downloadURLEntry),
+	}
+}
+
+// saveDirListToCache records the children of parentID
+func (f *Fs) saveDirListToCache(parentID int64, fileIDs []int64) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.dirCache[parentID] = dirCacheEntry{fileIDs: fileIDs, cachedAt: time.Now()}
+}
+
+// getDirListFromCache returns the cached children of parentID, if still fresh
+func (f *Fs) getDirListFromCache(parentID int64) ([]int64, bool) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	entry, ok := f.cache.dirCache[parentID]
+	if !ok || f.cacheConfig.DirCacheTTL <= 0 || time.Since(entry.cachedAt) > f.cacheConfig.DirCacheTTL {
+		return nil, false
+	}
+	return entry.fileIDs, true
+}
+
+// savePathToIDToCache records that path resolves to fileID
+func (f *Fs) savePathToIDToCache(path string, fileID int64) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.pathCache[path] = pathCacheEntry{fileID: fileID, cachedAt: time.Now()}
+}
+
+// getPathFromCache returns the cached fileID for path, if still fresh
+func (f *Fs) getPathFromCache(path string) (int64, bool) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	entry, ok := f.cache.pathCache[path]
+	if !ok || f.cacheConfig.PathCacheTTL <= 0 || time.Since(entry.cachedAt) > f.cacheConfig.PathCacheTTL {
+		return 0, false
+	}
+	return entry.fileID, true
+}
+
+// saveNegativePathToCache records that path does not exist directly under
+// parentID, if --123-list-cache-negative is enabled.
+func (f *Fs) saveNegativePathToCache(path string, parentID int64) {
+	if !f.opt.ListCacheNegative {
+		return
+	}
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.negativePathCache[path] = negativePathCacheEntry{parentID: parentID, cachedAt: time.Now()}
+}
+
+// getNegativePathFromCache reports whether path was recently recorded as
+// not found and that record hasn't expired yet.
+func (f *Fs) getNegativePathFromCache(path string) bool {
+	if !f.opt.ListCacheNegative {
+		return false
+	}
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	entry, ok := f.cache.negativePathCache[path]
+	if !ok || time.Since(entry.cachedAt) > negativePathCacheTTL {
+		return false
+	}
+	return true
+}
+
+// invalidateNegativePathCacheForParent drops every negative path cache
+// entry recorded directly under parentID, so a fresh create there is
+// immediately visible to the next lookup instead of waiting out the
+// negative cache's TTL.
+func (f *Fs) invalidateNegativePathCacheForParent(parentID int64) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	for path, entry := range f.cache.negativePathCache {
+		if entry.parentID == parentID {
+			delete(f.cache.negativePathCache, path)
+		}
+	}
+}
+
+// saveParentToCache records that fileID's parent is parentID
+func (f *Fs) saveParentToCache(fileID, parentID int64) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.parentCache[fileID] = parentCacheEntry{parentID: parentID, cachedAt: time.Now()}
+}
+
+// getParentFromCache returns the cached parent of fileID, if still fresh
+func (f *Fs) getParentFromCache(fileID int64) (int64, bool) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	entry, ok := f.cache.parentCache[fileID]
+	if !ok || f.cacheConfig.ParentCacheTTL <= 0 || time.Since(entry.cachedAt) > f.cacheConfig.ParentCacheTTL {
+		return 0, false
+	}
+	return entry.parentID, true
+}
+
+// saveTreeSizeToCache records the recursive size, file count and directory
+// count of dirID's subtree
+func (f *Fs) saveTreeSizeToCache(dirID, totalSize, fileCount, dirCount int64) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.treeSizeCache[dirID] = treeSizeEntry{totalSize: totalSize, fileCount: fileCount, dirCount: dirCount, cachedAt: time.Now()}
+}
+
+// getTreeSizeFromCache returns the cached recursive size, file count and
+// directory count of dirID's subtree, if still fresh. It shares
+// DirCacheTTL with directory listings, since a tree size is only as
+// trustworthy as the listings it was derived from.
+func (f *Fs) getTreeSizeFromCache(dirID int64) (totalSize, fileCount, dirCount int64, ok bool) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	entry, ok := f.cache.treeSizeCache[dirID]
+	if !ok || f.cacheConfig.DirCacheTTL <= 0 || time.Since(entry.cachedAt) > f.cacheConfig.DirCacheTTL {
+		return 0, 0, 0, false
+	}
+	return entry.totalSize, entry.fileCount, entry.dirCount, true
+}
+
+// downloadURLSafetyWindow is how long before a cached download URL's
+// reported expiry it is treated as already expired, so a request doesn't
+// race the URL's actual expiration mid-transfer.
+const downloadURLSafetyWindow = 30 * time.Second
+
+// saveDownloadURLToCache records url as fileID's download URL, valid
+// until expiresAt. Callers must supply a concrete expiresAt even when the
+// API response didn't report one - see getDownloadURL's fallback TTL.
+func (f *Fs) saveDownloadURLToCache(fileID int64, url string, expiresAt time.Time) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.downloadURLCache[fileID] = downloadURLEntry{url: url, expiresAt: expiresAt}
+}
+
+// getDownloadURLFromCache returns fileID's cached download URL, if one is
+// on record and not within downloadURLSafetyWindow of its expiry.
+func (f *Fs) getDownloadURLFromCache(fileID int64) (string, bool) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	entry, ok := f.cache.downloadURLCache[fileID]
+	if !ok {
+		return "", false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().Add(downloadURLSafetyWindow).After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.url, true
+}
+
+// evictDownloadURLFromCache drops fileID's cached download URL, forcing
+// the next getDownloadURL call to fetch a fresh one. Used when a
+// transfer reports the cached URL as expired or otherwise invalid,
+// despite not yet having reached its reported expiry.
+func (f *Fs) evictDownloadURLFromCache(fileID int64) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	delete(f.cache.downloadURLCache, fileID)
+}
+
+// verifyParentFileID checks that fileID's cached parent, if any, still
+// matches expectedParentID. It returns false if the cache disagrees with
+// the caller's expectation, which callers can use to decide whether a
+// cached path lookup needs to be invalidated and re-resolved.
+func (f *Fs) verifyParentFileID(fileID, expectedParentID int64) bool {
+	parentID, ok := f.getParentFromCache(fileID)
+	if !ok {
+		return true
+	}
+	return parentID == expectedParentID
+}
+
+// clearCache drops every cached lookup, forcing the next operation to hit the API
+func (f *Fs) clearCache() {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.dirCache = make(map[int64]dirCacheEntry)
+	f.cache.pathCache = make(map[string]pathCacheEntry)
+	f.cache.negativePathCache = make(map[string]negativePathCacheEntry)
+	f.cache.parentCache = make(map[int64]parentCacheEntry)
+	f.cache.treeSizeCache = make(map[int64]treeSizeEntry)
+	f.cache.downloadURLCache = make(map[int64]downloadURLEntry)
+}
+
+// namedCacheSizes is used by clearNamedCache and the "cache-clear" backend
+// command to report how many entries a given in-memory cache held
+// immediately before it was dropped.
+type namedCacheSizes struct {
+	Dir          int `json:"dir"`
+	Path         int `json:"path"`
+	NegativePath int `json:"negativePath"`
+	Parent       int `json:"parent"`
+	TreeSize     int `json:"treeSize"`
+	DownloadURL  int `json:"downloadURL"`
+}
+
+// clearNamedCache drops just the in-memory cache matching name ("dir",
+// "path", "negative-path", "parent", "tree-size" or "download-url"),
+// leaving the others untouched, and returns how many entries it held.
+// An unrecognized name is an error rather than a silent no-op.
+func (f *Fs) clearNamedCache(name string) (namedCacheSizes, error) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	var sizes namedCacheSizes
+	switch name {
+	case "dir":
+		sizes.Dir = len(f.cache.dirCache)
+		f.cache.dirCache = make(map[int64]dirCacheEntry)
+	case "path":
+		sizes.Path = len(f.cache.pathCache)
+		f.cache.pathCache = make(map[string]pathCacheEntry)
+	case "negative-path":
+		sizes.NegativePath = len(f.cache.negativePathCache)
+		f.cache.negativePathCache = make(map[string]negativePathCacheEntry)
+	case "parent":
+		sizes.Parent = len(f.cache.parentCache)
+		f.cache.parentCache = make(map[int64]parentCacheEntry)
+	case "tree-size":
+		sizes.TreeSize = len(f.cache.treeSizeCache)
+		f.cache.treeSizeCache = make(map[int64]treeSizeEntry)
+	case "download-url":
+		sizes.DownloadURL = len(f.cache.downloadURLCache)
+		f.cache.downloadURLCache = make(map[int64]downloadURLEntry)
+	default:
+		return namedCacheSizes{}, errors.Errorf("unknown cache %q", name)
+	}
+	return sizes, nil
+}
+
+// sampleDirCache returns up to max entries from the directory listing
+// cache, regardless of TTL freshness, for diagnostic inspection.
+func (f *Fs) sampleDirCache(max int) map[int64][]int64 {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	sample := make(map[int64][]int64, max)
+	for id, entry := range f.cache.dirCache {
+		if len(sample) >= max {
+			break
+		}
+		sample[id] = append([]int64(nil), entry.fileIDs...)
+	}
+	return sample
+}
+
+// samplePathCache returns up to max entries from the path->fileID cache,
+// regardless of TTL freshness, for diagnostic inspection.
+func (f *Fs) samplePathCache(max int) map[string]int64 {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	sample := make(map[string]int64, max)
+	for path, entry := range f.cache.pathCache {
+		if len(sample) >= max {
+			break
+		}
+		sample[path] = entry.fileID
+	}
+	return sample
+}
+
+// evictExpiredCacheEntries proactively removes entries whose TTL has
+// already passed, rather than leaving them to be skipped lazily the next
+// time they're looked up. This is purely a memory reclamation step: an
+// expired entry left in place costs nothing correctness-wise, since every
+// lookup already re-checks its age.
+func (f *Fs) evictExpiredCacheEntries() (evicted int) {
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	now := time.Now()
+	for id, entry := range f.cache.dirCache {
+		if f.cacheConfig.DirCacheTTL <= 0 || now.Sub(entry.cachedAt) > f.cacheConfig.DirCacheTTL {
+			delete(f.cache.dirCache, id)
+			evicted++
+		}
+	}
+	for path, entry := range f.cache.pathCache {
+		if f.cacheConfig.PathCacheTTL <= 0 || now.Sub(entry.cachedAt) > f.cacheConfig.PathCacheTTL {
+			delete(f.cache.pathCache, path)
+			evicted++
+		}
+	}
+	for path, entry := range f.cache.negativePathCache {
+		if now.Sub(entry.cachedAt) > negativePathCacheTTL {
+			delete(f.cache.negativePathCache, path)
+			evicted++
+		}
+	}
+	for id, entry := range f.cache.parentCache {
+		if f.cacheConfig.ParentCacheTTL <= 0 || now.Sub(entry.cachedAt) > f.cacheConfig.ParentCacheTTL {
+			delete(f.cache.parentCache, id)
+			evicted++
+		}
+	}
+	for id, entry := range f.cache.treeSizeCache {
+		if f.cacheConfig.DirCacheTTL <= 0 || now.Sub(entry.cachedAt) > f.cacheConfig.DirCacheTTL {
+			delete(f.cache.treeSizeCache, id)
+			evicted++
+		}
+	}
+	for id, entry := range f.cache.downloadURLCache {
+		if now.Add(downloadURLSafetyWindow).After(entry.expiresAt) {
+			delete(f.cache.downloadURLCache, id)
+			evicted++
+		}
+	}
+	return evicted
+}
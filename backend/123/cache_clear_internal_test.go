@@ -0,0 +1,109 @@
+package _123
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheClearTestFs(t *testing.T) *Fs {
+	dir, err := ioutil.TempDir("", "123-cache-clear-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return &Fs{
+		opt:         Options{ProgressDir: dir},
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+	}
+}
+
+func TestClearNamedCacheDropsOnlyTheNamedCache(t *testing.T) {
+	f := newCacheClearTestFs(t)
+	f.saveDirListToCache(1, []int64{2, 3})
+	f.savePathToIDToCache("a/b", 4)
+
+	sizes, err := f.clearNamedCache("dir")
+	require.NoError(t, err)
+	assert.Equal(t, 1, sizes.Dir)
+
+	_, ok := f.getDirListFromCache(1)
+	assert.False(t, ok)
+	_, ok = f.getPathFromCache("a/b")
+	assert.True(t, ok, "clearing the dir cache must not touch the path cache")
+}
+
+func TestClearNamedCacheRejectsUnknownName(t *testing.T) {
+	f := newCacheClearTestFs(t)
+	_, err := f.clearNamedCache("bogus")
+	assert.Error(t, err)
+}
+
+func TestCommandCacheClearAll(t *testing.T) {
+	f := newCacheClearTestFs(t)
+	f.saveDirListToCache(1, []int64{2, 3})
+	require.NoError(t, f.saveResumeRecord(&resumeRecord{Etag: "e", CreatedAt: time.Now()}))
+
+	res, err := f.commandCacheClear(context.Background(), "all")
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, "all", m["cleared"])
+	assert.Equal(t, 1, m["removedResumeRecords"])
+
+	_, ok := f.getDirListFromCache(1)
+	assert.False(t, ok)
+	rec, err := f.loadResumeRecord("e")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+}
+
+func TestCommandCacheClearResumeOnlyLeavesOtherCachesAlone(t *testing.T) {
+	f := newCacheClearTestFs(t)
+	f.saveDirListToCache(1, []int64{2, 3})
+	require.NoError(t, f.saveResumeRecord(&resumeRecord{Etag: "e", CreatedAt: time.Now()}))
+
+	res, err := f.commandCacheClear(context.Background(), "resume")
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, "resume", m["cleared"])
+	assert.Equal(t, 1, m["removedResumeRecords"])
+
+	_, ok := f.getDirListFromCache(1)
+	assert.True(t, ok, "cache-clear resume must not touch the in-memory caches")
+}
+
+func TestCommandCacheClearNamedDelegatesToClearNamedCache(t *testing.T) {
+	f := newCacheClearTestFs(t)
+	f.savePathToIDToCache("a/b", 4)
+
+	res, err := f.commandCacheClear(context.Background(), "path")
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, "path", m["cleared"])
+	sizes := m["sizes"].(namedCacheSizes)
+	assert.Equal(t, 1, sizes.Path)
+}
+
+func TestCommandDispatchesCacheClear(t *testing.T) {
+	f := newCacheClearTestFs(t)
+	f.saveDirListToCache(1, []int64{2, 3})
+
+	res, err := f.Command(context.Background(), "cache-clear", []string{"dir"}, nil)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, "dir", m["cleared"])
+}
+
+func TestCommandDispatchesCacheClearWithNoArgument(t *testing.T) {
+	f := newCacheClearTestFs(t)
+
+	res, err := f.Command(context.Background(), "cache-clear", nil, nil)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, "all", m["cleared"])
+}
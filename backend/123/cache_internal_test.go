@@ -0,0 +1,63 @@
+package _123
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheConfigFromOptions(t *testing.T) {
+	// Defaults are unchanged when nothing is configured
+	cfg, err := cacheConfigFromOptions(&Options{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCacheConfig(), cfg)
+
+	// Each TTL can be tuned independently
+	opt := &Options{
+		ParentCacheTTL: fs.Duration(time.Minute),
+		DirCacheTTL:    fs.Duration(2 * time.Minute),
+		PathCacheTTL:   fs.Duration(3 * time.Minute),
+	}
+	cfg, err = cacheConfigFromOptions(opt)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, cfg.ParentCacheTTL)
+	assert.Equal(t, 2*time.Minute, cfg.DirCacheTTL)
+	assert.Equal(t, 3*time.Minute, cfg.PathCacheTTL)
+
+	// Negative TTLs are rejected
+	_, err = cacheConfigFromOptions(&Options{DirCacheTTL: fs.Duration(-time.Second)})
+	assert.Error(t, err)
+}
+
+func TestCacheTTLsReachTheCache(t *testing.T) {
+	f := &Fs{
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+	}
+
+	f.saveDirListToCache(42, []int64{1, 2, 3})
+	ids, ok := f.getDirListFromCache(42)
+	require.True(t, ok)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+
+	f.savePathToIDToCache("foo/bar", 7)
+	id, ok := f.getPathFromCache("foo/bar")
+	require.True(t, ok)
+	assert.Equal(t, int64(7), id)
+
+	f.saveParentToCache(7, 42)
+	assert.True(t, f.verifyParentFileID(7, 42))
+	assert.False(t, f.verifyParentFileID(7, 99))
+
+	// A TTL of 0 disables the cache entirely
+	f.cacheConfig.PathCacheTTL = 0
+	_, ok = f.getPathFromCache("foo/bar")
+	assert.False(t, ok)
+}
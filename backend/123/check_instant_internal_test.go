@@ -0,0 +1,88 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCheckInstantTestFs builds a fake server for the "check-instant"
+// command. createReuse controls whether /upload/v1/file/create reports an
+// instant-upload hit; abortCalls counts requests to /upload/v1/file/abort
+// so tests can confirm a real (non-reused) session gets cleaned up.
+func newCheckInstantTestFs(t *testing.T, createReuse bool) (f *Fs, abortCalls func() int) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/upload/v1/file/create":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"preuploadID": "pre-1",
+					"reuse":       createReuse,
+					"sliceSize":   1024,
+				},
+			})
+		case "/upload/v1/file/abort":
+			calls++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/api/v2/file/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": []interface{}{}},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		opt:         Options{ProgressDir: t.TempDir()},
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, func() int { return calls }
+}
+
+func TestCommandCheckInstantReportsReuse(t *testing.T) {
+	f, abortCalls := newCheckInstantTestFs(t, true)
+
+	result, err := f.commandCheckInstant(context.Background(), "file.bin", "deadbeef", 4096)
+	require.NoError(t, err)
+	m := result.(map[string]interface{})
+	assert.Equal(t, true, m["reuse"])
+	assert.Equal(t, 0, abortCalls(), "a reused session must not be aborted")
+}
+
+func TestCommandCheckInstantAbortsRealSession(t *testing.T) {
+	f, abortCalls := newCheckInstantTestFs(t, false)
+
+	result, err := f.commandCheckInstant(context.Background(), "file.bin", "deadbeef", 4096)
+	require.NoError(t, err)
+	m := result.(map[string]interface{})
+	assert.Equal(t, false, m["reuse"])
+	assert.Equal(t, 1, abortCalls(), "a real session must be aborted so it doesn't linger")
+}
+
+func TestCommandCheckInstantRejectsMissingDestinationDir(t *testing.T) {
+	f, _ := newCheckInstantTestFs(t, false)
+
+	_, err := f.commandCheckInstant(context.Background(), "nosuchdir/file.bin", "deadbeef", 4096)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+}
@@ -0,0 +1,56 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCheckTokenTestFs(t *testing.T, handler http.HandlerFunc) *Fs {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	f.tokenExpiry = time.Now().Add(time.Hour)
+	return f
+}
+
+func TestCommandCheckTokenReportsValid(t *testing.T) {
+	f := newCheckTokenTestFs(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	})
+
+	res, err := f.commandCheckToken(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, true, m["valid"])
+	assert.Equal(t, f.tokenExpiry.Format(time.RFC3339), m["expiry"])
+	assert.NotContains(t, m, "error")
+}
+
+func TestCommandCheckTokenReportsInvalid(t *testing.T) {
+	f := newCheckTokenTestFs(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":401,"message":"token expired"}`))
+	})
+
+	res, err := f.commandCheckToken(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, false, m["valid"])
+	assert.Contains(t, m["error"], "token expired")
+}
@@ -0,0 +1,90 @@
+package _123
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUploadSlicesRetriesOnlyMissingIndex simulates a worker that drops
+// exactly one chunk's result (its first request fails outright, as if the
+// worker crashed mid-request) and checks that uploadSlices notices the gap
+// and re-uploads only that slice rather than falling back to redoing the
+// whole transfer.
+func TestUploadSlicesRetriesOnlyMissingIndex(t *testing.T) {
+	const droppedSliceNo = "2" // 1-based, as sent on the wire
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sliceNo := r.FormValue("sliceNo")
+		mu.Lock()
+		attempts[sliceNo]++
+		n := attempts[sliceNo]
+		mu.Unlock()
+
+		if sliceNo == droppedSliceNo && n == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	f := &Fs{
+		opt:   Options{UploadConcurrency: 4},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+
+	content := make([]byte, 4*4) // 4 slices of size 4
+	r := newBytesReaderAt(content)
+
+	session := &api.UploadCreateResponse{}
+	session.Data.PreuploadID = "preupload"
+	session.Data.SliceSize = 4
+
+	err := f.uploadSlices(context.Background(), session, r, int64(len(content)), "test.txt", "etag", 1, 0)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts[droppedSliceNo], "the dropped slice should have been retried exactly once")
+	for sliceNo, n := range attempts {
+		if sliceNo != droppedSliceNo {
+			assert.Equal(t, 1, n, "slice %s should only have been uploaded once", sliceNo)
+		}
+	}
+}
+
+// bytesReaderAt adapts a []byte to io.ReaderAt for the test
+type bytesReaderAt struct {
+	data []byte
+}
+
+func newBytesReaderAt(data []byte) *bytesReaderAt {
+	return &bytesReaderAt{data: data}
+}
+
+func (r *bytesReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[off:])
+	return n, nil
+}
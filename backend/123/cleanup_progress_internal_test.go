@@ -0,0 +1,43 @@
+package _123
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCleanupProgressTestFs(t *testing.T) *Fs {
+	dir, err := ioutil.TempDir("", "123-cleanup-progress-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return &Fs{opt: Options{ProgressDir: dir}}
+}
+
+func TestCommandCleanupProgressUsesConfiguredMaxAge(t *testing.T) {
+	f := newCleanupProgressTestFs(t)
+
+	require.NoError(t, f.saveResumeRecord(&resumeRecord{Etag: "medium", CreatedAt: time.Now().Add(-90 * time.Minute)}))
+	require.NoError(t, f.saveResumeRecord(&resumeRecord{Etag: "fresh", CreatedAt: time.Now()}))
+
+	// With the default 24h threshold, neither record is old enough to go.
+	res, err := f.commandCleanupProgress(context.Background(), resumeRecordMaxAge)
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.(map[string]interface{})["removed"])
+
+	// A 1h override catches the 90-minute-old record but not the fresh one.
+	res, err = f.commandCleanupProgress(context.Background(), time.Hour)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, 1, m["removed"])
+	assert.True(t, m["bytesReclaimed"].(int64) > 0)
+
+	rec, err := f.loadResumeRecord("fresh")
+	require.NoError(t, err)
+	assert.NotNil(t, rec)
+}
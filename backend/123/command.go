@@ -0,0 +1,1517 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/lib/pacer"
+)
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "upload-session-abort",
+	Short: "Abort a stuck preupload session and clean up local resume state",
+	Long: `This cancels an in-progress multipart upload session, freeing any
+server-side partial-upload resources it holds and removing the local
+resume record and progress file for it.
+
+Usage:
+
+    rclone backend upload-session-abort 123: path/to/file
+`,
+}, {
+	Name:  "reauth",
+	Short: "Force a full re-login, discarding the cached token",
+	Long: `This clears the cached access token and acquires a brand new one from
+scratch, then restarts the background token renewer. Use this as a
+recovery path if the cached token is somehow corrupted beyond what the
+normal refresh logic can fix.
+
+Usage:
+
+    rclone backend reauth 123:
+`,
+}, {
+	Name:  "id-to-path",
+	Short: "Resolve a fileID to its full path, the inverse of pathToFileID",
+	Long: `Given a file or directory ID, walks its ancestors up to the root and
+returns the reconstructed root-relative path. Useful for interpreting IDs
+returned by other commands and by the raw API.
+
+Usage:
+
+    rclone backend id-to-path 123: <fileID>
+`,
+}, {
+	Name:  "object-by-id",
+	Short: "Fetch an object's metadata directly by fileID, skipping the path walk",
+	Long: `Given a fileID already known to the caller (from a prior list or from
+another backend command), fetches its metadata with a single API call
+instead of the directory walk NewObject needs to turn a path into a
+fileID. The remote path is still resolved, via the same walk id-to-path
+uses, so the result is attributable, but no listing is performed.
+
+Usage:
+
+    rclone backend object-by-id 123: <fileID>
+`,
+}, {
+	Name:  "check-instant",
+	Short: "Check whether a file would instant-upload (秒传) without uploading it",
+	Long: `Given a destination path, an MD5 and a size, this calls the same
+create-session endpoint a real upload would, so it reports exactly what
+the server would do: Reuse=true means the content is already known
+server-side and the real upload would complete instantly with no data
+transfer, Reuse=false means it would actually have to upload. Either
+way no file is written, so a backup script can decide whether hashing
+a large local file is worth the time before committing to it.
+
+The destination's parent directory must already exist; unlike a real
+upload this never creates it, to keep the check free of side effects.
+
+If the server creates a real (non-reused) preupload session for the
+check, it is aborted immediately afterwards so it doesn't linger; if
+that cleanup call itself fails, the orphaned preuploadID is returned
+rather than the command failing outright.
+
+Usage:
+
+    rclone backend check-instant 123: path/to/file <md5> <size>
+`,
+}, {
+	Name:  "list-json",
+	Short: "Recursively list a subtree as rclone-compatible lsjson output",
+	Long: `This produces the same JSON items as "rclone lsjson --recursive", built
+on top of the backend's native recursive ListR instead of repeated
+top-down listings, which is significantly fewer round trips for large
+trees. Respects context cancellation. The items are collected in memory
+and returned together as one JSON array once the walk finishes; output
+is not streamed while the tree is being listed.
+
+Usage:
+
+    rclone backend list-json 123:path/to/dir
+`,
+}, {
+	Name:  "gc",
+	Short: "Run all background cleanup tasks immediately",
+	Long: `This bundles the maintenance work that's normally left to happen
+lazily or on a timer into one on-demand pass: expired local resume
+records are removed, orphaned upload temp files are deleted, and expired
+entries in the in-memory path/dir/parent caches are evicted. Useful
+before a big operation or under memory pressure, without waiting for the
+periodic cleanup.
+
+Usage:
+
+    rclone backend gc 123:
+`,
+}, {
+	Name:  "cache-clear",
+	Short: "Drop one or all in-memory lookup caches immediately",
+	Long: `Unlike "gc", which only evicts entries that have already expired,
+this unconditionally drops a cache's entire contents - useful when you
+know the server-side state has changed in a way the TTL-based eviction
+in "gc" hasn't caught up with yet. This only clears this backend's
+in-memory path/dir/parent/tree-size/download-url caches, and (via
+"resume" or "all") on-disk upload resume records - it has no effect on
+rclone's generic VFS cache, if one is layered on top.
+
+Usage:
+
+    rclone backend cache-clear 123:
+    rclone backend cache-clear 123: dir
+    rclone backend cache-clear 123: path
+    rclone backend cache-clear 123: negative-path
+    rclone backend cache-clear 123: parent
+    rclone backend cache-clear 123: tree-size
+    rclone backend cache-clear 123: download-url
+    rclone backend cache-clear 123: resume
+
+With no argument, or "all", every cache is cleared.
+`,
+}, {
+	Name:  "cleanup-progress",
+	Short: "Remove stale upload resume records, reporting files removed and bytes reclaimed",
+	Long: `This is a narrower, more configurable cousin of "gc": it only sweeps
+resume records in the progress directory (the same ones "gc" removes
+using the fixed resumeRecordMaxAge of 24h), and lets you pick a
+different age threshold instead. It does not touch orphan temp files or
+the path/dir/parent caches - use "gc" for those.
+
+Usage:
+
+    rclone backend cleanup-progress 123:
+
+Pass -o max-age=DURATION (e.g. "1h", "30m") to override the default
+24h threshold.
+`,
+}, {
+	Name:  "set-pacer",
+	Short: "Tune the request pacer's minimum sleep at runtime, without restarting",
+	Long: `This backend uses a single shared pacer for every API call rather than
+separate ones per operation type. The pacer name must be one of "list",
+"strict", "upload", "download" or "default", for compatibility with
+other backends' "set-pacer" commands; any of them updates the same
+pacer, and any other name is rejected. The change only lasts for this
+process; it is not written back to the config and is lost on restart.
+
+Usage:
+
+    rclone backend set-pacer 123: <name> <minSleep>
+
+minSleep is a duration like "100ms" or "1s". The effective requests/sec
+implied by the new minSleep is returned.
+`,
+}, {
+	Name:  "find-by-etag",
+	Short: "Recursively find files under a subtree whose MD5 matches etag",
+	Long: `Etags (MD5s) are already present in every directory listing, so this
+is a cheap way to answer "do I already have this file somewhere?" for
+manual dedup, or to check 秒传 (instant-upload) eligibility before
+starting a transfer.
+
+Usage:
+
+    rclone backend find-by-etag 123:path/to/dir <etag>
+
+Pass -o max-depth=N to limit how many levels below the starting
+directory are searched (default: unlimited).
+`,
+}, {
+	Name:  "quota",
+	Short: "Report account quota, breaking out temporary (VIP/trial) space and its expiry",
+	Long: `Unlike About, which folds spacePermanent and any still-valid spaceTemp
+into one Total/Free, this reports them separately, along with the
+temporary quota's expiry in human-readable form (or "none" / "expired"),
+plus the account's uid and nickname. This is the detail users need to
+avoid planning uploads against temp space that's about to vanish.
+
+The underlying user/info call is rate-limited server-side to around 4
+QPS, so the result is cached briefly - long enough that repeated calls in
+a tight loop don't each re-hit the API, but short enough that a
+check-token or About call made moments later still sees fresh data.
+
+Usage:
+
+    rclone backend quota 123:
+`,
+}, {
+	Name:  "search",
+	Short: "Search for files by name using the API's server-side search",
+	Long: `This drives the searchData/searchMode parameters that directory
+listing already supports but nothing in this backend previously used,
+so it can answer "where is this file" across a whole subtree in one
+paginated call instead of a recursive ListR. Results are never cached -
+unlike a plain directory listing, a search result set is a different
+shape every time (it depends on the query) and usually spans far more
+of the tree than what the path/dir caches are sized for.
+
+Usage:
+
+    rclone backend search 123: path/to/dir query
+
+Pass -o mode=exact to require an exact filename match instead of the
+default substring match, and -o max-results=N to stop after N matches
+(default: unbounded).
+`,
+}, {
+	Name:  "list-stale-cache",
+	Short: "Sample cached path/dir lookups and compare them against fresh API results",
+	Long: `This is a diagnostic for the recurring class of stale-cache bugs: it
+takes a bounded sample of entries currently held in the pathCache and
+dirCache, re-fetches the same information from the API (bypassing the
+cache), and reports any that no longer agree - e.g. a cached fileID that
+the API no longer knows about, or a directory listing whose children
+have changed since it was cached. API calls made while sampling still go
+through the normal pacer.
+
+Usage:
+
+    rclone backend list-stale-cache 123:
+
+Pass -o sample-size=N to change how many entries of each cache are
+checked (default 20).
+`,
+}, {
+	Name:  "tree-size",
+	Short: "Report the total size and file count of a subtree",
+	Long: `This walks the subtree rooted at dir, summing file sizes straight from
+directory listings rather than fetching per-file details, and caches the
+result per directory so repeated queries against an unchanged tree are
+instant. The cache shares dir-cache-ttl with directory listings and is
+dropped by any write (upload or delete), the same coarse invalidation
+every other cache in this backend already uses.
+
+Usage:
+
+    rclone backend tree-size 123:path/to/dir
+`,
+}, {
+	Name:  "du",
+	Short: "Report per-directory size, file count and directory count for a subtree",
+	Long: `This is "tree-size" plus a directory count and an optional depth
+limit, for the common case of wanting a breakdown without walking an
+entire, possibly huge tree. It shares tree-size's cache and the same
+bottom-up recursion, but only an unbounded (no -o max-depth) query is
+ever read from or written to that cache - a depth-limited total isn't
+the same number as the subtree's real total, so caching it would give a
+wrong answer to a later unbounded query.
+
+Usage:
+
+    rclone backend du 123:path/to/dir
+
+Pass -o max-depth=N to only descend N levels below the starting
+directory (0 counts just its direct children; default: unlimited).
+`,
+}, {
+	Name:  "check-token",
+	Short: "Verify the current token is actually accepted by the server",
+	Long: `The locally-stored token expiry can be wrong if the token was revoked
+server-side, so this makes one minimal authenticated call (user/info, the
+cheapest endpoint available) to check whether the server still accepts it,
+rather than trusting the local clock.
+
+Usage:
+
+    rclone backend check-token 123:
+`,
+}, {
+	Name:  "token-info",
+	Short: "Report the cached token's expiry without exposing the token itself",
+	Long: `Useful when debugging 401 loops: shows the cached token's expiry, how
+long until it expires, and whether it's within the leeway window the
+background renewer uses to trigger a refresh - all without printing the
+token itself.
+
+Usage:
+
+    rclone backend token-info 123:
+`,
+}, {
+	Name:  "download",
+	Short: "Download a file with resumable progress, surviving process restarts",
+	Long: `Unlike a normal "rclone copy", this records progress in a ".123dl"
+sidecar file next to the destination as each chunk completes, so an
+interrupted download - even one interrupted by the process being killed
+and restarted - resumes from the last completed chunk rather than
+starting over. If the remote file's etag no longer matches what's
+recorded in the sidecar, the partial download is discarded and restarted
+from scratch rather than resumed against changed data.
+
+Usage:
+
+    rclone backend download 123: path/to/file /local/destination/path
+`,
+}, {
+	Name:  "stat",
+	Short: "Report the full server-side detail record for a single path",
+	Long: `NewObject only keeps the fields needed to satisfy fs.Object (size, modTime,
+hash, ID), discarding the rest of what the API's file-detail response
+carries. This resolves path to a fileID and returns the complete record -
+fileID, type, size, etag, status, parentFileID, createAt, trashed, and
+punishFlag - useful for debugging review rejections, trash status, and
+content-violation penalties without guessing at what NewObject dropped.
+
+Usage:
+
+    rclone backend stat 123: path/to/file
+`,
+}, {
+	Name:  "perf-stats",
+	Short: "Report time-to-first-byte statistics for downloads",
+	Long: `This backend has a single download path (Open issues one GET and hands
+back its body), so there is no separate "concurrent" path to track
+separately. Each Open records the time from issuing the raw transfer
+request to receiving its response, excluding the preceding download_info
+round trip - this isolates transfer latency from URL-resolution latency,
+which matters when diagnosing whether a slow download is due to the
+download_info call or the transfer itself. The window is the most recent
+1000 downloads.
+
+Usage:
+
+    rclone backend perf-stats 123:
+`,
+}, {
+	Name:  "restore-to",
+	Short: "Restore a trashed file and relocate it to a destination directory",
+	Long: `A plain restore puts a file back under whatever parent it was trashed
+from, which fails or lands the file somewhere unexpected if that parent
+was deleted too. This restores the file out of the recycle bin, then
+moves it to the given destination directory (creating it if necessary),
+and reports the file's final path.
+
+Usage:
+
+    rclone backend restore-to 123: <fileID> <destination-dir>
+`,
+}, {
+	Name:  "share",
+	Short: "Create a public share link for a file or directory",
+	Long: `Resolves the given path to a fileID and creates a public share link for
+it, returning the share URL and, if a password was set, the extraction
+code needed to unlock it.
+
+The API only accepts fixed expiries of 1, 7 or 30 days, or permanent -
+"expire" is rounded up to the smallest one that covers what's asked for,
+falling back to permanent if it exceeds 30 days.
+
+Usage:
+
+    rclone backend share 123: path/to/file
+    rclone backend share 123: path/to/dir -o expire=7d -o password=abc
+`,
+}, {
+	Name:  "trash-list",
+	Short: "List the files currently in the recycle bin",
+	Long: `Returns the trashed FileDetail entries as JSON, so you can see what's in
+the recycle bin before restoring or permanently deleting anything.
+
+Usage:
+
+    rclone backend trash-list 123:
+`,
+}, {
+	Name:  "trash-restore",
+	Short: "Restore a trashed file back to its original parent directory",
+	Long: `Moves a single file out of the recycle bin, back to whatever directory
+it was trashed from, and invalidates the caches covering that directory.
+Use "restore-to" instead if the original parent may no longer exist, or
+you want the file relocated on restore.
+
+Usage:
+
+    rclone backend trash-restore 123: <fileID>
+`,
+}, {
+	Name:  "trash-delete",
+	Short: "Permanently delete a trashed file",
+	Long: `Calls the permanent-delete API on a single already-trashed file. Unlike a
+normal delete (which only moves a file to the recycle bin) this cannot be
+undone.
+
+Usage:
+
+    rclone backend trash-delete 123: <fileID>
+`,
+}, {
+	Name:  "trash",
+	Short: "Trash one or more files or directories by remote path",
+	Long: `Resolves each given remote path to a fileID and trashes them all in a
+single batched call, rather than relying on "rclone delete" filters or
+looking up fileIDs by hand for trash-delete/trash-restore. A path that
+fails to resolve doesn't stop the rest - every other path is still
+trashed - and unresolved paths are reported back alongside the fileIDs
+that were trashed. The caches covering the affected parents are
+invalidated afterward.
+
+Usage:
+
+    rclone backend trash 123: path/to/file path/to/dir
+`,
+}, {
+	Name:  "ls-trash-size",
+	Short: "Report how many files are in the recycle bin and their total size",
+	Long: `Lists the recycle bin via its etag-carrying listing (so no per-file
+detail calls are needed) and sums the sizes, returning {count, totalBytes}.
+Use this to decide whether permanently deleting trashed files would
+meaningfully reclaim quota before doing so.
+
+Usage:
+
+    rclone backend ls-trash-size 123:
+`,
+}, {
+	Name:  "empty-trash",
+	Short: "Permanently delete everything currently in the recycle bin",
+	Long: `Lists the recycle bin via trash-list's listing, then permanently
+deletes every entry found through the same endpoint trash-delete uses,
+in batches rather than one fileID at a time. A batch that fails doesn't
+stop the rest - every remaining batch is still attempted - and any
+failures are reported together in the returned error, after everything
+that could be removed has been. Returns {removed, freedBytes}.
+
+Usage:
+
+    rclone backend empty-trash 123:
+`,
+}, {
+	Name:  "refresh-upload-domain",
+	Short: "Clear the cached upload domain and re-select one",
+	Long: `Uploads stick to whichever upload domain last accepted a slice, to
+avoid re-probing the full fallback list for every slice once a working
+domain is known. This command discards that cached choice and
+immediately probes the configured candidates again, caching and
+returning whichever one responds first. Use it if the sticky domain has
+gone bad (e.g. after a network change) and you don't want to wait for
+the next upload's slice failures to discover that.
+
+Usage:
+
+    rclone backend refresh-upload-domain 123:
+`,
+}, {
+	Name:  "test-upload-domain",
+	Short: "Probe every upload domain candidate and report reachability and latency",
+	Long: `Unlike refresh-upload-domain, which stops at the first reachable
+candidate, this probes every upload domain candidate (dynamic servers
+aside, since none are known outside of an active upload) and reports
+each one's reachability and how long its probe took, as
+{domains: [{domain, reachable, latencyMs}, ...], selected}. The first
+reachable one is cached as the sticky selection, same as a normal
+refresh. Useful for diagnosing which domain(s) a regional network is
+blocking before it shows up as a slice-upload failure.
+
+Usage:
+
+    rclone backend test-upload-domain 123:
+`,
+}, {
+	Name:  "offline-add",
+	Short: "Start an offline (cloud) download of a URL or magnet link",
+	Long: `123pan can fetch an HTTP(S) URL or magnet link directly into the
+user's drive without it passing through this machine first. This starts
+such a download into destDir (created if it doesn't already exist) and
+returns the new task's ID - poll it with "offline-list".
+
+Usage:
+
+    rclone backend offline-add 123: <url-or-magnet> <destination-dir>
+
+Pass -o filename=name.ext to set the saved file's name, if the source
+doesn't already imply one the server is happy with.
+`,
+}, {
+	Name:  "offline-list",
+	Short: "Poll the status of one or more offline-download tasks",
+	Long: `Reports each task's status (downloading, done or failed), percent
+progress, and - once done - the fileID of the downloaded file.
+
+Usage:
+
+    rclone backend offline-list 123: <taskID> [taskID...]
+`,
+}, {
+	Name:  "health",
+	Short: "Run a fast readiness check and report the result of each sub-check",
+	Long: `Runs a handful of quick checks - token validity, upload-domain
+reachability, a 1-item listing of the root, and whether the in-memory
+cache is available - and returns {ok, checks: {...}}, with ok true only
+if every sub-check passed. Each sub-check carries its own short timeout
+so one slow/unreachable dependency can't block the others from
+reporting, making this suitable for a liveness/readiness probe when
+rclone runs as a service (e.g. behind "rclone serve").
+
+Usage:
+
+    rclone backend health 123:
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (interface{}, error) {
+	switch name {
+	case "upload-session-abort":
+		if len(arg) != 1 {
+			return nil, errors.New("upload-session-abort needs exactly 1 argument, a remote path")
+		}
+		return f.commandUploadSessionAbort(ctx, arg[0])
+	case "reauth":
+		return f.commandReauth(ctx)
+	case "id-to-path":
+		if len(arg) != 1 {
+			return nil, errors.New("id-to-path needs exactly 1 argument, a fileID")
+		}
+		return f.commandIDToPath(ctx, arg[0])
+	case "object-by-id":
+		if len(arg) != 1 {
+			return nil, errors.New("object-by-id needs exactly 1 argument, a fileID")
+		}
+		return f.commandObjectByID(ctx, arg[0])
+	case "check-instant":
+		if len(arg) != 3 {
+			return nil, errors.New("check-instant needs exactly 3 arguments: a remote path, an MD5 and a size")
+		}
+		size, err := strconv.ParseInt(arg[2], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "check-instant: size must be a number")
+		}
+		return f.commandCheckInstant(ctx, arg[0], arg[1], size)
+	case "list-json":
+		dir := ""
+		if len(arg) == 1 {
+			dir = arg[0]
+		} else if len(arg) > 1 {
+			return nil, errors.New("list-json takes at most 1 argument, a directory")
+		}
+		return f.commandListJSON(ctx, dir)
+	case "gc":
+		return f.commandGC(ctx)
+	case "cache-clear":
+		name := "all"
+		if len(arg) == 1 {
+			name = arg[0]
+		} else if len(arg) > 1 {
+			return nil, errors.New("cache-clear takes at most 1 argument, a cache name")
+		}
+		return f.commandCacheClear(ctx, name)
+	case "cleanup-progress":
+		maxAge := resumeRecordMaxAge
+		if ageArg, ok := opt["max-age"]; ok {
+			d, err := time.ParseDuration(ageArg)
+			if err != nil {
+				return nil, errors.Wrap(err, "cleanup-progress: max-age must be a duration")
+			}
+			maxAge = d
+		}
+		return f.commandCleanupProgress(ctx, maxAge)
+	case "set-pacer":
+		if len(arg) != 2 {
+			return nil, errors.New("set-pacer needs exactly 2 arguments, a pacer name and a minSleep duration")
+		}
+		return f.commandSetPacer(arg[0], arg[1])
+	case "find-by-etag":
+		dir := ""
+		var etag string
+		switch len(arg) {
+		case 1:
+			etag = arg[0]
+		case 2:
+			dir, etag = arg[0], arg[1]
+		default:
+			return nil, errors.New("find-by-etag needs 1 or 2 arguments: [directory] etag")
+		}
+		maxDepth := -1
+		if depthArg, ok := opt["max-depth"]; ok {
+			d, err := strconv.Atoi(depthArg)
+			if err != nil {
+				return nil, errors.Wrap(err, "find-by-etag: max-depth must be an integer")
+			}
+			maxDepth = d
+		}
+		return f.commandFindByEtag(ctx, dir, etag, maxDepth)
+	case "quota":
+		return f.commandQuota(ctx)
+	case "search":
+		if len(arg) != 2 {
+			return nil, errors.New("search needs exactly 2 arguments: directory query")
+		}
+		maxResults := 0
+		if maxArg, ok := opt["max-results"]; ok {
+			n, err := strconv.Atoi(maxArg)
+			if err != nil {
+				return nil, errors.Wrap(err, "search: max-results must be an integer")
+			}
+			maxResults = n
+		}
+		return f.commandSearch(ctx, arg[0], arg[1], opt["mode"], maxResults)
+	case "list-stale-cache":
+		sampleSize := defaultStaleCacheSampleSize
+		if sizeArg, ok := opt["sample-size"]; ok {
+			n, err := strconv.Atoi(sizeArg)
+			if err != nil {
+				return nil, errors.Wrap(err, "list-stale-cache: sample-size must be an integer")
+			}
+			sampleSize = n
+		}
+		return f.commandListStaleCache(ctx, sampleSize)
+	case "tree-size":
+		dir := ""
+		if len(arg) == 1 {
+			dir = arg[0]
+		} else if len(arg) > 1 {
+			return nil, errors.New("tree-size takes at most 1 argument, a directory")
+		}
+		return f.commandTreeSize(ctx, dir, -1)
+	case "du":
+		dir := ""
+		if len(arg) == 1 {
+			dir = arg[0]
+		} else if len(arg) > 1 {
+			return nil, errors.New("du takes at most 1 argument, a directory")
+		}
+		maxDepth := -1
+		if depthArg, ok := opt["max-depth"]; ok {
+			d, err := strconv.Atoi(depthArg)
+			if err != nil {
+				return nil, errors.Wrap(err, "du: max-depth must be an integer")
+			}
+			maxDepth = d
+		}
+		return f.commandTreeSize(ctx, dir, maxDepth)
+	case "check-token":
+		return f.commandCheckToken(ctx)
+	case "token-info":
+		return f.commandTokenInfo(ctx)
+	case "download":
+		if len(arg) != 2 {
+			return nil, errors.New("download needs exactly 2 arguments: a remote path and a local destination path")
+		}
+		return f.commandDownload(ctx, arg[0], arg[1])
+	case "perf-stats":
+		return f.commandPerfStats(ctx)
+	case "stat":
+		if len(arg) != 1 {
+			return nil, errors.New("stat needs exactly 1 argument, a remote path")
+		}
+		return f.commandStat(ctx, arg[0])
+	case "ls-trash-size":
+		return f.commandLsTrashSize(ctx)
+	case "refresh-upload-domain":
+		return f.commandRefreshUploadDomain(ctx)
+	case "test-upload-domain":
+		return f.commandTestUploadDomain(ctx)
+	case "health":
+		return f.commandHealth(ctx)
+	case "restore-to":
+		if len(arg) != 2 {
+			return nil, errors.New("restore-to needs exactly 2 arguments: a trashed fileID and a destination directory path")
+		}
+		fileID, err := parseFileID("restore-to", arg[0])
+		if err != nil {
+			return nil, err
+		}
+		return f.commandRestoreTo(ctx, fileID, arg[1])
+	case "trash-list":
+		return f.commandTrashList(ctx)
+	case "trash-restore":
+		if len(arg) != 1 {
+			return nil, errors.New("trash-restore needs exactly 1 argument, a trashed fileID")
+		}
+		fileID, err := parseFileID("trash-restore", arg[0])
+		if err != nil {
+			return nil, err
+		}
+		return f.commandTrashRestore(ctx, fileID)
+	case "trash-delete":
+		if len(arg) != 1 {
+			return nil, errors.New("trash-delete needs exactly 1 argument, a trashed fileID")
+		}
+		fileID, err := parseFileID("trash-delete", arg[0])
+		if err != nil {
+			return nil, err
+		}
+		return f.commandTrashDelete(ctx, fileID)
+	case "share":
+		if len(arg) != 1 {
+			return nil, errors.New("share needs exactly 1 argument, a remote path")
+		}
+		var expire time.Duration
+		if expireArg, ok := opt["expire"]; ok {
+			d, err := fs.ParseDuration(expireArg)
+			if err != nil {
+				return nil, errors.Wrap(err, "share: expire must be a duration like 7d")
+			}
+			expire = d
+		}
+		return f.commandShare(ctx, arg[0], expire, opt["password"])
+	case "offline-add":
+		if len(arg) != 2 {
+			return nil, errors.New("offline-add needs exactly 2 arguments: a URL or magnet link and a destination directory")
+		}
+		return f.commandOfflineAdd(ctx, arg[0], opt["filename"], arg[1])
+	case "offline-list":
+		if len(arg) == 0 {
+			return nil, errors.New("offline-list needs at least 1 argument, a taskID")
+		}
+		taskIDs := make([]int64, len(arg))
+		for i, a := range arg {
+			taskID, err := parseFileID("offline-list", a)
+			if err != nil {
+				return nil, err
+			}
+			taskIDs[i] = taskID
+		}
+		return f.commandOfflineList(ctx, taskIDs)
+	case "empty-trash":
+		return f.commandEmptyTrash(ctx)
+	case "trash":
+		if len(arg) == 0 {
+			return nil, errors.New("trash needs at least 1 argument, a remote path")
+		}
+		return f.commandTrash(ctx, arg)
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// commandUploadSessionAbort implements the "upload-session-abort" backend command
+func (f *Fs) commandUploadSessionAbort(ctx context.Context, remote string) (interface{}, error) {
+	fullPath := path.Join(f.root, remote)
+
+	// The server-side preuploadID is only known locally via the resume
+	// record we saved while uploading, keyed by the content's MD5.
+	keys, err := f.getAllResumeKeys()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan for resume records")
+	}
+
+	var aborted []string
+	for _, etag := range keys {
+		rec, err := f.loadResumeRecord(etag)
+		if err != nil || rec == nil {
+			continue
+		}
+		if rec.Remote != remote && rec.Remote != fullPath {
+			continue
+		}
+		if err := f.abortUploadSession(ctx, etag, rec.PreuploadID); err != nil {
+			return nil, err
+		}
+		aborted = append(aborted, rec.PreuploadID)
+	}
+	if len(aborted) == 0 {
+		return nil, errors.Errorf("no stuck upload session found for %q", remote)
+	}
+	return map[string]interface{}{"aborted": aborted}, nil
+}
+
+// commandReauth implements the "reauth" backend command - it forces a full
+// re-login rather than relying on refreshTokenIfNecessary's usual
+// already-valid-token short circuit.
+func (f *Fs) commandReauth(ctx context.Context) (interface{}, error) {
+	f.tokenMu.Lock()
+	f.token = ""
+	f.tokenExpiry = time.Time{}
+	f.tokenMu.Unlock()
+
+	if err := f.refreshTokenIfNecessary(ctx); err != nil {
+		return nil, errors.Wrap(err, "reauth failed")
+	}
+
+	f.stopTokenRenewer()
+	f.startTokenRenewer()
+
+	f.tokenMu.Lock()
+	expiry := f.tokenExpiry
+	f.tokenMu.Unlock()
+	return map[string]interface{}{"expiry": expiry.Format(time.RFC3339)}, nil
+}
+
+// parseFileID parses a command argument expected to be a numeric fileID,
+// wrapping strconv's error with the command name so a missing or
+// malformed ID produces a clear, attributable error rather than a bare
+// "invalid syntax".
+func parseFileID(command, idArg string) (int64, error) {
+	if idArg == "" {
+		return 0, errors.Errorf("%s needs a fileID", command)
+	}
+	fileID, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "%s needs a numeric fileID", command)
+	}
+	return fileID, nil
+}
+
+// commandIDToPath implements the "id-to-path" backend command
+func (f *Fs) commandIDToPath(ctx context.Context, idArg string) (interface{}, error) {
+	fileID, err := parseFileID("id-to-path", idArg)
+	if err != nil {
+		return nil, err
+	}
+	fullPath, err := f.idToPath(ctx, fileID)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return nil, errors.Errorf("fileID %d is unreachable: %v", fileID, err)
+		}
+		return nil, err
+	}
+	return map[string]interface{}{"path": fullPath}, nil
+}
+
+// commandObjectByID implements the "object-by-id" backend command
+func (f *Fs) commandObjectByID(ctx context.Context, idArg string) (interface{}, error) {
+	fileID, err := parseFileID("object-by-id", idArg)
+	if err != nil {
+		return nil, err
+	}
+	o, err := f.objectByID(ctx, fileID)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return nil, errors.Errorf("fileID %d is unreachable: %v", fileID, err)
+		}
+		return nil, err
+	}
+	return map[string]interface{}{
+		"fileID":  fileID,
+		"path":    o.Remote(),
+		"size":    o.Size(),
+		"modTime": o.ModTime(ctx),
+	}, nil
+}
+
+// commandCheckInstant implements the "check-instant" backend command. It
+// asks createUploadSession whether content matching etag/size would
+// instant-upload, without transferring anything. If the call created a
+// real (non-reused) session, it's aborted immediately so it doesn't
+// linger as an orphaned preupload; a failure to abort is reported
+// alongside the result rather than failing the command, since the check
+// result itself is still valid.
+func (f *Fs) commandCheckInstant(ctx context.Context, remote, etag string, size int64) (interface{}, error) {
+	fullPath := path.Join(f.root, remote)
+	dir, leaf := path.Split(fullPath)
+	parentID, err := f.pathToFileID(ctx, strings.TrimSuffix(dir, "/"), false)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return nil, errors.Errorf("check-instant: destination directory for %q does not exist", remote)
+		}
+		return nil, err
+	}
+
+	session, err := f.createUploadSession(ctx, parentID, leaf, size, etag)
+	if err != nil {
+		return nil, errors.Wrap(err, "check-instant: failed to query upload session")
+	}
+
+	result := map[string]interface{}{
+		"reuse": session.Data.Reuse,
+	}
+	if !session.Data.Reuse && session.Data.PreuploadID != "" {
+		if err := f.abortUploadSession(ctx, etag, session.Data.PreuploadID); err != nil {
+			fs.Logf(f, "check-instant: failed to abort preupload session %s, it may linger server-side: %v", session.Data.PreuploadID, err)
+			result["orphanedPreuploadID"] = session.Data.PreuploadID
+		}
+	}
+	return result, nil
+}
+
+// commandListJSON implements the "list-json" backend command. It marshals
+// each ListJSONItem as it's produced and collects them all in memory,
+// returning the whole slice once the walk finishes; nothing is emitted
+// before then. It returns early if ctx is cancelled mid-walk.
+func (f *Fs) commandListJSON(ctx context.Context, dir string) (interface{}, error) {
+	opt := &operations.ListJSONOpt{
+		Recurse:  true,
+		ShowHash: true,
+	}
+	var items []json.RawMessage
+	err := operations.ListJSON(ctx, f, dir, opt, func(item *operations.ListJSONItem) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		items = append(items, raw)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list-json failed")
+	}
+	return items, nil
+}
+
+// commandGC implements the "gc" backend command, running every cleanup
+// task on demand and reporting how much each one reclaimed.
+func (f *Fs) commandGC(ctx context.Context) (interface{}, error) {
+	expiredResumeRecords, resumeBytesReclaimed, err := f.cleanupExpiredResumeInfo(resumeRecordMaxAge)
+	if err != nil {
+		return nil, errors.Wrap(err, "gc failed to clean up expired resume info")
+	}
+	orphanTempFiles, tempBytesReclaimed, err := f.cleanupOrphanTempFiles()
+	if err != nil {
+		return nil, errors.Wrap(err, "gc failed to clean up orphan temp files")
+	}
+	evictedCacheEntries := f.evictExpiredCacheEntries()
+	return map[string]interface{}{
+		"expiredResumeRecords": expiredResumeRecords,
+		"orphanTempFiles":      orphanTempFiles,
+		"evictedCacheEntries":  evictedCacheEntries,
+		"bytesReclaimed":       resumeBytesReclaimed + tempBytesReclaimed,
+	}, nil
+}
+
+// commandCacheClear implements the "cache-clear" backend command. "all"
+// (and no argument) clears every in-memory cache via clearCache plus
+// every on-disk resume record via cleanupExpiredResumeInfo(0); any other
+// name clears just that one in-memory cache via clearNamedCache. "resume"
+// only clears resume records, leaving the in-memory caches untouched.
+func (f *Fs) commandCacheClear(ctx context.Context, name string) (interface{}, error) {
+	if name == "all" {
+		f.clearCache()
+		removedResumeRecords, _, err := f.cleanupExpiredResumeInfo(0)
+		if err != nil {
+			return nil, errors.Wrap(err, "cache-clear failed to remove resume records")
+		}
+		return map[string]interface{}{
+			"cleared":              "all",
+			"removedResumeRecords": removedResumeRecords,
+		}, nil
+	}
+	if name == "resume" {
+		removedResumeRecords, _, err := f.cleanupExpiredResumeInfo(0)
+		if err != nil {
+			return nil, errors.Wrap(err, "cache-clear failed to remove resume records")
+		}
+		return map[string]interface{}{
+			"cleared":              "resume",
+			"removedResumeRecords": removedResumeRecords,
+		}, nil
+	}
+	sizes, err := f.clearNamedCache(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "cache-clear")
+	}
+	return map[string]interface{}{
+		"cleared": name,
+		"sizes":   sizes,
+	}, nil
+}
+
+// commandCleanupProgress implements the "cleanup-progress" backend command,
+// a narrower and more configurable cousin of "gc" that only targets
+// upload-progress state (resume records in the progress directory), not
+// the directory/parent caches gc also sweeps, and lets the caller override
+// the age threshold instead of always using resumeRecordMaxAge.
+func (f *Fs) commandCleanupProgress(ctx context.Context, maxAge time.Duration) (interface{}, error) {
+	removed, bytesReclaimed, err := f.cleanupExpiredResumeInfo(maxAge)
+	if err != nil {
+		return nil, errors.Wrap(err, "cleanup-progress failed")
+	}
+	return map[string]interface{}{
+		"removed":        removed,
+		"bytesReclaimed": bytesReclaimed,
+	}, nil
+}
+
+// knownPacerNames are the pacer names accepted by "set-pacer" for
+// compatibility with other backends that do have one pacer per operation
+// type. This backend has only one, so every name maps to it.
+var knownPacerNames = map[string]bool{
+	"list": true, "strict": true, "upload": true, "download": true, "default": true,
+}
+
+// commandSetPacer implements the "set-pacer" backend command
+func (f *Fs) commandSetPacer(name, minSleepArg string) (interface{}, error) {
+	if !knownPacerNames[name] {
+		return nil, errors.Errorf("set-pacer: unknown pacer name %q", name)
+	}
+	minSleep, err := time.ParseDuration(minSleepArg)
+	if err != nil {
+		return nil, errors.Wrap(err, "set-pacer needs a valid duration for minSleep")
+	}
+	if minSleep <= 0 {
+		return nil, errors.New("set-pacer: minSleep must be positive")
+	}
+	f.pacer.SetCalculator(pacer.NewDefault(pacer.MinSleep(minSleep)))
+	return map[string]interface{}{
+		"pacer":             name,
+		"minSleep":          minSleep.String(),
+		"effectiveQPS":      float64(time.Second) / float64(minSleep),
+		"persistedToConfig": false,
+	}, nil
+}
+
+// findByEtagDir tracks a directory still to be visited by
+// commandFindByEtag, along with its depth relative to the search root.
+type findByEtagDir struct {
+	id     int64
+	remote string
+	depth  int
+}
+
+// commandFindByEtag implements the "find-by-etag" backend command. It
+// walks the subtree rooted at dir comparing each file's Etag against
+// target, without going through ListR's fs.Object construction since
+// the raw api.FileInfo.Etag from listDir is all that's needed here.
+// maxDepth < 0 means unlimited.
+func (f *Fs) commandFindByEtag(ctx context.Context, dir, target string, maxDepth int) (interface{}, error) {
+	if target == "" {
+		return nil, errors.New("find-by-etag needs a non-empty etag")
+	}
+	dirID, err := f.pathToFileID(ctx, path.Join(f.root, dir), false)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, err
+	}
+
+	var matches []string
+	stack := []findByEtagDir{{id: dirID, remote: dir, depth: 0}}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		infos, err := f.listDir(ctx, current.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.Trashed != 0 {
+				continue
+			}
+			remote := path.Join(current.remote, f.opt.Enc.ToStandardName(info.Filename))
+			if info.Type == 1 {
+				if maxDepth < 0 || current.depth < maxDepth {
+					stack = append(stack, findByEtagDir{id: info.FileID, remote: remote, depth: current.depth + 1})
+				}
+				continue
+			}
+			if info.Etag == target {
+				matches = append(matches, remote)
+			}
+		}
+	}
+	return map[string]interface{}{"matches": matches}, nil
+}
+
+// commandQuota implements the "quota" backend command. It goes through
+// getUserInfoCached rather than getUserInfo directly, so a burst of
+// quota calls doesn't each re-hit user/info's own tighter server-side rate
+// limit. check-token and About call getUserInfo directly, so they neither
+// read nor fill this cache.
+func (f *Fs) commandQuota(ctx context.Context) (interface{}, error) {
+	info, err := f.getUserInfoCached(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tempExpiry := "none"
+	spaceTemp := usableSpaceTemp(info)
+	if expiry, ok := spaceTempExpiry(info); ok {
+		if spaceTemp == 0 {
+			tempExpiry = "expired at " + expiry.Format(time.RFC3339)
+		} else {
+			tempExpiry = expiry.Format(time.RFC3339)
+		}
+	}
+
+	return map[string]interface{}{
+		"uid":            info.Data.UID,
+		"nickname":       info.Data.Nickname,
+		"spacePermanent": info.Data.SpacePermanent,
+		"spaceTemp":      spaceTemp,
+		"spaceTempUntil": tempExpiry,
+		"spaceUsed":      info.Data.SpaceUsed,
+		"vip":            info.Data.VIP,
+	}, nil
+}
+
+// defaultStaleCacheSampleSize bounds how many cache entries
+// "list-stale-cache" checks per cache when -o sample-size isn't given.
+const defaultStaleCacheSampleSize = 20
+
+// commandListStaleCache implements the "list-stale-cache" backend command.
+func (f *Fs) commandListStaleCache(ctx context.Context, sampleSize int) (interface{}, error) {
+	if sampleSize <= 0 {
+		return nil, errors.New("list-stale-cache: sample-size must be positive")
+	}
+
+	dirSample := f.sampleDirCache(sampleSize)
+	pathSample := f.samplePathCache(sampleSize)
+
+	var staleDirs []map[string]interface{}
+	for parentID, cachedIDs := range dirSample {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fresh, err := f.listDir(ctx, parentID)
+		if err != nil {
+			staleDirs = append(staleDirs, map[string]interface{}{
+				"parentFileID": parentID,
+				"problem":      errors.Wrap(err, "failed to re-list").Error(),
+			})
+			continue
+		}
+		freshIDs := make([]int64, 0, len(fresh))
+		for _, info := range fresh {
+			freshIDs = append(freshIDs, info.FileID)
+		}
+		if !sameIDSet(cachedIDs, freshIDs) {
+			staleDirs = append(staleDirs, map[string]interface{}{
+				"parentFileID": parentID,
+				"cachedCount":  len(cachedIDs),
+				"freshCount":   len(freshIDs),
+			})
+		}
+	}
+
+	var stalePaths []map[string]interface{}
+	for p, fileID := range pathSample {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := f.getFileInfo(ctx, fileID)
+		if err != nil {
+			stalePaths = append(stalePaths, map[string]interface{}{
+				"path":         p,
+				"cachedFileID": fileID,
+				"problem":      errors.Wrap(err, "cached fileID no longer resolves").Error(),
+			})
+			continue
+		}
+		if info.Trashed != 0 {
+			stalePaths = append(stalePaths, map[string]interface{}{
+				"path":         p,
+				"cachedFileID": fileID,
+				"problem":      "cached fileID has since been trashed",
+			})
+		}
+	}
+
+	return map[string]interface{}{
+		"dirsSampled":  len(dirSample),
+		"pathsSampled": len(pathSample),
+		"staleDirs":    staleDirs,
+		"stalePaths":   stalePaths,
+	}, nil
+}
+
+// sameIDSet reports whether a and b contain the same fileIDs, ignoring order.
+func sameIDSet(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[int64]int, len(a))
+	for _, id := range a {
+		counts[id]++
+	}
+	for _, id := range b {
+		counts[id]--
+	}
+	for _, n := range counts {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// commandTreeSize implements the "tree-size" backend command. It recurses
+// depth-first, caching each directory's own totals as it unwinds so that a
+// later query against a nested subdirectory - or a repeat query against dir
+// itself - can be served from cache without any further API calls.
+//
+// maxDepth bounds how many levels below dir are descended into (0 means
+// only dir's own direct children are counted, -1 means unlimited). A
+// depth-limited query is never served from or written to the cache, since
+// the cache only ever holds a subtree's true, unbounded totals.
+func (f *Fs) commandTreeSize(ctx context.Context, dir string, maxDepth int) (interface{}, error) {
+	dirID, err := f.pathToFileID(ctx, path.Join(f.root, dir), false)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, err
+	}
+
+	totalSize, fileCount, dirCount, cached, err := f.treeSize(ctx, dirID, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"totalSize": totalSize,
+		"fileCount": fileCount,
+		"dirCount":  dirCount,
+		"cached":    cached,
+	}, nil
+}
+
+// treeSize returns the recursive size, file count and directory count of
+// dirID's subtree down to maxDepth levels (-1 for unlimited), serving from
+// the cache when fresh and unbounded, and populating it, bottom-up, on an
+// unbounded miss.
+func (f *Fs) treeSize(ctx context.Context, dirID int64, maxDepth int) (totalSize, fileCount, dirCount int64, cached bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, 0, false, err
+	}
+	unbounded := maxDepth < 0
+	if unbounded {
+		if size, fileN, dirN, ok := f.getTreeSizeFromCache(dirID); ok {
+			return size, fileN, dirN, true, nil
+		}
+	}
+
+	infos, err := f.listDir(ctx, dirID)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	for _, info := range infos {
+		if info.Trashed != 0 {
+			continue
+		}
+		if info.Type == 1 {
+			dirCount++
+			if !unbounded && maxDepth == 0 {
+				continue
+			}
+			childDepth := maxDepth - 1
+			if unbounded {
+				childDepth = -1
+			}
+			size, fileN, dirN, _, err := f.treeSize(ctx, info.FileID, childDepth)
+			if err != nil {
+				return 0, 0, 0, false, err
+			}
+			totalSize += size
+			fileCount += fileN
+			dirCount += dirN
+			continue
+		}
+		totalSize += info.Size
+		fileCount++
+	}
+
+	if unbounded {
+		f.saveTreeSizeToCache(dirID, totalSize, fileCount, dirCount)
+	}
+	return totalSize, fileCount, dirCount, false, nil
+}
+
+// commandCheckToken implements the "check-token" backend command. It makes
+// the cheapest authenticated call available (user/info) to find out
+// whether the server still accepts the current token, rather than trusting
+// the locally-cached expiry, which can't see a server-side revocation.
+func (f *Fs) commandCheckToken(ctx context.Context) (interface{}, error) {
+	f.tokenMu.Lock()
+	expiry := f.tokenExpiry
+	f.tokenMu.Unlock()
+
+	_, err := f.getUserInfo(ctx)
+	if err != nil {
+		return map[string]interface{}{
+			"valid":  false,
+			"expiry": expiry.Format(time.RFC3339),
+			"error":  err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{
+		"valid":  true,
+		"expiry": expiry.Format(time.RFC3339),
+	}, nil
+}
+
+// commandTokenInfo implements the "token-info" backend command. It reads
+// the cached expiry under tokenMu without touching f.token itself, so
+// callers can diagnose 401 loops without the raw token ever leaving the
+// process.
+func (f *Fs) commandTokenInfo(ctx context.Context) (interface{}, error) {
+	f.tokenMu.Lock()
+	expiry := f.tokenExpiry
+	f.tokenMu.Unlock()
+
+	timeUntilExpiry := expiry.Sub(f.serverNow())
+	return map[string]interface{}{
+		"expiry":          expiry.Format(time.RFC3339),
+		"timeUntilExpiry": timeUntilExpiry.String(),
+		"refreshImminent": timeUntilExpiry <= tokenExpiryLeeway,
+	}, nil
+}
+
+// commandStat implements the "stat" backend command
+func (f *Fs) commandStat(ctx context.Context, remote string) (interface{}, error) {
+	fullPath := path.Join(f.root, remote)
+	info, err := f.lookupFileInfo(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"fileID":       info.FileID,
+		"type":         info.Type,
+		"size":         info.Size,
+		"etag":         info.Etag,
+		"status":       info.Status,
+		"parentFileID": info.ParentFileID,
+		"createAt":     info.CreateAt,
+		"trashed":      info.Trashed,
+		"punishFlag":   info.PunishFlag,
+	}, nil
+}
+
+// commandTrashList implements the "trash-list" backend command
+func (f *Fs) commandTrashList(ctx context.Context) (interface{}, error) {
+	trashed, err := f.listTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return trashed, nil
+}
+
+// commandTrashRestore implements the "trash-restore" backend command
+func (f *Fs) commandTrashRestore(ctx context.Context, fileID int64) (interface{}, error) {
+	if err := f.restoreFiles(ctx, []int64{fileID}); err != nil {
+		return nil, errors.Wrap(err, "trash-restore: failed to restore file")
+	}
+	return map[string]interface{}{"fileID": fileID, "restored": true}, nil
+}
+
+// commandTrashDelete implements the "trash-delete" backend command
+func (f *Fs) commandTrashDelete(ctx context.Context, fileID int64) (interface{}, error) {
+	if err := f.permanentlyDeleteFiles(ctx, []int64{fileID}); err != nil {
+		return nil, errors.Wrap(err, "trash-delete: failed to permanently delete file")
+	}
+	return map[string]interface{}{"fileID": fileID, "deleted": true}, nil
+}
+
+// commandTrash implements the "trash" backend command: resolve each
+// remote path to a fileID and trash everything that resolved in a
+// single batched deleteFiles call. A path that fails to resolve is
+// recorded in the returned errors map rather than aborting the rest.
+func (f *Fs) commandTrash(ctx context.Context, remotes []string) (interface{}, error) {
+	fileIDs := make([]int64, 0, len(remotes))
+	trashed := make([]string, 0, len(remotes))
+	errs := map[string]string{}
+	for _, remote := range remotes {
+		fullPath := path.Join(f.root, remote)
+		fileID, err := f.pathToFileID(ctx, fullPath, false)
+		if err != nil {
+			errs[remote] = err.Error()
+			continue
+		}
+		fileIDs = append(fileIDs, fileID)
+		trashed = append(trashed, remote)
+	}
+	if len(fileIDs) > 0 {
+		if err := f.deleteFiles(ctx, fileIDs); err != nil {
+			return nil, errors.Wrap(err, "trash: failed to trash resolved paths")
+		}
+	}
+	result := map[string]interface{}{
+		"trashed": trashed,
+		"fileIDs": fileIDs,
+	}
+	if len(errs) > 0 {
+		result["errors"] = errs
+	}
+	return result, nil
+}
+
+// commandLsTrashSize implements the "ls-trash-size" backend command
+func (f *Fs) commandLsTrashSize(ctx context.Context) (interface{}, error) {
+	trashed, err := f.listTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var totalBytes int64
+	for _, info := range trashed {
+		totalBytes += info.Size
+	}
+	return map[string]interface{}{
+		"count":      len(trashed),
+		"totalBytes": totalBytes,
+	}, nil
+}
+
+// commandEmptyTrash implements the "empty-trash" backend command
+func (f *Fs) commandEmptyTrash(ctx context.Context) (interface{}, error) {
+	removed, freedBytes, err := f.emptyTrash(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "empty-trash: removed %d file(s) (%d bytes) before hitting a failure", removed, freedBytes)
+	}
+	return map[string]interface{}{
+		"removed":    removed,
+		"freedBytes": freedBytes,
+	}, nil
+}
+
+// commandRestoreTo implements the "restore-to" backend command: restore
+// fileID out of the recycle bin, then move it to destDir, creating
+// destDir if it doesn't already exist. isBenignMoveError tolerates the
+// move landing the file at its destination already (e.g. a retry after
+// a prior partial success).
+func (f *Fs) commandRestoreTo(ctx context.Context, fileID int64, destDir string) (interface{}, error) {
+	destParentID, err := f.pathToFileID(ctx, path.Join(f.root, destDir), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "restore-to: failed to resolve destination directory")
+	}
+	if err := f.restoreFiles(ctx, []int64{fileID}); err != nil {
+		return nil, errors.Wrap(err, "restore-to: failed to restore file")
+	}
+	if err := f.moveFile(ctx, fileID, destParentID); err != nil && !isBenignMoveError(err) {
+		return nil, errors.Wrap(err, "restore-to: failed to move restored file")
+	}
+	f.clearCache()
+
+	finalPath, err := f.idToPath(ctx, fileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "restore-to: failed to resolve final path")
+	}
+	return map[string]interface{}{
+		"fileID": fileID,
+		"path":   finalPath,
+	}, nil
+}
+
+// commandRefreshUploadDomain implements the "refresh-upload-domain"
+// backend command
+func (f *Fs) commandRefreshUploadDomain(ctx context.Context) (interface{}, error) {
+	f.clearSelectedUploadDomain()
+	domain := f.selectUploadDomain(ctx, f.uploadDomainCandidates(nil))
+	f.setSelectedUploadDomain(domain)
+	fs.Logf(f, "refresh-upload-domain selected %q", domain)
+	return map[string]interface{}{
+		"domain": domain,
+	}, nil
+}
+
+// commandTestUploadDomain implements the "test-upload-domain" backend
+// command: probes every upload domain candidate (not just the first
+// reachable one, unlike refresh-upload-domain) and reports each one's
+// reachability and latency, then caches whichever responded first as
+// the sticky selection, same as a normal refresh.
+func (f *Fs) commandTestUploadDomain(ctx context.Context) (interface{}, error) {
+	candidates := f.uploadDomainCandidates(nil)
+	results := make([]map[string]interface{}, 0, len(candidates))
+	selected := ""
+	haveSelected := false
+	for _, c := range candidates {
+		reachable, latency := f.probeUploadDomainLatency(ctx, c)
+		results = append(results, map[string]interface{}{
+			"domain":    c,
+			"reachable": reachable,
+			"latencyMs": latency.Milliseconds(),
+		})
+		if reachable && !haveSelected {
+			selected = c
+			haveSelected = true
+		}
+	}
+	if !haveSelected && len(candidates) > 0 {
+		selected = candidates[0]
+	}
+	f.setSelectedUploadDomain(selected)
+	fs.Logf(f, "test-upload-domain selected %q", selected)
+	return map[string]interface{}{
+		"domains":  results,
+		"selected": selected,
+	}, nil
+}
+
+// commandPerfStats implements the "perf-stats" backend command
+func (f *Fs) commandPerfStats(ctx context.Context) (interface{}, error) {
+	avgMs, p95Ms, n := f.ttfbStats()
+	return map[string]interface{}{
+		"avg_ttfb_ms": avgMs,
+		"p95_ttfb_ms": p95Ms,
+		"samples":     n,
+	}, nil
+}
+
+// min returns the smaller of a and b.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,118 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletePollDelayDefaultsAndBacksOffExponentially(t *testing.T) {
+	f := &Fs{}
+	assert.Equal(t, defaultCompletePollBase, f.completePollDelay(0))
+	assert.Equal(t, 2*defaultCompletePollBase, f.completePollDelay(1))
+	assert.Equal(t, 4*defaultCompletePollBase, f.completePollDelay(2))
+}
+
+func TestCompletePollDelayCapsAtMax(t *testing.T) {
+	f := &Fs{}
+	f.opt.CompletePollBase = fs.Duration(time.Second)
+	f.opt.CompletePollMax = fs.Duration(4 * time.Second)
+	assert.Equal(t, time.Second, f.completePollDelay(0))
+	assert.Equal(t, 2*time.Second, f.completePollDelay(1))
+	assert.Equal(t, 4*time.Second, f.completePollDelay(2))
+	assert.Equal(t, 4*time.Second, f.completePollDelay(5))
+}
+
+// TestCompleteUploadPollsWithConfiguredBackoff confirms completeUpload
+// keeps polling upload_complete until the server reports completion (on
+// the third call here) and then stops, returning its fileID. The poll
+// base and cap are set to 1ms/10ms so the waits between calls stay short;
+// the test counts calls only and does not measure the delays themselves.
+func TestCompleteUploadPollsWithConfiguredBackoff(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls++
+		completed := calls >= 3
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"completed": completed,
+				"fileID":    99,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	f.opt.CompletePollBase = fs.Duration(time.Millisecond)
+	f.opt.CompletePollMax = fs.Duration(10 * time.Millisecond)
+
+	fileID, err := f.completeUpload(context.Background(), "preupload-id", 1024)
+	require.NoError(t, err)
+	assert.EqualValues(t, 99, fileID)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCompletePollMaxAttemptsDefaultsToSizeScaledFormula(t *testing.T) {
+	f := &Fs{}
+	assert.Equal(t, defaultCompletePollMinAttempts, f.completePollMaxAttempts(0))
+	assert.Equal(t, defaultCompletePollMinAttempts+60, f.completePollMaxAttempts(100*1024*1024))
+	assert.Equal(t, defaultCompletePollMaxAttempts, f.completePollMaxAttempts(10000*1024*1024))
+}
+
+func TestCompletePollMaxAttemptsClampsToConfiguredBounds(t *testing.T) {
+	f := &Fs{}
+	f.opt.CompletePollMinAttempts = 100
+	assert.Equal(t, 100, f.completePollMaxAttempts(0), "a small file should be able to get more patience than its size alone implies")
+
+	f = &Fs{}
+	f.opt.CompletePollMaxAttempts = 25
+	assert.Equal(t, 25, f.completePollMaxAttempts(10000*1024*1024))
+}
+
+// TestCompleteUploadGivesUpAfterMaxAttempts confirms completeUpload
+// doesn't poll forever against a server that never reports completion -
+// it gives up with a clear error once completePollMaxAttempts(size) polls
+// have been made.
+func TestCompleteUploadGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"completed": false},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	f.opt.CompletePollBase = fs.Duration(time.Millisecond)
+	f.opt.CompletePollMax = fs.Duration(time.Millisecond)
+	f.opt.CompletePollMinAttempts = 3
+	f.opt.CompletePollMaxAttempts = 3
+
+	_, err := f.completeUpload(context.Background(), "preupload-id", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not complete after 3 attempts")
+	assert.Equal(t, 3, calls)
+}
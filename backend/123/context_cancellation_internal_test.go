@@ -0,0 +1,76 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateDirectoryRespectsCancelledContext and
+// TestRenameFileRespectsCancelledContext guard against createDirectory and
+// renameFile ever gaining a bare time.Sleep (or any other wait that
+// ignores ctx) on their retry path: both rely entirely on the shared
+// pacer/shouldRetry machinery to carry ctx through to the underlying HTTP
+// request, so an already-cancelled context must make them return
+// immediately rather than blocking on the fake server, which never
+// responds.
+func newCancellationTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestCreateDirectoryRespectsCancelledContext(t *testing.T) {
+	f := newCancellationTestFs(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.createDirectory(ctx, 7, "leaf")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("createDirectory did not return promptly after context cancellation")
+	}
+}
+
+func TestRenameFileRespectsCancelledContext(t *testing.T) {
+	f := newCancellationTestFs(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f.renameFile(ctx, 42, "newname")
+	}()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("renameFile did not return promptly after context cancellation")
+	}
+	assert.True(t, true) // reaching here means the select above didn't time out
+}
@@ -0,0 +1,110 @@
+package _123
+
+import (
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// Copy src to this remote using the server-side copy API, avoiding a
+// download+upload round trip. The API's copy endpoint preserves the
+// source's name in the destination directory and has no rename
+// parameter, so when the destination needs a different name (most
+// commonly because src and dst are the same directory) this does the
+// copy, finds the newly created entry by diffing the directory's
+// contents before and after, then renames it - resolving a fresh
+// collision-free name first if the desired name is already taken.
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+
+	dstPath := path.Join(f.root, remote)
+	dstDir, dstLeaf := path.Split(dstPath)
+	destParentID, err := f.pathToFileID(ctx, dstDir, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy: failed to resolve destination directory")
+	}
+
+	srcObj.mu.Lock()
+	fileID := srcObj.id
+	srcLeaf := f.opt.Enc.FromStandardName(path.Base(srcObj.remote))
+	srcObj.mu.Unlock()
+
+	before, err := f.listDir(ctx, destParentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy: failed to list destination directory")
+	}
+	existedBefore := make(map[int64]bool, len(before))
+	for _, info := range before {
+		existedBefore[info.FileID] = true
+	}
+
+	if err := f.copyFile(ctx, fileID, destParentID); err != nil {
+		return nil, errors.Wrap(err, "copy: failed to copy")
+	}
+	// Copy only ever changes destParentID's listing - the source is
+	// untouched - so only that directory's cache needs dropping.
+	f.clearDirCacheFor(destParentID)
+
+	after, err := f.listDir(ctx, destParentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy: failed to list destination directory after copy")
+	}
+	var copied *api.FileInfo
+	for i := range after {
+		info := &after[i]
+		if !existedBefore[info.FileID] && info.Filename == srcLeaf {
+			copied = info
+			break
+		}
+	}
+	if copied == nil {
+		return nil, errors.New("copy: could not locate the copied file in the destination directory")
+	}
+
+	encodedDstLeaf := f.opt.Enc.FromStandardName(dstLeaf)
+	if encodedDstLeaf != srcLeaf {
+		newName, err := f.resolveUniqueFilename(ctx, destParentID, encodedDstLeaf)
+		if err != nil {
+			return nil, errors.Wrap(err, "copy: failed to resolve destination name")
+		}
+		if err := f.renameFile(ctx, copied.FileID, newName); err != nil && !isBenignMoveError(err) {
+			return nil, errors.Wrap(err, "copy: failed to rename copied file")
+		}
+		f.clearDirCacheFor(destParentID)
+	}
+
+	info, err := f.getFileInfo(ctx, copied.FileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy: failed to fetch copied file's metadata")
+	}
+	return f.newObjectFromInfo(remote, info)
+}
+
+// copyFile copies fileID into destParentID server-side, keeping its name
+func (f *Fs) copyFile(ctx context.Context, fileID, destParentID int64) error {
+	req := api.CopyRequest{FileIDs: []int64{fileID}, ToParentFileID: destParentID}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v1/file/copy",
+	}
+	var result api.Response
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	if !result.OK() {
+		return errors.New(result.Message)
+	}
+	return nil
+}
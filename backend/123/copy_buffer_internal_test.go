@@ -0,0 +1,52 @@
+package _123
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyWithHash(t *testing.T) {
+	f := &Fs{bufPool: &sync.Pool{New: func() interface{} { return make([]byte, 64*1024) }}}
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	var out bytes.Buffer
+	n, etag, sha1sum, err := f.copyWithHash(&out, bytes.NewReader(content), false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, content, out.Bytes())
+	sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), etag)
+	assert.Empty(t, sha1sum)
+}
+
+func TestCopyWithHashComputesSHA1WhenRequested(t *testing.T) {
+	f := &Fs{bufPool: &sync.Pool{New: func() interface{} { return make([]byte, 64*1024) }}}
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	var out bytes.Buffer
+	_, _, sha1sum, err := f.copyWithHash(&out, bytes.NewReader(content), true)
+	require.NoError(t, err)
+	sum := sha1.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), sha1sum)
+}
+
+// BenchmarkCopyWithHash measures throughput of the pooled-buffer,
+// hash-teeing copy used as the fallback path for streamingPutWithMemoryBuffer.
+func BenchmarkCopyWithHash(b *testing.B) {
+	const size = 64 * 1024 * 1024
+	content := bytes.Repeat([]byte("x"), size)
+	f := &Fs{bufPool: &sync.Pool{New: func() interface{} { return make([]byte, 1024*1024) }}}
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := f.copyWithHash(ioutil.Discard, bytes.NewReader(content), false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyTestEntry is one file or directory tracked by newCopyTestFs's fake
+// server, which is stateful enough to support copy, rename, mkdir and
+// detail/list against the same in-memory tree.
+type copyTestEntry struct {
+	fileID   int64
+	filename string
+	parentID int64
+	typ      int
+}
+
+// newCopyTestFs builds a stateful fake server backing a small file tree,
+// and returns the Fs plus a lookup of the live entries by fileID so
+// tests can assert on the result.
+func newCopyTestFs(t *testing.T, entries []copyTestEntry) (f *Fs, tree map[int64]*copyTestEntry) {
+	tree = make(map[int64]*copyTestEntry, len(entries))
+	var nextID int64 = 1000
+	for i := range entries {
+		e := entries[i]
+		tree[e.fileID] = &e
+		if e.fileID >= nextID {
+			nextID = e.fileID + 1
+		}
+	}
+	var mu sync.Mutex
+	createSeq := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			var req api.FileListRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var list []map[string]interface{}
+			for _, e := range tree {
+				if e.parentID != req.ParentFileID {
+					continue
+				}
+				list = append(list, map[string]interface{}{
+					"fileID": e.fileID, "filename": e.filename, "parentFileID": e.parentID,
+					"type": e.typ, "createAt": "2026-01-01 00:00:00",
+				})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": list},
+			})
+		case "/api/v1/file/copy":
+			var req api.CopyRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for _, srcID := range req.FileIDs {
+				src := tree[srcID]
+				require.NotNil(t, src)
+				createSeq++
+				nextID++
+				tree[nextID] = &copyTestEntry{fileID: nextID, filename: src.filename, parentID: req.ToParentFileID, typ: src.typ}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/api/v1/file/rename":
+			var req api.RenameRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			e := tree[req.FileID]
+			require.NotNil(t, e)
+			e.filename = req.Name
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/upload/v1/file/mkdir":
+			var req api.MkdirRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			nextID++
+			tree[nextID] = &copyTestEntry{fileID: nextID, filename: req.Name, parentID: req.ParentID, typ: 1}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"dirID": nextID},
+			})
+		case "/api/v1/file/detail":
+			fileID := int64(0)
+			_, _ = fmt.Sscanf(r.URL.Query().Get("fileID"), "%d", &fileID)
+			e := tree[fileID]
+			require.NotNil(t, e)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID": e.fileID, "filename": e.filename, "parentFileID": e.parentID,
+					"type": e.typ, "etag": "etag", "size": 123, "createAt": "2026-01-01 00:00:00",
+				},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, tree
+}
+
+func TestCopyWithinSameDirectoryRenames(t *testing.T) {
+	f, tree := newCopyTestFs(t, []copyTestEntry{
+		{fileID: 7, filename: "", parentID: 0, typ: 1}, // root dir itself, unused by lookups
+		{fileID: 1, filename: "src.bin", parentID: 7, typ: 0},
+	})
+	f.rootFolderID = 7
+
+	src := &Object{fs: f, remote: "src.bin", id: 1}
+	result, err := f.Copy(context.Background(), src, "dst.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "dst.bin", result.Remote())
+
+	// The original must still exist, untouched, alongside the renamed copy.
+	var names []string
+	for _, e := range tree {
+		if e.parentID == 7 {
+			names = append(names, e.filename)
+		}
+	}
+	assert.ElementsMatch(t, []string{"src.bin", "dst.bin"}, names)
+}
+
+func TestCopyToNewDestinationDirectory(t *testing.T) {
+	f, tree := newCopyTestFs(t, []copyTestEntry{
+		{fileID: 7, filename: "", parentID: 0, typ: 1},
+		{fileID: 1, filename: "src.bin", parentID: 7, typ: 0},
+	})
+	f.rootFolderID = 7
+
+	src := &Object{fs: f, remote: "src.bin", id: 1}
+	result, err := f.Copy(context.Background(), src, "newdir/src.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "newdir/src.bin", result.Remote())
+
+	var newDirID int64
+	for _, e := range tree {
+		if e.typ == 1 && e.filename == "newdir" && e.parentID == 7 {
+			newDirID = e.fileID
+		}
+	}
+	require.NotZero(t, newDirID)
+
+	var namesInNewDir []string
+	for _, e := range tree {
+		if e.parentID == newDirID {
+			namesInNewDir = append(namesInNewDir, e.filename)
+		}
+	}
+	assert.Equal(t, []string{"src.bin"}, namesInNewDir)
+
+	// The source, in the root, must be untouched.
+	original := tree[1]
+	assert.Equal(t, "src.bin", original.filename)
+	assert.Equal(t, int64(7), original.parentID)
+}
+
+// TestCopyOnlyInvalidatesDestinationParentCache confirms a cross-directory
+// Copy only drops the destination parent's cached listing, leaving an
+// unrelated sibling directory's listing alone.
+func TestCopyOnlyInvalidatesDestinationParentCache(t *testing.T) {
+	f, _ := newCopyTestFs(t, []copyTestEntry{
+		{fileID: 7, filename: "", parentID: 0, typ: 1},
+		{fileID: 1, filename: "src.bin", parentID: 7, typ: 0},
+		{fileID: 2, filename: "newdir", parentID: 7, typ: 1},
+	})
+	f.rootFolderID = 7
+	f.savePathToIDToCache("newdir", 2)
+	f.saveDirListToCache(500, []int64{999}) // unrelated sibling directory
+
+	src := &Object{fs: f, remote: "src.bin", id: 1}
+	_, err := f.Copy(context.Background(), src, "newdir/src.bin")
+	require.NoError(t, err)
+
+	_, ok := f.getDirListFromCache(500)
+	assert.True(t, ok, "an unrelated sibling directory's listing must survive the copy")
+}
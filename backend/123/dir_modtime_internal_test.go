@@ -0,0 +1,63 @@
+package _123
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListSurfacesDirectoryModTime confirms that List (not just ListR)
+// gives directory entries a real, non-zero ModTime derived from the
+// configured ListModTimeSource rather than the zero time, using the same
+// fake server and tree as TestListR.
+func TestListSurfacesDirectoryModTime(t *testing.T) {
+	f := newListRTestFs(t)
+	// listRTestTree only sets createAt, so pin the source explicitly
+	// rather than relying on the "updated" default falling back to
+	// parseFileTime's time.Now() on an empty updateAt.
+	f.opt.ListModTimeSource = "created"
+
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+
+	var dirA fs.Directory
+	for _, e := range entries {
+		if e.Remote() == "dirA" {
+			dirA = e.(fs.Directory)
+			break
+		}
+	}
+	require.NotNil(t, dirA, "dirA should be present among the root entries")
+
+	want := parseFileTime("2020-01-01 00:00:00")
+	assert.True(t, dirA.ModTime(context.Background()).Equal(want),
+		"dirA's ModTime should come from its createAt rather than the zero time")
+}
+
+// TestListRSurfacesDirectoryModTime is the ListR analogue of
+// TestListSurfacesDirectoryModTime: it walks the whole tree and checks that
+// every directory entry it yields also carries a real ModTime.
+func TestListRSurfacesDirectoryModTime(t *testing.T) {
+	f := newListRTestFs(t)
+	f.opt.ListModTimeSource = "created"
+
+	var got fs.DirEntries
+	err := f.ListR(context.Background(), "", func(entries fs.DirEntries) error {
+		got = append(got, entries...)
+		return nil
+	})
+	require.NoError(t, err)
+
+	want := parseFileTime("2020-01-01 00:00:00")
+	for _, e := range got {
+		if e.Remote() == "dirA" {
+			assert.True(t, e.(fs.Directory).ModTime(context.Background()).Equal(want),
+				"dirA's ModTime should come from its createAt rather than the zero time")
+			return
+		}
+	}
+	t.Fatal("dirA should be present among the ListR entries")
+}
@@ -0,0 +1,83 @@
+package _123
+
+import (
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// DirMove moves src, srcRemote to this remote at dstRemote, using the same
+// server-side move (and, if the leaf name changes, rename) calls as Move,
+// since moveFile and renameFile already operate on an arbitrary fileID
+// rather than anything object-specific. This avoids the slow recursive
+// copy-then-delete rclone would otherwise fall back to for a whole
+// directory tree.
+//
+// If it isn't possible then return fs.ErrorCantDirMove.
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debugf(src, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+
+	srcPath := path.Join(srcFs.root, srcRemote)
+	srcDirID, err := srcFs.pathToFileID(ctx, srcPath, false)
+	if err != nil {
+		return errors.Wrap(err, "dirmove: failed to resolve source directory")
+	}
+	srcParentID, err := srcFs.getParentID(ctx, srcDirID)
+	if err != nil {
+		return errors.Wrap(err, "dirmove: failed to resolve source directory's parent")
+	}
+
+	dstPath := path.Join(f.root, dstRemote)
+	dstDir, dstLeaf := path.Split(dstPath)
+	destParentID, err := f.pathToFileID(ctx, dstDir, true)
+	if err != nil {
+		return errors.Wrap(err, "dirmove: failed to resolve destination directory")
+	}
+	encodedDstLeaf := f.opt.Enc.FromStandardName(dstLeaf)
+	srcLeaf := path.Base(srcPath)
+
+	if destParentID == srcParentID && encodedDstLeaf == srcFs.opt.Enc.FromStandardName(srcLeaf) {
+		return fs.ErrorDirExists
+	}
+
+	renameWasBenign := false
+	if dstLeaf != srcLeaf {
+		if err := srcFs.renameFile(ctx, srcDirID, encodedDstLeaf); err != nil {
+			if !isBenignMoveError(err) {
+				return errors.Wrap(err, "dirmove: failed to rename")
+			}
+			renameWasBenign = true
+		}
+	}
+
+	moveWasBenign := false
+	if err := srcFs.moveFile(ctx, srcDirID, destParentID); err != nil {
+		if !isBenignMoveError(err) {
+			return errors.Wrap(err, "dirmove: failed to move")
+		}
+		moveWasBenign = true
+	}
+
+	srcFs.invalidatePathCacheForSubtree(srcPath)
+	srcFs.invalidateCachesForMove("", srcDirID, srcParentID, destParentID)
+	if srcFs != f {
+		f.clearDirCacheFor(destParentID)
+	}
+
+	if renameWasBenign || moveWasBenign {
+		info, err := f.getFileInfo(ctx, srcDirID)
+		if err != nil {
+			return errors.Wrap(err, "dirmove: failed to fetch moved directory's new metadata")
+		}
+		if err := confirmMoveOutcome(info, destParentID, encodedDstLeaf, renameWasBenign, moveWasBenign); err != nil {
+			return err
+		}
+	}
+	return nil
+}
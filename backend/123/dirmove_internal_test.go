@@ -0,0 +1,125 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDirMoveTestFs builds a fake server handling rename, move and detail
+// lookups for a directory move, the same shape as newMoveTestFs but with
+// detailFilename/detailParentID describing the moved directory's own
+// post-call metadata rather than a file's.
+func newDirMoveTestFs(t *testing.T, renameMessage, moveMessage string, detailParentID int64, detailFilename string) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/file/rename":
+			if renameMessage != "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": renameMessage})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/api/v1/file/move":
+			if moveMessage != "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": moveMessage})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/api/v1/file/detail":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID":       55,
+					"filename":     detailFilename,
+					"parentFileID": detailParentID,
+					"type":         1,
+					"etag":         "",
+					"size":         0,
+					"createAt":     "2026-01-01 00:00:00",
+				},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:        newCacheState(),
+		cacheConfig:  DefaultCacheConfig(),
+		srv:          rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:        fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		rootFolderID: 7,
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("srcdir", 55)
+	f.saveParentToCache(55, 7)
+	f.savePathToIDToCache("dstdir", 99)
+	return f
+}
+
+func TestDirMoveCrossDir(t *testing.T) {
+	f := newDirMoveTestFs(t, "", "", 99, "srcdir")
+	err := f.DirMove(context.Background(), f, "srcdir", "dstdir/srcdir")
+	require.NoError(t, err)
+}
+
+func TestDirMoveRenameOnly(t *testing.T) {
+	f := newDirMoveTestFs(t, "", "文件已在当前文件夹", 7, "renamed")
+	err := f.DirMove(context.Background(), f, "srcdir", "renamed")
+	require.NoError(t, err)
+}
+
+func TestDirMoveRejectsDifferentRemoteType(t *testing.T) {
+	f := newDirMoveTestFs(t, "", "", 7, "srcdir")
+	err := f.DirMove(context.Background(), mockfs.NewFs("other", "srcdir"), "srcdir", "dstdir")
+	assert.Equal(t, fs.ErrorCantDirMove, err)
+}
+
+// TestDirMoveInvalidatesOnlySubtreeAndAffectedParents confirms moving a
+// directory drops its own cached path/parent entries and every cached
+// path under it, plus the source and destination parents' listings -
+// while leaving an unrelated sibling directory's cache alone.
+func TestDirMoveInvalidatesOnlySubtreeAndAffectedParents(t *testing.T) {
+	f := newDirMoveTestFs(t, "", "", 99, "srcdir")
+	f.savePathToIDToCache("srcdir/inner.txt", 101)
+	f.saveDirListToCache(55, []int64{101})
+	f.saveDirListToCache(7, []int64{55})
+	f.saveDirListToCache(200, []int64{999}) // unrelated sibling directory
+	f.savePathToIDToCache("otherdir", 200)
+
+	err := f.DirMove(context.Background(), f, "srcdir", "dstdir/srcdir")
+	require.NoError(t, err)
+
+	_, ok := f.getPathFromCache("srcdir")
+	assert.False(t, ok, "the moved directory's own path entry should be gone")
+	_, ok = f.getPathFromCache("srcdir/inner.txt")
+	assert.False(t, ok, "cached paths under the moved directory should be gone")
+	_, ok = f.getParentFromCache(55)
+	assert.False(t, ok, "the moved directory's own parent entry should be gone")
+	_, ok = f.getDirListFromCache(7)
+	assert.False(t, ok, "the source parent's listing should be gone")
+
+	_, ok = f.getPathFromCache("otherdir")
+	assert.True(t, ok, "an unrelated cached path must survive the move")
+	_, ok = f.getDirListFromCache(200)
+	assert.True(t, ok, "an unrelated sibling directory's listing must survive the move")
+}
+
+func TestDirMoveBlockedByDifferentDirectory(t *testing.T) {
+	f := newDirMoveTestFs(t, "", "当前目录有重名文件", 7, "srcdir")
+	err := f.DirMove(context.Background(), f, "srcdir", "dstdir/srcdir")
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClampDownloadBufferSize(t *testing.T) {
+	assert.Equal(t, defaultDownloadBufferSize, clampDownloadBufferSize(0))
+	assert.Equal(t, defaultDownloadBufferSize, clampDownloadBufferSize(-1))
+	assert.Equal(t, minDownloadBufferSize, clampDownloadBufferSize(1024))
+	assert.Equal(t, maxDownloadBufferSize, clampDownloadBufferSize(1024*1024*1024))
+	assert.Equal(t, 2*1024*1024, clampDownloadBufferSize(2*1024*1024))
+}
+
+// newDownloadChunkTestFs builds a fake server serving content at /raw via
+// http.ServeContent (so Range requests work), with an object pre-seeded in
+// the path cache, for exercising downloadChunkToFile directly.
+func newDownloadChunkTestFs(t testing.TB, content []byte, bufferSize int) (*Fs, *Object) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/file/download_info":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"code":0,"data":{"downloadUrl":"` + server.URL + `/raw"}}`))
+		case "/raw":
+			http.ServeContent(w, r, "f.bin", time.Time{}, bytes.NewReader(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		downloadBufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, clampDownloadBufferSize(bufferSize)) },
+		},
+	}
+	f.srv.SetRoot(server.URL)
+	o := &Object{fs: f, remote: "f.bin", id: 42, size: int64(len(content))}
+	return f, o
+}
+
+func TestDownloadChunkToFileUsesPooledBuffer(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	f, o := newDownloadChunkTestFs(t, content, 64*1024)
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "out.bin")
+	file, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer file.Close()
+
+	acc := accounting.Stats(context.Background()).NewTransfer(o).Account(nil)
+	require.NoError(t, f.downloadChunkToFile(context.Background(), o, file, 0, int64(len(content)), acc))
+
+	got, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+// BenchmarkDownloadChunkToFile demonstrates the effect of
+// --123-download-buffer-size on a large download: larger buffers issue
+// fewer, bigger syscalls per chunk.
+func BenchmarkDownloadChunkToFile(b *testing.B) {
+	const size = 64 * 1024 * 1024
+	content := bytes.Repeat([]byte("x"), size)
+
+	for _, bufSize := range []int{32 * 1024, 1024 * 1024, 16 * 1024 * 1024} {
+		bufSize := bufSize
+		b.Run(fs.SizeSuffix(bufSize).String(), func(b *testing.B) {
+			f, o := newDownloadChunkTestFs(b, content, bufSize)
+			dir := b.TempDir()
+			dst := filepath.Join(dir, "out.bin")
+			file, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0600)
+			require.NoError(b, err)
+			defer file.Close()
+
+			acc := accounting.Stats(context.Background()).NewTransfer(o).Account(nil)
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := f.downloadChunkToFile(context.Background(), o, file, 0, size, acc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDownloadTestFs builds a fake server serving a single file "f.bin" (id
+// 42) of the given content under fileID etag, with a chunk downloaded
+// counter. The Fs's path cache is pre-seeded so NewObject resolves "f.bin"
+// without any listing calls.
+func newDownloadTestFs(t *testing.T, content []byte, etag string) (f *Fs, chunkRequests func() int32) {
+	var requests int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/file/detail":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID":   42,
+					"filename": "f.bin",
+					"type":     0,
+					"size":     len(content),
+					"etag":     etag,
+				},
+			})
+		case "/api/v1/file/download_info":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"downloadUrl": server.URL + "/raw"},
+			})
+		case "/raw":
+			atomic.AddInt32(&requests, 1)
+			http.ServeContent(w, r, "f.bin", time.Time{}, bytes.NewReader(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		downloadBufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, defaultDownloadBufferSize) },
+		},
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("f.bin", 42)
+	return f, func() int32 { return atomic.LoadInt32(&requests) }
+}
+
+func TestCommandDownloadFreshCompletes(t *testing.T) {
+	content := []byte("hello resumable download world")
+	f, chunkRequests := newDownloadTestFs(t, content, "etag-1")
+	f.opt.DownloadChunkSize = fs.SizeSuffix(8)
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "out.bin")
+
+	res, err := f.commandDownload(context.Background(), "f.bin", dst)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, false, m["resumed"])
+
+	got, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	_, err = os.Stat(sidecarPath(dst))
+	assert.True(t, os.IsNotExist(err), "sidecar should be removed on completion")
+	assert.True(t, chunkRequests() > 0)
+}
+
+// TestCommandDownloadResumesAfterInterruption simulates a process restart
+// partway through a download: a sidecar recording some chunks already
+// completed, with those bytes already present in the destination file, is
+// written to disk before commandDownload is called. Only the remaining
+// chunks should be fetched.
+func TestCommandDownloadResumesAfterInterruption(t *testing.T) {
+	content := []byte("hello resumable download world")
+	f, chunkRequests := newDownloadTestFs(t, content, "etag-1")
+	f.opt.DownloadChunkSize = fs.SizeSuffix(8)
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "out.bin")
+
+	chunkSize := int64(8)
+	totalChunks := downloadChunkCount(int64(len(content)), chunkSize)
+	completed := make([]bool, totalChunks)
+	completed[0] = true // first chunk already downloaded before the "restart"
+
+	file, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteAt(content[:chunkSize], 0)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	rec := &downloadRecord{
+		FileID:    42,
+		Remote:    "f.bin",
+		Etag:      "etag-1",
+		Size:      int64(len(content)),
+		ChunkSize: chunkSize,
+		Completed: completed,
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, saveDownloadRecord(sidecarPath(dst), rec))
+
+	res, err := f.commandDownload(context.Background(), "f.bin", dst)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, true, m["resumed"])
+	assert.Equal(t, totalChunks-1, m["chunksDownloaded"])
+
+	got, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Only the not-yet-completed chunks should have hit the server.
+	assert.EqualValues(t, totalChunks-1, chunkRequests())
+
+	_, err = os.Stat(sidecarPath(dst))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestCommandDownloadRespectsMaxConcurrentDownloads confirms chunk fetches
+// are bounded by --123-max-concurrent-downloads rather than all firing at
+// once, while still downloading more than one chunk at a time when the
+// limit allows it.
+func TestCommandDownloadRespectsMaxConcurrentDownloads(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 20) // 200 bytes, 20 chunks of 10
+	var inFlight, maxInFlight int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/file/detail":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID":   42,
+					"filename": "f.bin",
+					"type":     0,
+					"size":     len(content),
+					"etag":     "etag-1",
+				},
+			})
+		case "/api/v1/file/download_info":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"downloadUrl": server.URL + "/raw"},
+			})
+		case "/raw":
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			http.ServeContent(w, r, "f.bin", time.Time{}, bytes.NewReader(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		downloadBufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, defaultDownloadBufferSize) },
+		},
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("f.bin", 42)
+	f.opt.DownloadChunkSize = fs.SizeSuffix(10)
+	f.opt.MaxConcurrentDownloads = 2
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "out.bin")
+
+	res, err := f.commandDownload(context.Background(), "f.bin", dst)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, 20, m["chunksDownloaded"])
+
+	got, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	assert.True(t, atomic.LoadInt32(&maxInFlight) > 1, "expected more than one chunk in flight at once")
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 2, "expected at most --123-max-concurrent-downloads chunks in flight at once")
+}
+
+// TestEffectiveDownloadConcurrencyClamps checks the fallback-to-default and
+// upper-bound clamping of --123-max-concurrent-downloads.
+func TestEffectiveDownloadConcurrencyClamps(t *testing.T) {
+	f := &Fs{}
+	assert.Equal(t, defaultDownloadConcurrency(), f.effectiveDownloadConcurrency())
+
+	f.opt.MaxConcurrentDownloads = 8
+	assert.Equal(t, 8, f.effectiveDownloadConcurrency())
+
+	f.opt.MaxConcurrentDownloads = 1000
+	assert.Equal(t, maxDownloadConcurrencyLimit, f.effectiveDownloadConcurrency())
+}
+
+// TestDefaultDownloadConcurrencyFollowsCheckers checks that leaving
+// --123-max-concurrent-downloads unset follows --checkers rather than a
+// flat value disconnected from it.
+func TestDefaultDownloadConcurrencyFollowsCheckers(t *testing.T) {
+	orig := fs.Config.Checkers
+	defer func() { fs.Config.Checkers = orig }()
+
+	fs.Config.Checkers = 6
+	assert.Equal(t, 6, defaultDownloadConcurrency())
+
+	fs.Config.Checkers = 0
+	assert.Equal(t, minDownloadConcurrency, defaultDownloadConcurrency())
+}
+
+// TestCommandDownloadRestartsWhenEtagChanges confirms a sidecar left over
+// from a download of a since-modified remote file is discarded rather than
+// trusted, and the download restarts from scratch.
+func TestCommandDownloadRestartsWhenEtagChanges(t *testing.T) {
+	content := []byte("hello resumable download world")
+	f, chunkRequests := newDownloadTestFs(t, content, "etag-2")
+	f.opt.DownloadChunkSize = fs.SizeSuffix(8)
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "out.bin")
+
+	rec := &downloadRecord{
+		FileID:    42,
+		Remote:    "f.bin",
+		Etag:      "etag-1", // stale - the fake server now reports etag-2
+		Size:      int64(len(content)),
+		ChunkSize: 8,
+		Completed: []bool{true, false, false, false, false},
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, saveDownloadRecord(sidecarPath(dst), rec))
+	require.NoError(t, ioutil.WriteFile(dst, []byte("stale partial data"), 0600))
+
+	res, err := f.commandDownload(context.Background(), "f.bin", dst)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, false, m["resumed"])
+
+	got, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assert.True(t, chunkRequests() > 0)
+}
@@ -0,0 +1,117 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDownloadURLCacheTestFs returns an Fs backed by a fake server that
+// counts download_info calls and serves a URL expiring expireIn from now
+// (or with an empty expireAt, if expireIn is zero).
+func newDownloadURLCacheTestFs(t *testing.T, expireIn time.Duration) (f *Fs, downloadInfoCalls *int) {
+	downloadInfoCalls = new(int)
+	var mu sync.Mutex
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/file/download_info":
+			mu.Lock()
+			*downloadInfoCalls++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			expireAt := ""
+			if expireIn > 0 {
+				expireAt = time.Now().Add(expireIn).Format(time.RFC3339)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"downloadUrl":"` + server.URL + `/dl","expireAt":"` + expireAt + `"}}`))
+		case "/dl":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("file content"))
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, downloadInfoCalls
+}
+
+func TestGetDownloadURLCachesUntilExpiry(t *testing.T) {
+	f, calls := newDownloadURLCacheTestFs(t, time.Hour)
+
+	url1, err := f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, 1, *calls)
+
+	url2, err := f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, url1, url2)
+	assert.Equal(t, 1, *calls, "second call within TTL should be served from cache")
+}
+
+func TestGetDownloadURLRefetchesAfterSafetyWindow(t *testing.T) {
+	f, calls := newDownloadURLCacheTestFs(t, downloadURLSafetyWindow/2)
+
+	_, err := f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, 1, *calls)
+
+	_, err = f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls, "a URL within the safety window of its expiry should be refetched")
+}
+
+func TestGetDownloadURLFallsBackToDefaultTTLWhenExpireAtMissing(t *testing.T) {
+	f, calls := newDownloadURLCacheTestFs(t, 0)
+
+	_, err := f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, 1, *calls)
+
+	_, ok := f.getDownloadURLFromCache(42)
+	assert.True(t, ok, "a missing expireAt should still be cached, using the fallback TTL")
+
+	_, err = f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestEvictDownloadURLFromCacheForcesRefetch(t *testing.T) {
+	f, calls := newDownloadURLCacheTestFs(t, time.Hour)
+
+	_, err := f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, 1, *calls)
+
+	f.evictDownloadURLFromCache(42)
+
+	_, err = f.getDownloadURL(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIsDownloadURLExpiredResponse(t *testing.T) {
+	assert.False(t, isDownloadURLExpiredResponse(nil))
+	assert.True(t, isDownloadURLExpiredResponse(&http.Response{StatusCode: http.StatusForbidden}))
+	assert.True(t, isDownloadURLExpiredResponse(&http.Response{StatusCode: http.StatusGone}))
+	assert.False(t, isDownloadURLExpiredResponse(&http.Response{StatusCode: http.StatusOK}))
+}
@@ -0,0 +1,80 @@
+package _123
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommandTreeSizeReportsDirCount reuses newTreeSizeTestFs's fixture
+// tree (root -> a.txt, sub -> b.txt) to check that commandTreeSize, the
+// function backing both "tree-size" and "du", now exposes a directory
+// count alongside size and file count.
+func TestCommandTreeSizeReportsDirCount(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+	f.savePathToIDToCache("dir", 1)
+
+	res, err := f.commandTreeSize(context.Background(), "dir", -1)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 350, m["totalSize"])
+	assert.EqualValues(t, 2, m["fileCount"])
+	assert.EqualValues(t, 1, m["dirCount"])
+}
+
+func TestCommandTreeSizeHonoursMaxDepth(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+	f.savePathToIDToCache("dir", 1)
+
+	res, err := f.commandTreeSize(context.Background(), "dir", 0)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 100, m["totalSize"])
+	assert.EqualValues(t, 1, m["fileCount"])
+	assert.EqualValues(t, 1, m["dirCount"])
+}
+
+// TestCommandDispatchesDuWithMaxDepthOption confirms "rclone backend du
+// <dir> -o max-depth=N" reaches commandTreeSize with the parsed depth,
+// not just commandTreeSize called directly.
+func TestCommandDispatchesDuWithMaxDepthOption(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+	f.savePathToIDToCache("dir", 1)
+
+	res, err := f.Command(context.Background(), "du", []string{"dir"}, map[string]string{"max-depth": "0"})
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 100, m["totalSize"])
+	assert.EqualValues(t, 1, m["dirCount"])
+}
+
+// TestCommandDuWithoutMaxDepthIsUnbounded confirms "du" without -o
+// max-depth walks the whole subtree, same as "tree-size".
+func TestCommandDuWithoutMaxDepthIsUnbounded(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+	f.savePathToIDToCache("dir", 1)
+
+	res, err := f.Command(context.Background(), "du", []string{"dir"}, nil)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 350, m["totalSize"])
+	assert.EqualValues(t, 2, m["fileCount"])
+}
+
+func TestCommandDuRejectsNonIntegerMaxDepth(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+
+	_, err := f.Command(context.Background(), "du", nil, map[string]string{"max-depth": "not-a-number"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max-depth must be an integer")
+}
+
+func TestCommandDuRejectsMoreThanOneArgument(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+
+	_, err := f.Command(context.Background(), "du", []string{"a", "b"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "du takes at most 1 argument")
+}
@@ -0,0 +1,78 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDuplicateNameTestFs returns an Fs backed by a fake server whose root
+// directory contains both a file and a directory named "dup", simulating
+// 123 Pan's ability to have same-named entries of different types.
+func newDuplicateNameTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"lastFileId": -1,
+				"fileList": []map[string]interface{}{
+					{"fileID": 1, "filename": "dup", "parentFileID": 0, "type": 1, "createAt": "2020-01-01 00:00:00"},
+					{"fileID": 2, "filename": "dup", "parentFileID": 0, "type": 0, "createAt": "2020-06-01 00:00:00"},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestFindChildPrefersDirectoryForIntermediateSegment(t *testing.T) {
+	f := newDuplicateNameTestFs(t)
+
+	id, err := f.findChild(context.Background(), 0, "dup", true, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id, "intermediate segment must resolve to the directory, not the file")
+}
+
+func TestFindChildAllowsEitherTypeForLeafSegment(t *testing.T) {
+	f := newDuplicateNameTestFs(t)
+
+	// The leaf can be either type; the most recently created match wins.
+	id, err := f.findChild(context.Background(), 0, "dup", false, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+}
+
+func TestPathToFileIDWalksThroughDirectoryNotFile(t *testing.T) {
+	f := newDuplicateNameTestFs(t)
+
+	// "dup/dup" can only resolve if the first "dup" is treated as the
+	// directory (fileID 1); treating it as the file (fileID 2) would fail
+	// to list children at all.
+	id, err := f.pathToFileID(context.Background(), "dup/dup", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+}
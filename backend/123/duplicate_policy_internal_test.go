@@ -0,0 +1,84 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicatePolicyValue(t *testing.T) {
+	for _, tc := range []struct {
+		policy string
+		want   int
+	}{
+		{"", 1},
+		{"rename", 1},
+		{"overwrite", 2},
+	} {
+		got, err := duplicatePolicyValue(tc.policy)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := duplicatePolicyValue("bogus")
+	assert.Error(t, err)
+}
+
+func TestValidateOptionsRejectsUnknownDuplicatePolicy(t *testing.T) {
+	opt := &Options{DuplicatePolicy: "bogus"}
+	assert.Error(t, validateOptions(opt))
+}
+
+// newDuplicatePolicyTestFs records the Duplicate value sent in every
+// upload/v1/file/create request it receives.
+func newDuplicatePolicyTestFs(t *testing.T, policy string) (f *Fs, requests *[]api.UploadCreateRequest) {
+	var reqs []api.UploadCreateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.UploadCreateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		reqs = append(reqs, req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"fileID": 1, "preuploadID": "p1", "reuse": true},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		opt:   Options{DuplicatePolicy: policy},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, &reqs
+}
+
+func TestCreateUploadSessionSendsRenameDuplicateByDefault(t *testing.T) {
+	f, requests := newDuplicatePolicyTestFs(t, "")
+
+	_, err := f.createUploadSession(context.Background(), 0, "a.txt", 10, "etag")
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+	assert.Equal(t, 1, (*requests)[0].Duplicate)
+}
+
+func TestCreateUploadSessionSendsOverwriteDuplicate(t *testing.T) {
+	f, requests := newDuplicatePolicyTestFs(t, "overwrite")
+
+	_, err := f.createUploadSession(context.Background(), 0, "a.txt", 10, "etag")
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+	assert.Equal(t, 2, (*requests)[0].Duplicate)
+}
@@ -0,0 +1,106 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmptyDirTestFs returns an Fs backed by a fake server that behaves
+// like a real directory-based backend: a directory created via mkdir
+// persists with no children and is listed back as a plain fs.Dir, the
+// same as any non-empty one.
+func newEmptyDirTestFs(t *testing.T) *Fs {
+	var mu sync.Mutex
+	children := map[int64][]map[string]interface{}{}
+	nextID := int64(1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/upload/v1/file/mkdir":
+			var req struct {
+				Name     string `json:"name"`
+				ParentID int64  `json:"parentID"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			id := nextID
+			nextID++
+			children[req.ParentID] = append(children[req.ParentID], map[string]interface{}{
+				"fileID": id, "filename": req.Name, "parentFileID": req.ParentID,
+				"type": 1, "createAt": "2020-01-01 00:00:00",
+			})
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"dirID": id},
+			})
+		case "/api/v2/file/list":
+			var req struct {
+				ParentFileID int64 `json:"parentFileId"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			list := children[req.ParentFileID]
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList":   list,
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+// TestEmptyDirectoryPersistsAndLists checks that a directory created with
+// Mkdir and left empty still shows up as an fs.Dir when its parent is
+// listed, i.e. the API doesn't silently drop empty directories the way
+// some object-storage-backed backends do.
+func TestEmptyDirectoryPersistsAndLists(t *testing.T) {
+	f := newEmptyDirTestFs(t)
+
+	require.NoError(t, f.Mkdir(context.Background(), "empty"))
+
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	dir, ok := entries[0].(fs.Directory)
+	require.True(t, ok, "empty directory must be listed as an fs.Directory, not dropped")
+	assert.Equal(t, "empty", dir.Remote())
+
+	// And the now-empty directory itself lists with no children.
+	children, err := f.List(context.Background(), "empty")
+	require.NoError(t, err)
+	assert.Empty(t, children)
+}
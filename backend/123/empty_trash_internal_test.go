@@ -0,0 +1,112 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmptyTrashTestFs builds a fake server whose trash listing contains n
+// entries of size bytesPerEntry each, and whose delete endpoint fails for
+// any batch whose first fileID is in failFirstIDs - simulating a genuine
+// per-batch failure rather than a blanket one, so emptyTrash's "keep going
+// after a failed batch" behaviour can be exercised.
+func newEmptyTrashTestFs(t *testing.T, n int, bytesPerEntry int64, failFirstIDs map[int64]bool) (f *Fs, deleteCalls *[][]int64) {
+	deleteCalls = new([][]int64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/file/trash/list":
+			fileList := make([]map[string]interface{}, n)
+			for i := 0; i < n; i++ {
+				fileList[i] = map[string]interface{}{
+					"fileID":   i + 1,
+					"filename": "trashed.bin",
+					"size":     bytesPerEntry,
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList":   fileList,
+				},
+			})
+		case "/api/v1/file/delete":
+			var req api.DeleteRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*deleteCalls = append(*deleteCalls, req.FileIDs)
+			if len(req.FileIDs) > 0 && failFirstIDs[req.FileIDs[0]] {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, deleteCalls
+}
+
+func TestEmptyTrashDeletesEverythingInOneBatch(t *testing.T) {
+	f, deleteCalls := newEmptyTrashTestFs(t, 3, 100, nil)
+
+	removed, freedBytes, err := f.emptyTrash(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	assert.EqualValues(t, 300, freedBytes)
+	assert.Equal(t, 1, len(*deleteCalls))
+}
+
+// TestEmptyTrashContinuesPastAFailedBatch confirms a failed batch doesn't
+// abort the remaining batches, and that the aggregate counts only reflect
+// what actually got deleted.
+func TestEmptyTrashContinuesPastAFailedBatch(t *testing.T) {
+	n := trashDeleteBatchSize + 50
+	f, deleteCalls := newEmptyTrashTestFs(t, n, 10, map[int64]bool{1: true})
+
+	removed, freedBytes, err := f.emptyTrash(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 2 batch(es) failed")
+	assert.Equal(t, 50, removed)
+	assert.EqualValues(t, 500, freedBytes)
+	assert.Equal(t, 2, len(*deleteCalls))
+	assert.Equal(t, trashDeleteBatchSize, len((*deleteCalls)[0]))
+	assert.Equal(t, 50, len((*deleteCalls)[1]))
+}
+
+func TestCommandEmptyTrashReportsRemovedAndFreedBytes(t *testing.T) {
+	f, _ := newEmptyTrashTestFs(t, 2, 150, nil)
+
+	result, err := f.commandEmptyTrash(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"removed": 2, "freedBytes": int64(300)}, result)
+}
+
+func TestCommandDispatchesEmptyTrash(t *testing.T) {
+	f, deleteCalls := newEmptyTrashTestFs(t, 1, 50, nil)
+
+	_, err := f.Command(context.Background(), "empty-trash", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(*deleteCalls))
+}
@@ -0,0 +1,54 @@
+package _123
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDefaultEncodingRoundTripsReservedCharacters confirms the default
+// --123-encoding mask quotes every character 123pan's API rejects
+// ("\/:*?|><) rather than leaving them for a caller to strip or mangle,
+// so a name containing them round-trips losslessly through
+// FromStandardName/ToStandardName.
+func TestDefaultEncodingRoundTripsReservedCharacters(t *testing.T) {
+	enc := encoder.Base | encoder.EncodeSlash | encoder.EncodeBackSlash | encoder.EncodeWin | encoder.EncodeInvalidUtf8
+
+	for _, name := range []string{
+		`report"final.txt`,
+		`back\slash.txt`,
+		`weird:name?.txt`,
+		`star*pipe|lt<gt>.txt`,
+	} {
+		encoded := enc.FromStandardName(name)
+		for _, bad := range []byte{'"', '\\', ':', '?', '*', '|', '<', '>'} {
+			assert.NotContains(t, encoded, string(bad), "encoded name %q should not contain %q", encoded, string(bad))
+		}
+		assert.Equal(t, name, enc.ToStandardName(encoded))
+	}
+}
+
+// TestCleanFileNameAfterEncodingStaysValidForReservedCharacters confirms
+// the order the upload path actually runs these two steps in - encode
+// first (Put in 123.go and Update in object.go both call FromStandardName
+// before Object.upload calls cleanFileName) - doesn't regress once a name is long enough to need
+// truncation too. FromStandardName's quoted replacements are themselves
+// multibyte, so cleanFileName's truncation still has to land on a rune
+// boundary even though it's operating on already-encoded text.
+func TestCleanFileNameAfterEncodingStaysValidForReservedCharacters(t *testing.T) {
+	enc := encoder.Base | encoder.EncodeSlash | encoder.EncodeBackSlash | encoder.EncodeWin | encoder.EncodeInvalidUtf8
+
+	name := strings.Repeat(`weird:name*with|reserved"chars<>`, 10) + ".txt"
+	encoded := enc.FromStandardName(name)
+	if len(encoded) <= maxFileNameBytes {
+		t.Fatalf("fixture should actually need truncation, got %d bytes", len(encoded))
+	}
+
+	cleaned := cleanFileName(encoded)
+	assert.NoError(t, validateFileName(cleaned))
+	assert.True(t, utf8.ValidString(cleaned), "truncation must not split one of FromStandardName's multibyte replacements")
+	assert.True(t, strings.HasSuffix(cleaned, ".txt"))
+}
@@ -0,0 +1,55 @@
+package _123
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// maxFileNameBytes is the longest filename, as UTF-8 bytes, the 123pan API
+// accepts. A name that exceeds it is rejected server-side with an
+// unhelpful error, so it's validated - and, where it came from
+// truncating something longer, trimmed to fit - before ever being sent.
+const maxFileNameBytes = 255
+
+// validateFileName reports whether name fits within maxFileNameBytes once
+// UTF-8 encoded. name is expected to already be server-encoded (see
+// Options.Enc), since encoding can only grow a name, never shrink it.
+func validateFileName(name string) error {
+	if len(name) > maxFileNameBytes {
+		return errors.Errorf("filename %q is %d bytes, exceeds the %d byte limit", name, len(name), maxFileNameBytes)
+	}
+	return nil
+}
+
+// cleanFileName truncates name to fit within maxFileNameBytes once UTF-8
+// encoded, preserving the extension and never splitting a multibyte
+// rune. A name already within budget is returned unchanged.
+func cleanFileName(name string) string {
+	if len(name) <= maxFileNameBytes {
+		return name
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	budget := maxFileNameBytes - len(ext)
+	if budget <= 0 {
+		// The extension alone doesn't fit within budget, so there's
+		// nothing meaningful left to preserve - just hard-truncate.
+		return truncateUTF8(name, maxFileNameBytes)
+	}
+	return truncateUTF8(base, budget) + ext
+}
+
+// truncateUTF8 returns the longest prefix of s that fits within maxBytes
+// without splitting a multibyte rune.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
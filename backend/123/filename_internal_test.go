@@ -0,0 +1,67 @@
+package _123
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFileNameAcceptsWithinBudget(t *testing.T) {
+	assert.NoError(t, validateFileName("hello.txt"))
+	assert.NoError(t, validateFileName(strings.Repeat("a", maxFileNameBytes)))
+}
+
+func TestValidateFileNameRejectsOverBudget(t *testing.T) {
+	err := validateFileName(strings.Repeat("a", maxFileNameBytes+1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the 255 byte limit")
+}
+
+func TestCleanFileNameLeavesShortNamesUnchanged(t *testing.T) {
+	assert.Equal(t, "hello.txt", cleanFileName("hello.txt"))
+}
+
+func TestCleanFileNamePreservesExtension(t *testing.T) {
+	name := strings.Repeat("a", 300) + ".txt"
+	cleaned := cleanFileName(name)
+	assert.LessOrEqual(t, len(cleaned), maxFileNameBytes)
+	assert.True(t, strings.HasSuffix(cleaned, ".txt"))
+}
+
+func TestCleanFileNameNeverSplitsAMultibyteRune(t *testing.T) {
+	// Each "中" is 3 bytes, so a budget-hugging ASCII-length count alone
+	// would slice into the middle of one if truncation weren't
+	// rune-boundary-aware.
+	name := strings.Repeat("中", 200) + ".txt"
+	cleaned := cleanFileName(name)
+	assert.True(t, utf8.ValidString(cleaned), "cleaned name must remain valid UTF-8: %q", cleaned)
+	assert.LessOrEqual(t, len(cleaned), maxFileNameBytes)
+}
+
+// TestCleanThenValidateRoundTrips is the adversarial round-trip check:
+// whatever cleanFileName produces, validateFileName must always accept -
+// for pure-ASCII, pure-multibyte and mixed inputs, regardless of how far
+// over budget the input started.
+func TestCleanThenValidateRoundTrips(t *testing.T) {
+	inputs := []string{
+		"short.txt",
+		strings.Repeat("a", 254) + ".txt",
+		strings.Repeat("a", 255) + ".txt",
+		strings.Repeat("a", 256) + ".txt",
+		strings.Repeat("中", 1) + ".txt",
+		strings.Repeat("中", 100) + ".txt",
+		strings.Repeat("中", 300) + ".txt",
+		strings.Repeat("🎉", 300) + ".txt",
+		strings.Repeat("a", 10000),
+		strings.Repeat("中", 10) + strings.Repeat("a", 10000) + ".tar.gz",
+		"." + strings.Repeat("a", 400),
+		strings.Repeat("a", 300) + strings.Repeat(".", 300),
+	}
+	for _, in := range inputs {
+		cleaned := cleanFileName(in)
+		assert.NoError(t, validateFileName(cleaned), "input %q cleaned to %q", in, cleaned)
+	}
+}
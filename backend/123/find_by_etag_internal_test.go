@@ -0,0 +1,89 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFindByEtagTestFs builds a fake server exposing this tree:
+//
+//	/ (root=1)
+//	  a.txt     etag=target
+//	  sub/ (id=2)
+//	    b.txt   etag=other
+//	    c.txt   etag=target
+func newFindByEtagTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var req struct {
+			ParentFileID int64 `json:"parentFileId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var files []map[string]interface{}
+		switch req.ParentFileID {
+		case 1:
+			files = []map[string]interface{}{
+				{"fileID": 10, "filename": "a.txt", "type": 0, "etag": "target"},
+				{"fileID": 2, "filename": "sub", "type": 1, "etag": ""},
+			}
+		case 2:
+			files = []map[string]interface{}{
+				{"fileID": 20, "filename": "b.txt", "type": 0, "etag": "other"},
+				{"fileID": 21, "filename": "c.txt", "type": 0, "etag": "target"},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"fileList": files, "lastFileId": -1},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		rootFolderID: 1,
+		cache:        newCacheState(),
+		srv:          rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:        fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestCommandFindByEtag(t *testing.T) {
+	f := newFindByEtagTestFs(t)
+
+	res, err := f.commandFindByEtag(context.Background(), "", "target", -1)
+	require.NoError(t, err)
+	matches := res.(map[string]interface{})["matches"].([]string)
+	assert.ElementsMatch(t, []string{"a.txt", "sub/c.txt"}, matches)
+}
+
+func TestCommandFindByEtagRespectsMaxDepth(t *testing.T) {
+	f := newFindByEtagTestFs(t)
+
+	res, err := f.commandFindByEtag(context.Background(), "", "target", 0)
+	require.NoError(t, err)
+	matches := res.(map[string]interface{})["matches"].([]string)
+	assert.ElementsMatch(t, []string{"a.txt"}, matches)
+}
+
+func TestCommandFindByEtagNoMatch(t *testing.T) {
+	f := newFindByEtagTestFs(t)
+
+	res, err := f.commandFindByEtag(context.Background(), "", "nonexistent", -1)
+	require.NoError(t, err)
+	matches := res.(map[string]interface{})["matches"].([]string)
+	assert.Empty(t, matches)
+}
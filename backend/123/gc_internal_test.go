@@ -0,0 +1,80 @@
+package _123
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGCTestFs(t *testing.T) *Fs {
+	dir, err := ioutil.TempDir("", "123-gc-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return &Fs{
+		opt:         Options{ProgressDir: dir},
+		cache:       newCacheState(),
+		cacheConfig: CacheConfig{ParentCacheTTL: time.Millisecond, DirCacheTTL: time.Millisecond, PathCacheTTL: time.Millisecond},
+	}
+}
+
+func TestCleanupExpiredResumeInfo(t *testing.T) {
+	f := newGCTestFs(t)
+
+	require.NoError(t, f.saveResumeRecord(&resumeRecord{Etag: "old", CreatedAt: time.Now().Add(-2 * resumeRecordMaxAge)}))
+	require.NoError(t, f.saveResumeRecord(&resumeRecord{Etag: "fresh", CreatedAt: time.Now()}))
+
+	removed, bytesReclaimed, err := f.cleanupExpiredResumeInfo(resumeRecordMaxAge)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.True(t, bytesReclaimed > 0)
+
+	rec, err := f.loadResumeRecord("fresh")
+	require.NoError(t, err)
+	assert.NotNil(t, rec)
+
+	rec, err = f.loadResumeRecord("old")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+}
+
+func TestCleanupOrphanTempFiles(t *testing.T) {
+	f := newGCTestFs(t)
+
+	oldPath := filepath.Join(f.opt.ProgressDir, "123-xfer-old")
+	require.NoError(t, ioutil.WriteFile(oldPath, []byte("x"), 0600))
+	oldTime := time.Now().Add(-2 * orphanTempFileMaxAge)
+	require.NoError(t, os.Chtimes(oldPath, oldTime, oldTime))
+
+	freshPath := filepath.Join(f.opt.ProgressDir, "123-xfer-fresh")
+	require.NoError(t, ioutil.WriteFile(freshPath, []byte("x"), 0600))
+
+	removed, bytesReclaimed, err := f.cleanupOrphanTempFiles()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.True(t, bytesReclaimed > 0)
+
+	_, err = os.Stat(oldPath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(freshPath)
+	assert.NoError(t, err)
+}
+
+func TestCommandGC(t *testing.T) {
+	f := newGCTestFs(t)
+
+	f.saveDirListToCache(1, []int64{2, 3})
+	time.Sleep(5 * time.Millisecond) // let the TTL expire
+
+	result, err := f.commandGC(context.Background())
+	require.NoError(t, err)
+	summary, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, 1, summary["evictedCacheEntries"])
+}
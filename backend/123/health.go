@@ -0,0 +1,114 @@
+package _123
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// healthCheckTimeout bounds each individual sub-check of commandHealth, so
+// one slow or unreachable dependency can't stall the others or make the
+// overall probe hang.
+const healthCheckTimeout = 5 * time.Second
+
+// healthCheckResult is the outcome of a single commandHealth sub-check.
+type healthCheckResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func healthOK() healthCheckResult {
+	return healthCheckResult{OK: true}
+}
+
+func healthErr(err error) healthCheckResult {
+	return healthCheckResult{OK: false, Error: err.Error()}
+}
+
+// commandHealth implements the "health" backend command: a fast
+// aggregate readiness check suitable for a liveness/readiness probe.
+func (f *Fs) commandHealth(ctx context.Context) (interface{}, error) {
+	checks := map[string]healthCheckResult{
+		"token":         f.healthCheckToken(ctx),
+		"upload_domain": f.healthCheckUploadDomain(ctx),
+		"list":          f.healthCheckList(ctx),
+		"cache":         f.healthCheckCache(),
+	}
+	ok := true
+	for _, result := range checks {
+		if !result.OK {
+			ok = false
+			break
+		}
+	}
+	return map[string]interface{}{
+		"ok":     ok,
+		"checks": checks,
+	}, nil
+}
+
+// healthCheckToken verifies the current access token is valid by making
+// the cheapest authenticated call available, fetching account info.
+func (f *Fs) healthCheckToken(ctx context.Context) healthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if _, err := f.getUserInfo(ctx); err != nil {
+		return healthErr(err)
+	}
+	return healthOK()
+}
+
+// healthCheckUploadDomain probes the first candidate upload domain (the
+// sticky one if set, else the normal fallback order) for reachability.
+func (f *Fs) healthCheckUploadDomain(ctx context.Context) healthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	domain := f.preferSelectedUploadDomain(f.uploadDomainCandidates(nil))[0]
+	if !f.probeUploadDomain(ctx, domain) {
+		return healthErr(errors.Errorf("upload domain %q is unreachable", domain))
+	}
+	return healthOK()
+}
+
+// healthCheckList lists a single entry of the root, to confirm the API
+// is reachable and the configured root resolves to something listable,
+// without paying for a full directory walk.
+func (f *Fs) healthCheckList(ctx context.Context) healthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	dirID, err := f.pathToFileID(ctx, f.root, true)
+	if err != nil {
+		return healthErr(err)
+	}
+	var result api.FileListResponse
+	req := api.FileListRequest{ParentFileID: dirID, Limit: 1}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v2/file/list",
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return healthErr(err)
+	}
+	if !result.OK() {
+		return healthErr(errors.Errorf("%s", result.Message))
+	}
+	return healthOK()
+}
+
+// healthCheckCache confirms the in-memory cache is initialised and its
+// mutex isn't deadlocked.
+func (f *Fs) healthCheckCache() healthCheckResult {
+	if f.cache == nil {
+		return healthErr(errors.New("cache not initialised"))
+	}
+	f.cache.mu.Lock()
+	f.cache.mu.Unlock()
+	return healthOK()
+}
@@ -0,0 +1,93 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHealthTestFs builds an Fs backed by a fake server that answers
+// user/info and file/list successfully, so every sub-check of the
+// "health" command can pass.
+func newHealthTestFs(t *testing.T, failList bool) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/user/info":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"uid": 1, "nickname": "test"},
+			})
+		case "/api/v2/file/list":
+			if failList {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "boom"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": []map[string]interface{}{}},
+			})
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		opt:           Options{},
+		cache:         newCacheState(),
+		cacheConfig:   DefaultCacheConfig(),
+		memoryManager: newMemoryManager(0),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 64*1024) },
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestCommandHealthReportsOKWhenEverythingPasses(t *testing.T) {
+	f := newHealthTestFs(t, false)
+
+	result, err := f.commandHealth(context.Background())
+	require.NoError(t, err)
+	m := result.(map[string]interface{})
+	assert.Equal(t, true, m["ok"])
+
+	checks := m["checks"].(map[string]healthCheckResult)
+	for name, check := range checks {
+		assert.True(t, check.OK, "expected %s check to pass, got error %q", name, check.Error)
+	}
+}
+
+func TestCommandHealthReportsFailureForBadSubCheckOnly(t *testing.T) {
+	f := newHealthTestFs(t, true)
+
+	result, err := f.commandHealth(context.Background())
+	require.NoError(t, err)
+	m := result.(map[string]interface{})
+	assert.Equal(t, false, m["ok"])
+
+	checks := m["checks"].(map[string]healthCheckResult)
+	assert.False(t, checks["list"].OK)
+	assert.True(t, checks["token"].OK)
+	assert.True(t, checks["cache"].OK)
+}
+
+func TestHealthCheckCacheFailsWhenCacheNil(t *testing.T) {
+	f := &Fs{}
+	assert.False(t, f.healthCheckCache().OK)
+}
@@ -0,0 +1,83 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idToPathTestTree maps fileID -> (filename, parentFileID) for a tiny fake directory
+// structure used by TestIDToPath: root(0) -> a(1) -> b(2) -> c.txt(3).
+var idToPathTestTree = map[int64][2]interface{}{
+	1: {"a", int64(0)},
+	2: {"b", int64(1)},
+	3: {"c.txt", int64(2)},
+}
+
+func newIDToPathTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fileID, _ := strconv.ParseInt(r.URL.Query().Get("fileID"), 10, 64)
+		entry, ok := idToPathTestTree[fileID]
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "not found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"fileID":       fileID,
+				"filename":     entry[0],
+				"parentFileID": entry[1],
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestIDToPath(t *testing.T) {
+	f := newIDToPathTestFs(t)
+
+	got, err := f.idToPath(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, "a/b/c.txt", got)
+}
+
+func TestIDToPathRoot(t *testing.T) {
+	f := newIDToPathTestFs(t)
+
+	got, err := f.idToPath(context.Background(), f.rootFolderID)
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+}
+
+func TestIDToPathUnreachable(t *testing.T) {
+	f := newIDToPathTestFs(t)
+
+	_, err := f.idToPath(context.Background(), 999)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
@@ -0,0 +1,83 @@
+package _123
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubFs is a minimal fs.Fs standing in for a real backend in
+// isRemoteSource tests - a local disk, S3, another 123-like cloud, or (via
+// unwrap) a wrapper such as crypt/chunker/union sitting in front of one of
+// those. None of the list/object methods are exercised by isRemoteSource.
+type stubFs struct {
+	name    string
+	isLocal bool
+	unwrap  fs.Fs
+}
+
+func (s stubFs) Name() string             { return s.name }
+func (s stubFs) Root() string             { return "" }
+func (s stubFs) String() string           { return s.name }
+func (s stubFs) Precision() time.Duration { return time.Second }
+func (s stubFs) Hashes() hash.Set         { return hash.Set(hash.None) }
+func (s stubFs) Features() *fs.Features {
+	features := &fs.Features{IsLocal: s.isLocal}
+	if s.unwrap != nil {
+		base := s.unwrap
+		features.UnWrap = func() fs.Fs { return base }
+	}
+	return features
+}
+func (s stubFs) List(ctx context.Context, dir string) (fs.DirEntries, error) { return nil, nil }
+func (s stubFs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	return nil, fs.ErrorObjectNotFound
+}
+func (s stubFs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return nil, fs.ErrorNotImplemented
+}
+func (s stubFs) Mkdir(ctx context.Context, dir string) error { return nil }
+func (s stubFs) Rmdir(ctx context.Context, dir string) error { return nil }
+
+func newStubObjectInfo(base fs.Fs) fs.ObjectInfo {
+	return object.NewStaticObjectInfo("file.bin", time.Now(), 1234, true, nil, base)
+}
+
+func TestIsRemoteSourceNilSrcIsTreatedAsRemote(t *testing.T) {
+	assert.True(t, isRemoteSource(nil))
+}
+
+func TestIsRemoteSourceLocalDisk(t *testing.T) {
+	src := newStubObjectInfo(stubFs{name: "local", isLocal: true})
+	assert.False(t, isRemoteSource(src))
+}
+
+func TestIsRemoteSourceOtherCloudBackend(t *testing.T) {
+	assert.True(t, isRemoteSource(newStubObjectInfo(stubFs{name: "s3", isLocal: false})))
+	assert.True(t, isRemoteSource(newStubObjectInfo(stubFs{name: "115", isLocal: false})))
+}
+
+// TestIsRemoteSourceUnwrapsWrappedLocalFs confirms a wrapped Fs (crypt,
+// chunker, union, ...) reporting its own non-local Name/Features doesn't
+// fool isRemoteSource when its Features().UnWrap chain bottoms out at a
+// real local disk.
+func TestIsRemoteSourceUnwrapsWrappedLocalFs(t *testing.T) {
+	base := stubFs{name: "local", isLocal: true}
+	wrapped := stubFs{name: "crypt", isLocal: false, unwrap: base}
+	assert.False(t, isRemoteSource(newStubObjectInfo(wrapped)))
+}
+
+// TestIsRemoteSourceUnwrapsWrappedRemoteFs confirms the same unwrap chain
+// correctly reports remote when the base Fs underneath the wrapper isn't
+// local either.
+func TestIsRemoteSourceUnwrapsWrappedRemoteFs(t *testing.T) {
+	base := stubFs{name: "s3", isLocal: false}
+	wrapped := stubFs{name: "crypt", isLocal: false, unwrap: base}
+	assert.True(t, isRemoteSource(newStubObjectInfo(wrapped)))
+}
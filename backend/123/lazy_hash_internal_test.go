@@ -0,0 +1,100 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashLazilyFetchesEmptyEtag checks that an Object listed with no
+// Etag (e.g. a file still processing server-side) fetches its md5 on
+// demand from Hash rather than returning an empty string forever.
+func TestHashLazilyFetchesEmptyEtag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"fileID": 42,
+				"etag":   "abc123",
+			},
+		})
+	}))
+	defer server.Close()
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+
+	o, err := f.newObjectFromInfo("foo.txt", &api.FileInfo{FileID: 42, Filename: "foo.txt", Etag: ""})
+	require.NoError(t, err)
+
+	got, err := o.(*Object).Hash(context.Background(), hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", got)
+
+	// A second call should reuse the now-cached value without another round trip.
+	got, err = o.(*Object).Hash(context.Background(), hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", got)
+}
+
+func TestHashReturnsCachedEtagWithoutNetworkCall(t *testing.T) {
+	f := &Fs{}
+	o, err := f.newObjectFromInfo("foo.txt", &api.FileInfo{FileID: 1, Filename: "foo.txt", Etag: "already-known"})
+	require.NoError(t, err)
+
+	got, err := o.(*Object).Hash(context.Background(), hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "already-known", got)
+}
+
+// TestHashSHA1UnsupportedWithoutLocalUpload checks that requesting SHA1
+// for an object this process didn't itself upload (so it never computed
+// one) returns ErrUnsupported, regardless of the compute_sha1_on_upload
+// setting - there's no server-side SHA1 to fall back to.
+func TestHashSHA1UnsupportedWithoutLocalUpload(t *testing.T) {
+	f := &Fs{}
+	f.opt.ComputeSHA1OnUpload = true
+	o, err := f.newObjectFromInfo("foo.txt", &api.FileInfo{FileID: 1, Filename: "foo.txt", Etag: "abc"})
+	require.NoError(t, err)
+
+	_, err = o.(*Object).Hash(context.Background(), hash.SHA1)
+	assert.Equal(t, hash.ErrUnsupported, err)
+}
+
+// TestHashSHA1ReturnsLocallyComputedValue checks that once an Object has
+// a sha1sum set (as upload does when compute_sha1_on_upload is set),
+// Hash(SHA1) returns it without any network call.
+func TestHashSHA1ReturnsLocallyComputedValue(t *testing.T) {
+	f := &Fs{}
+	o, err := f.newObjectFromInfo("foo.txt", &api.FileInfo{FileID: 1, Filename: "foo.txt", Etag: "abc"})
+	require.NoError(t, err)
+	o.(*Object).sha1sum = "deadbeef"
+
+	got, err := o.(*Object).Hash(context.Background(), hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "deadbeef", got)
+}
+
+func TestHashesAdvertisesSHA1OnlyWhenComputeSHA1OnUploadSet(t *testing.T) {
+	f := &Fs{}
+	assert.Equal(t, hash.Set(hash.MD5), f.Hashes())
+
+	f.opt.ComputeSHA1OnUpload = true
+	assert.Equal(t, hash.NewHashSet(hash.MD5, hash.SHA1), f.Hashes())
+}
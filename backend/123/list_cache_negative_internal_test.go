@@ -0,0 +1,102 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newListCacheNegativeTestFs returns an Fs backed by an empty root
+// directory, counting how many times its listing is requested so tests
+// can tell whether a second lookup of the same missing path hit the API
+// again or was served from the negative cache.
+func newListCacheNegativeTestFs(t *testing.T, negative bool) (f *Fs, listCalls *int) {
+	listCalls = new(int)
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			mu.Lock()
+			*listCalls++
+			mu.Unlock()
+			var req api.FileListRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": []interface{}{}},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.opt.ListCacheNegative = negative
+	f.srv.SetRoot(server.URL)
+	return f, listCalls
+}
+
+func TestPathToFileIDSecondMissingLookupHitsNegativeCache(t *testing.T) {
+	f, listCalls := newListCacheNegativeTestFs(t, true)
+
+	_, err := f.pathToFileID(context.Background(), "missing.txt", false)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	require.Equal(t, 1, *listCalls)
+
+	_, err = f.pathToFileID(context.Background(), "missing.txt", false)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	assert.Equal(t, 1, *listCalls, "second lookup should have been served from the negative cache")
+}
+
+func TestPathToFileIDMissingLookupWithoutNegativeCacheStillWalks(t *testing.T) {
+	f, listCalls := newListCacheNegativeTestFs(t, false)
+
+	_, err := f.pathToFileID(context.Background(), "missing.txt", false)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	require.Equal(t, 1, *listCalls)
+
+	_, err = f.pathToFileID(context.Background(), "missing.txt", false)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	assert.Equal(t, 2, *listCalls, "without the option, every lookup should walk again")
+}
+
+func TestInvalidateNegativePathCacheForParentDropsOnlyThatParent(t *testing.T) {
+	f, _ := newListCacheNegativeTestFs(t, true)
+	f.saveNegativePathToCache("a.txt", 1)
+	f.saveNegativePathToCache("b.txt", 2)
+
+	f.invalidateNegativePathCacheForParent(1)
+
+	assert.False(t, f.getNegativePathFromCache("a.txt"))
+	assert.True(t, f.getNegativePathFromCache("b.txt"))
+}
+
+func TestCreateDirectoryInvalidatesNegativePathCacheForItsParent(t *testing.T) {
+	f, _ := newListCacheNegativeTestFs(t, true)
+	f.saveNegativePathToCache("newdir", f.rootFolderID)
+	require.True(t, f.getNegativePathFromCache("newdir"))
+
+	f.clearDirCacheFor(f.rootFolderID)
+
+	assert.False(t, f.getNegativePathFromCache("newdir"))
+}
@@ -0,0 +1,107 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listRTestTree maps parentFileID -> children for a small fake directory
+// structure used by TestListR: root(0) -> dirA(1, dir), top.txt(2, file);
+// dirA(1) -> nested.txt(3, file).
+var listRTestTree = map[int64][]map[string]interface{}{
+	0: {
+		{"fileID": 1, "filename": "dirA", "parentFileID": 0, "type": 1, "createAt": "2020-01-01 00:00:00"},
+		{"fileID": 2, "filename": "top.txt", "parentFileID": 0, "type": 0, "size": 5, "createAt": "2020-01-01 00:00:00"},
+	},
+	1: {
+		{"fileID": 3, "filename": "nested.txt", "parentFileID": 1, "type": 0, "size": 7, "createAt": "2020-01-02 00:00:00"},
+	},
+}
+
+func newListRTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ParentFileID int64 `json:"parentFileID"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"lastFileId": -1,
+				"fileList":   listRTestTree[req.ParentFileID],
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:      rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:    fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		features: &fs.Features{},
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestListR(t *testing.T) {
+	f := newListRTestFs(t)
+
+	var got fs.DirEntries
+	err := f.ListR(context.Background(), "", func(entries fs.DirEntries) error {
+		got = append(got, entries...)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var remotes []string
+	for _, e := range got {
+		remotes = append(remotes, e.Remote())
+	}
+	assert.ElementsMatch(t, []string{"dirA", "top.txt", "dirA/nested.txt"}, remotes)
+}
+
+func TestListRRespectsCancellation(t *testing.T) {
+	f := newListRTestFs(t)
+
+	ctx, cancel := context.Background(), func() {}
+	ctx, cancel = contextWithImmediateCancel(ctx)
+	defer cancel()
+
+	err := f.ListR(ctx, "", func(entries fs.DirEntries) error { return nil })
+	assert.Equal(t, context.Canceled, err)
+}
+
+// contextWithImmediateCancel returns a context that is already cancelled.
+func contextWithImmediateCancel(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	cancel()
+	return ctx, cancel
+}
+
+func TestCommandListJSON(t *testing.T) {
+	f := newListRTestFs(t)
+
+	result, err := f.commandListJSON(context.Background(), "")
+	require.NoError(t, err)
+	items, ok := result.([]json.RawMessage)
+	require.True(t, ok)
+	assert.Len(t, items, 3)
+}
@@ -0,0 +1,45 @@
+package _123
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListPopulatesRealModTimeForFiles confirms List() gives each file
+// entry the modTime parsed from the API's timestamp fields rather than
+// time.Now(), so incremental syncs can rely on it instead of re-uploading
+// every file on every run.
+func TestListPopulatesRealModTimeForFiles(t *testing.T) {
+	f := newListRTestFs(t)
+	f.opt.ListModTimeSource = "created"
+
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+
+	var top fs.Object
+	for _, e := range entries {
+		if e.Remote() == "top.txt" {
+			top = e.(fs.Object)
+		}
+	}
+	require.NotNil(t, top)
+
+	want := parseFileTime("2020-01-01 00:00:00")
+	assert.True(t, top.ModTime(context.Background()).Equal(want), "got %v, want %v", top.ModTime(context.Background()), want)
+	assert.False(t, top.ModTime(context.Background()).Equal(time.Now()), "modTime should come from the API, not time.Now()")
+}
+
+func TestPrecisionIsOneSecond(t *testing.T) {
+	f := &Fs{}
+	assert.Equal(t, time.Second, f.Precision())
+}
+
+func TestSetModTimeReturnsCantSetModTime(t *testing.T) {
+	o := &Object{}
+	assert.Equal(t, fs.ErrorCantSetModTime, o.SetModTime(context.Background(), time.Now()))
+}
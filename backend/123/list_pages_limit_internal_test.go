@@ -0,0 +1,58 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListDirBoundsRunawayPagination confirms that a server which never
+// sends a terminating lastFileId of -1 doesn't cause listDir to page
+// forever - it aborts with an error once --123-list-all-pages-limit pages
+// have been fetched.
+func TestListDirBoundsRunawayPagination(t *testing.T) {
+	var nextID int64 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := nextID
+		nextID++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"fileList":   []map[string]interface{}{{"fileID": id, "filename": "f", "type": 0, "size": 1}},
+				"lastFileId": id, // never -1, so a naive loop would run forever
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	f.opt.ListAllPagesLimit = 5
+
+	_, err := f.listDir(context.Background(), 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5 pages")
+}
+
+// TestListDirDefaultPagesLimitAppliesWhenUnset pins the value of
+// defaultListAllPagesLimit, the bound listDir uses in place of a zero
+// ListAllPagesLimit; it checks the constant only and lists nothing.
+func TestListDirDefaultPagesLimitAppliesWhenUnset(t *testing.T) {
+	assert.Equal(t, 10000, defaultListAllPagesLimit)
+}
@@ -0,0 +1,29 @@
+package _123
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListRWarmsPathAndParentCaches confirms that ListR's walk populates
+// the same path->fileID and fileID->parent caches pathToFileID itself
+// uses, so a lookup of a path ListR just visited is served from cache
+// without another API call.
+func TestListRWarmsPathAndParentCaches(t *testing.T) {
+	f := newListRTestFs(t)
+
+	err := f.ListR(context.Background(), "", func(entries fs.DirEntries) error { return nil })
+	require.NoError(t, err)
+
+	id, ok := f.getPathFromCache("dira")
+	require.True(t, ok)
+	assert.EqualValues(t, 1, id)
+
+	parentID, ok := f.getParentFromCache(3)
+	require.True(t, ok)
+	assert.EqualValues(t, 1, parentID)
+}
@@ -0,0 +1,86 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newListStaleCacheTestFs builds a fake server where directory 1's live
+// children are just {100}, while the path cache claims fileID 999 (which
+// the server has no record of, so getFileInfo 404s) resolves to "gone.txt".
+func newListStaleCacheTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileList": []map[string]interface{}{
+						{"fileID": 100, "filename": "a.txt", "type": 0},
+					},
+					"lastFileId": -1,
+				},
+			})
+		case "/api/v1/file/detail":
+			fileID := r.URL.Query().Get("fileID")
+			if fileID == "999" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "not found"})
+				return
+			}
+			n, _ := strconv.ParseInt(fileID, 10, 64)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"fileID": n},
+			})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:       newCacheState(),
+		cacheConfig: CacheConfig{DirCacheTTL: time.Minute, PathCacheTTL: time.Minute},
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+
+	f.saveDirListToCache(1, []int64{100, 200}) // 200 doesn't really exist any more
+	f.savePathToIDToCache("a.txt", 100)        // still valid
+	f.savePathToIDToCache("gone.txt", 999)     // no longer resolves
+	return f
+}
+
+func TestCommandListStaleCacheFindsMismatches(t *testing.T) {
+	f := newListStaleCacheTestFs(t)
+
+	res, err := f.commandListStaleCache(context.Background(), defaultStaleCacheSampleSize)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+
+	staleDirs := m["staleDirs"].([]map[string]interface{})
+	require.Len(t, staleDirs, 1)
+	assert.EqualValues(t, 1, staleDirs[0]["parentFileID"])
+
+	stalePaths := m["stalePaths"].([]map[string]interface{})
+	require.Len(t, stalePaths, 1)
+	assert.Equal(t, "gone.txt", stalePaths[0]["path"])
+}
+
+func TestCommandListStaleCacheRejectsBadSampleSize(t *testing.T) {
+	f := newListStaleCacheTestFs(t)
+	_, err := f.commandListStaleCache(context.Background(), 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package _123
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// crossCloudMD5CacheTTL is how long a cached MD5/SHA1 is trusted before
+// it's treated as expired, matching the lazy-expiry window this cache has
+// always implicitly had via retries happening well within a session.
+const crossCloudMD5CacheTTL = 24 * time.Hour
+
+// defaultMD5CacheMaxEntries caps crossCloudMD5Cache when
+// --123-md5-cache-max-entries is left at 0, bounding how much memory a
+// long-running process that sees many distinct cross-cloud sources can
+// have tied up in cached hashes.
+const defaultMD5CacheMaxEntries = 10000
+
+// md5CacheSweepInterval is how often the background sweep goroutine
+// proactively prunes expired entries, rather than relying solely on the
+// lazy expiry check in lookupCrossCloudMD5.
+const md5CacheSweepInterval = 10 * time.Minute
+
+// md5CacheEntry is one cached hash, with the bookkeeping eviction and
+// expiry need: cachedAt for crossCloudMD5CacheTTL, lastUsed for LRU
+// eviction in evictCrossCloudMD5LRULocked.
+type md5CacheEntry struct {
+	md5sum   string
+	sha1sum  string
+	cachedAt time.Time
+	lastUsed time.Time
+}
+
+// crossCloudMD5Cache caches the MD5 (and, if computed, SHA1) of a
+// cross-cloud transfer's source content, keyed by crossCloudMD5CacheKey,
+// so a Put retried for the same source within crossCloudMD5CacheTTL
+// doesn't need a fresh hashing pass over content it has already buffered
+// once. It is a package-level global shared by every 123 remote in the
+// process, so all access must go through mutex.
+var crossCloudMD5Cache = struct {
+	mutex     sync.Mutex
+	entries   map[string]md5CacheEntry
+	sweepOnce sync.Once
+}{entries: make(map[string]md5CacheEntry)}
+
+// crossCloudMD5CacheKey identifies a cross-cloud transfer's source well
+// enough to treat a retried Put against the same (remote, size, modTime)
+// as the same content, without needing to hash anything to compute the
+// key itself.
+func crossCloudMD5CacheKey(srcFsName, srcRemote string, size int64, modTime time.Time) string {
+	return srcFsName + "\x00" + srcRemote + "\x00" + strconv.FormatInt(size, 10) + "\x00" + modTime.UTC().Format(time.RFC3339Nano)
+}
+
+// lookupCrossCloudMD5 returns a previously cached hash for key, if present
+// and no older than crossCloudMD5CacheTTL. A hit refreshes lastUsed so the
+// entry counts as recently used for evictCrossCloudMD5LRULocked.
+func lookupCrossCloudMD5(key string) (md5sum, sha1sum string, ok bool) {
+	crossCloudMD5Cache.mutex.Lock()
+	defer crossCloudMD5Cache.mutex.Unlock()
+	entry, found := crossCloudMD5Cache.entries[key]
+	if !found {
+		return "", "", false
+	}
+	if time.Since(entry.cachedAt) > crossCloudMD5CacheTTL {
+		delete(crossCloudMD5Cache.entries, key)
+		return "", "", false
+	}
+	entry.lastUsed = time.Now()
+	crossCloudMD5Cache.entries[key] = entry
+	return entry.md5sum, entry.sha1sum, true
+}
+
+// cacheCrossCloudMD5 stores md5sum/sha1sum under key, evicting the
+// least-recently-used entries first if the cache would otherwise grow
+// past maxEntries (0 falls back to defaultMD5CacheMaxEntries). It also
+// starts the background sweep goroutine on first use.
+func cacheCrossCloudMD5(key, md5sum, sha1sum string, maxEntries int) {
+	if maxEntries <= 0 {
+		maxEntries = defaultMD5CacheMaxEntries
+	}
+	startCrossCloudMD5CacheSweep()
+
+	crossCloudMD5Cache.mutex.Lock()
+	defer crossCloudMD5Cache.mutex.Unlock()
+	now := time.Now()
+	crossCloudMD5Cache.entries[key] = md5CacheEntry{md5sum: md5sum, sha1sum: sha1sum, cachedAt: now, lastUsed: now}
+	evictCrossCloudMD5LRULocked(maxEntries)
+}
+
+// evictCrossCloudMD5LRULocked removes the least-recently-used entries
+// until the cache holds at most maxEntries. Callers must hold
+// crossCloudMD5Cache.mutex.
+func evictCrossCloudMD5LRULocked(maxEntries int) {
+	for len(crossCloudMD5Cache.entries) > maxEntries {
+		var oldestKey string
+		var oldestUsed time.Time
+		first := true
+		for k, v := range crossCloudMD5Cache.entries {
+			if first || v.lastUsed.Before(oldestUsed) {
+				oldestKey, oldestUsed, first = k, v.lastUsed, false
+			}
+		}
+		delete(crossCloudMD5Cache.entries, oldestKey)
+	}
+}
+
+// pruneExpiredCrossCloudMD5Locked removes every entry older than
+// crossCloudMD5CacheTTL, returning how many were removed. Callers must
+// hold crossCloudMD5Cache.mutex.
+func pruneExpiredCrossCloudMD5Locked() (removed int) {
+	cutoff := time.Now().Add(-crossCloudMD5CacheTTL)
+	for k, v := range crossCloudMD5Cache.entries {
+		if v.cachedAt.Before(cutoff) {
+			delete(crossCloudMD5Cache.entries, k)
+			removed++
+		}
+	}
+	return removed
+}
+
+// startCrossCloudMD5CacheSweep launches the background goroutine that
+// proactively prunes expired entries every md5CacheSweepInterval, rather
+// than relying solely on the lazy expiry check in lookupCrossCloudMD5. It
+// runs at most once per process, since crossCloudMD5Cache is itself a
+// package-level global shared by every 123 remote.
+func startCrossCloudMD5CacheSweep() {
+	crossCloudMD5Cache.sweepOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(md5CacheSweepInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				crossCloudMD5Cache.mutex.Lock()
+				pruneExpiredCrossCloudMD5Locked()
+				crossCloudMD5Cache.mutex.Unlock()
+			}
+		}()
+	})
+}
+
+// crossCloudMD5CacheLen returns the current number of cached entries, for
+// tests and diagnostics.
+func crossCloudMD5CacheLen() int {
+	crossCloudMD5Cache.mutex.Lock()
+	defer crossCloudMD5Cache.mutex.Unlock()
+	return len(crossCloudMD5Cache.entries)
+}
@@ -0,0 +1,143 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetCrossCloudMD5Cache clears crossCloudMD5Cache so tests don't see
+// entries left behind by other tests sharing this package-level global.
+func resetCrossCloudMD5Cache(t *testing.T) {
+	crossCloudMD5Cache.mutex.Lock()
+	crossCloudMD5Cache.entries = make(map[string]md5CacheEntry)
+	crossCloudMD5Cache.mutex.Unlock()
+	t.Cleanup(func() {
+		crossCloudMD5Cache.mutex.Lock()
+		crossCloudMD5Cache.entries = make(map[string]md5CacheEntry)
+		crossCloudMD5Cache.mutex.Unlock()
+	})
+}
+
+func TestCacheCrossCloudMD5EvictsLRUPastMaxEntries(t *testing.T) {
+	resetCrossCloudMD5Cache(t)
+	const maxEntries = 10
+
+	for i := 0; i < maxEntries*5; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		cacheCrossCloudMD5(key, "md5", "", maxEntries)
+		assert.LessOrEqual(t, crossCloudMD5CacheLen(), maxEntries, "cache must never grow past maxEntries")
+	}
+	assert.Equal(t, maxEntries, crossCloudMD5CacheLen())
+
+	// The most recently inserted entries should have survived eviction;
+	// the earliest ones should be gone.
+	_, _, ok := lookupCrossCloudMD5("key-0")
+	assert.False(t, ok, "oldest entry should have been evicted")
+	_, _, ok = lookupCrossCloudMD5(fmt.Sprintf("key-%d", maxEntries*5-1))
+	assert.True(t, ok, "most recently inserted entry should still be cached")
+}
+
+func TestCacheCrossCloudMD5TouchOnLookupProtectsFromEviction(t *testing.T) {
+	resetCrossCloudMD5Cache(t)
+	const maxEntries = 3
+
+	cacheCrossCloudMD5("a", "md5a", "", maxEntries)
+	cacheCrossCloudMD5("b", "md5b", "", maxEntries)
+	cacheCrossCloudMD5("c", "md5c", "", maxEntries)
+
+	// Touch "a" so it's no longer the least-recently-used entry.
+	_, _, ok := lookupCrossCloudMD5("a")
+	require.True(t, ok)
+
+	cacheCrossCloudMD5("d", "md5d", "", maxEntries)
+
+	_, _, ok = lookupCrossCloudMD5("a")
+	assert.True(t, ok, "recently touched entry should survive eviction")
+	_, _, ok = lookupCrossCloudMD5("b")
+	assert.False(t, ok, "untouched oldest entry should have been evicted")
+}
+
+func TestLookupCrossCloudMD5ExpiresAfterTTL(t *testing.T) {
+	resetCrossCloudMD5Cache(t)
+
+	crossCloudMD5Cache.mutex.Lock()
+	crossCloudMD5Cache.entries["stale"] = md5CacheEntry{
+		md5sum:   "deadbeef",
+		cachedAt: time.Now().Add(-crossCloudMD5CacheTTL - time.Minute),
+		lastUsed: time.Now().Add(-crossCloudMD5CacheTTL - time.Minute),
+	}
+	crossCloudMD5Cache.mutex.Unlock()
+
+	_, _, ok := lookupCrossCloudMD5("stale")
+	assert.False(t, ok, "entry older than crossCloudMD5CacheTTL must not be returned")
+	assert.Equal(t, 0, crossCloudMD5CacheLen(), "expired entry should have been removed on lookup")
+}
+
+func TestPruneExpiredCrossCloudMD5LockedRemovesOnlyExpired(t *testing.T) {
+	resetCrossCloudMD5Cache(t)
+
+	crossCloudMD5Cache.mutex.Lock()
+	crossCloudMD5Cache.entries["fresh"] = md5CacheEntry{md5sum: "f", cachedAt: time.Now(), lastUsed: time.Now()}
+	crossCloudMD5Cache.entries["stale"] = md5CacheEntry{md5sum: "s", cachedAt: time.Now().Add(-crossCloudMD5CacheTTL - time.Minute), lastUsed: time.Now()}
+	removed := pruneExpiredCrossCloudMD5Locked()
+	crossCloudMD5Cache.mutex.Unlock()
+
+	assert.Equal(t, 1, removed)
+	_, _, ok := lookupCrossCloudMD5("fresh")
+	assert.True(t, ok)
+}
+
+// TestPrepareUploadSourceSkipsRehashingOnCacheHit confirms that a second
+// upload of the same cross-cloud source reuses the hash cached by the
+// first attempt instead of hashing the content again.
+func TestPrepareUploadSourceSkipsRehashingOnCacheHit(t *testing.T) {
+	resetCrossCloudMD5Cache(t)
+	f := newPrepareUploadSourceTestFs(t)
+	content := bytes.Repeat([]byte("z"), 4096)
+	src := object.NewStaticObjectInfo("source.bin", time.Now(), int64(len(content)), true, nil, sourceFsStub{})
+
+	source1, _, etag1, _, cleanup1, err := f.prepareUploadSource(context.Background(), sequentialOnlyReader{bytes.NewReader(content)}, int64(len(content)), src)
+	require.NoError(t, err)
+	defer cleanup1()
+	got1 := make([]byte, len(content))
+	_, err = source1.ReadAt(got1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, content, got1)
+	assert.Equal(t, md5Hex(content), etag1)
+	assert.Equal(t, 1, crossCloudMD5CacheLen())
+
+	// Retry with mismatched content: if the cache hit is actually taken,
+	// the returned etag still reflects the first attempt's hash rather
+	// than being recomputed from this (deliberately different) content.
+	retryContent := bytes.Repeat([]byte("y"), 4096)
+	source2, resolvedSize2, etag2, _, cleanup2, err := f.prepareUploadSource(context.Background(), sequentialOnlyReader{bytes.NewReader(retryContent)}, int64(len(retryContent)), src)
+	require.NoError(t, err)
+	defer cleanup2()
+
+	assert.Equal(t, etag1, etag2, "cache hit should reuse the first attempt's hash")
+	assert.EqualValues(t, len(retryContent), resolvedSize2)
+	got2 := make([]byte, len(retryContent))
+	_, err = source2.ReadAt(got2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, retryContent, got2, "content itself must still be buffered faithfully even on a hash cache hit")
+}
+
+// sourceFsStub is a minimal fs.Info for naming a cross-cloud source in
+// crossCloudMD5CacheKey; none of its other methods are exercised.
+type sourceFsStub struct{}
+
+func (sourceFsStub) Name() string             { return "stub" }
+func (sourceFsStub) Root() string             { return "" }
+func (sourceFsStub) String() string           { return "stub" }
+func (sourceFsStub) Precision() time.Duration { return time.Second }
+func (sourceFsStub) Hashes() hash.Set         { return hash.Set(hash.None) }
+func (sourceFsStub) Features() *fs.Features   { return &fs.Features{} }
@@ -0,0 +1,134 @@
+package _123
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overDeliveringReader yields all of data, which tests make longer than
+// the size they pass alongside it, simulating a source whose reported size
+// doesn't match what it delivers.
+type overDeliveringReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *overDeliveringReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// shortDeliveringReader simulates a mid-stream read failure: it yields
+// fewer bytes than the caller was told to expect, then hits a clean EOF,
+// as a network error truncating a response body might look from the
+// reader's side.
+type shortDeliveringReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *shortDeliveringReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+func TestPutSmallFileWithMD5(t *testing.T) {
+	content := []byte("The quick brown fox jumps over the lazy dog")
+	data, etag, sha1sum, err := putSmallFileWithMD5(bytes.NewReader(content), int64(len(content)), false)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), etag)
+	assert.Empty(t, sha1sum)
+}
+
+func TestPutSmallFileWithMD5ComputesSHA1WhenRequested(t *testing.T) {
+	content := []byte("The quick brown fox jumps over the lazy dog")
+	_, _, sha1sum, err := putSmallFileWithMD5(bytes.NewReader(content), int64(len(content)), true)
+	require.NoError(t, err)
+	sum := sha1.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), sha1sum)
+}
+
+func TestPutSmallFileWithMD5OverDelivering(t *testing.T) {
+	// Source claims to be 4 bytes but actually delivers far more than the
+	// allowed margin on top of that.
+	content := bytes.Repeat([]byte("x"), 4+2*memoryBufferMargin)
+	in := &overDeliveringReader{data: content}
+	_, _, _, err := putSmallFileWithMD5(in, 4, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "more than its reported size")
+}
+
+func newTestFsForCopy(limit int64) *Fs {
+	return &Fs{
+		memoryManager: newMemoryManager(limit),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 64*1024) },
+		},
+	}
+}
+
+func TestStreamingPutWithMemoryBufferFallsBackWhenMemoryTight(t *testing.T) {
+	f := newTestFsForCopy(1) // effectively no headroom
+	content := []byte("hello world")
+	var out bytes.Buffer
+	n, etag, _, err := f.streamingPutWithMemoryBuffer(bytes.NewReader(content), &out, int64(len(content)), false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, content, out.Bytes())
+	sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), etag)
+}
+
+func TestStreamingPutWithMemoryBufferBoundsOverDeliveringSource(t *testing.T) {
+	f := newTestFsForCopy(0)
+	content := bytes.Repeat([]byte("x"), 4+2*memoryBufferMargin)
+	in := &overDeliveringReader{data: content}
+	var out bytes.Buffer
+	_, _, _, err := f.streamingPutWithMemoryBuffer(in, &out, 4, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "more than its reported size")
+}
+
+// TestStreamingPutWithMemoryBufferRejectsShortSource covers the in-memory
+// path (size small enough to buffer): a source that delivers fewer bytes
+// than its reported size, with no Read error of its own, must still be
+// treated as a failed transfer rather than a short file.
+func TestStreamingPutWithMemoryBufferRejectsShortSource(t *testing.T) {
+	f := newTestFsForCopy(2 << 20) // room for 100 bytes plus memoryBufferMargin
+	in := &shortDeliveringReader{data: []byte("only 4")}
+	var out bytes.Buffer
+	_, _, _, err := f.streamingPutWithMemoryBuffer(in, &out, 100, false)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// TestStreamingPutWithMemoryBufferRejectsShortSourceFallbackPath covers the
+// pooled-buffer fallback path (memory tight), which must apply the same
+// short-read check as the in-memory path.
+func TestStreamingPutWithMemoryBufferRejectsShortSourceFallbackPath(t *testing.T) {
+	f := newTestFsForCopy(1) // effectively no headroom, forces the fallback copy
+	in := &shortDeliveringReader{data: []byte("only 4")}
+	var out bytes.Buffer
+	_, _, _, err := f.streamingPutWithMemoryBuffer(in, &out, 100, false)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
@@ -0,0 +1,79 @@
+package _123
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeSelectsConfiguredSource(t *testing.T) {
+	info := api.FileInfo{
+		CreateAt: "2020-01-01 00:00:00",
+		UpdateAt: "2021-06-15 12:30:00",
+	}
+
+	f := &Fs{opt: Options{ListModTimeSource: "modified"}}
+	modTime, otherTime := f.parseTime(info)
+	assert.True(t, modTime.Equal(parseFileTime(info.UpdateAt)))
+	assert.True(t, otherTime.Equal(parseFileTime(info.CreateAt)))
+
+	f.opt.ListModTimeSource = "created"
+	modTime, otherTime = f.parseTime(info)
+	assert.True(t, modTime.Equal(parseFileTime(info.CreateAt)))
+	assert.True(t, otherTime.Equal(parseFileTime(info.UpdateAt)))
+}
+
+func TestObjectMetadataSurfacesTheOtherTimestamp(t *testing.T) {
+	f := &Fs{opt: Options{ListModTimeSource: "modified"}}
+	info := &api.FileInfo{
+		FileID:   1,
+		Filename: "foo.txt",
+		CreateAt: "2020-01-01 00:00:00",
+		UpdateAt: "2021-06-15 12:30:00",
+	}
+	obj, err := f.newObjectFromInfo("foo.txt", info)
+	require.NoError(t, err)
+	o := obj.(*Object)
+
+	assert.True(t, o.ModTime(context.Background()).Equal(parseFileTime(info.UpdateAt)))
+
+	md, err := o.Metadata(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, md, "created")
+	created, err := time.Parse(time.RFC3339, md["created"])
+	require.NoError(t, err)
+	assert.True(t, created.Equal(parseFileTime(info.CreateAt)))
+}
+
+// TestObjectMetadataSurfacesCategoryAndReviewFields confirms category,
+// status, punishFlag and trashed - already present on the api.FileInfo
+// an Object is built from - are surfaced by Metadata without any extra
+// API call (f has no srv configured, so a call to getFileInfo would
+// panic rather than silently succeed).
+func TestObjectMetadataSurfacesCategoryAndReviewFields(t *testing.T) {
+	f := &Fs{opt: Options{ListModTimeSource: "modified"}}
+	info := &api.FileInfo{
+		FileID:     1,
+		Filename:   "video.mp4",
+		Category:   2,
+		Status:     100,
+		PunishFlag: 1,
+		Trashed:    0,
+		CreateAt:   "2020-01-01 00:00:00",
+		UpdateAt:   "2021-06-15 12:30:00",
+	}
+	obj, err := f.newObjectFromInfo("video.mp4", info)
+	require.NoError(t, err)
+	o := obj.(*Object)
+
+	md, err := o.Metadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "2", md["category"])
+	assert.Equal(t, "100", md["status"])
+	assert.Equal(t, "1", md["punishFlag"])
+	assert.Equal(t, "0", md["trashed"])
+}
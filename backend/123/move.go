@@ -0,0 +1,172 @@
+package _123
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// benignMoveErrorMessages are API error messages indicating the desired
+// end state of a move - the file living at the destination - was already
+// achieved by the time the call was made, rather than a real failure.
+var benignMoveErrorMessages = []string{
+	"当前目录有重名文件", // a same-name file already exists in the destination
+	"文件已在当前文件夹", // the file is already in the target folder
+}
+
+// isBenignMoveError reports whether err is one of the API's "already
+// correctly placed" responses. Every move failure branch should check
+// this before giving up, since the file ending up at the destination -
+// which is what the caller wanted - isn't a failure just because the API
+// phrased it as one.
+func isBenignMoveError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, benign := range benignMoveErrorMessages {
+		if strings.Contains(msg, benign) {
+			return true
+		}
+	}
+	return false
+}
+
+// Move moves src to remote using the server-side move (and, if the leaf
+// name changes, rename) API rather than a copy-then-delete. renameFile is
+// only called when the leaf name changes, so a file is never renamed onto
+// itself; moveFile is always called, even for a same-directory move, and
+// its "already in the target folder" reply is treated as benign below.
+//
+// If either call reports an isBenignMoveError, that only means the
+// desired end state *might* already be true (e.g. a retried move landing
+// on itself) - it's just as consistent with a *different* file already
+// occupying that name or directory, in which case nothing actually moved
+// despite the API call looking like a no-op success. confirmMoveOutcome
+// checks the file's post-call metadata against what Move was asked to
+// achieve before trusting a benign error, the same way verifyUploadedSize
+// re-checks an upload rather than trusting upload_complete's report.
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+
+	dstPath := path.Join(f.root, remote)
+	dstDir, dstLeaf := path.Split(dstPath)
+	destParentID, err := f.pathToFileID(ctx, dstDir, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "move: failed to resolve destination directory")
+	}
+	encodedDstLeaf := f.opt.Enc.FromStandardName(dstLeaf)
+
+	srcObj.mu.Lock()
+	fileID := srcObj.id
+	srcLeaf := path.Base(srcObj.remote)
+	oldFullPath := path.Join(f.root, srcObj.remote)
+	srcObj.mu.Unlock()
+
+	oldParentID, haveOldParentID := f.getParentFromCache(fileID)
+
+	renameWasBenign := false
+	if dstLeaf != srcLeaf {
+		if err := f.renameFile(ctx, fileID, encodedDstLeaf); err != nil {
+			if !isBenignMoveError(err) {
+				return nil, errors.Wrap(err, "move: failed to rename")
+			}
+			renameWasBenign = true
+		}
+	}
+
+	moveWasBenign := false
+	if err := f.moveFile(ctx, fileID, destParentID); err != nil {
+		if !isBenignMoveError(err) {
+			return nil, errors.Wrap(err, "move: failed to move")
+		}
+		moveWasBenign = true
+	}
+
+	if haveOldParentID {
+		f.invalidateCachesForMove(oldFullPath, fileID, oldParentID, destParentID)
+	} else {
+		// Without a cached parent to target precisely, fall back to the
+		// full flush rather than risk leaving a stale listing somewhere.
+		f.clearCache()
+	}
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "move: failed to fetch moved file's new metadata")
+	}
+	if err := confirmMoveOutcome(info, destParentID, encodedDstLeaf, renameWasBenign, moveWasBenign); err != nil {
+		return nil, err
+	}
+	return f.newObjectFromInfo(remote, info)
+}
+
+// confirmMoveOutcome checks info - the moved file's post-call metadata -
+// against what Move was asked to achieve, but only when a benign error was
+// swallowed along the way. A call that returned no error at all is trusted
+// without this check, matching how the rest of this backend treats a
+// successful API response.
+func confirmMoveOutcome(info *api.FileInfo, wantParentID int64, wantLeaf string, renameWasBenign, moveWasBenign bool) error {
+	if renameWasBenign && info.Filename != wantLeaf {
+		return errors.Errorf("move: a different file already named %q blocked the rename", wantLeaf)
+	}
+	if moveWasBenign && info.ParentFileID != wantParentID {
+		return errors.Errorf("move: a different file named %q already exists in the destination directory", wantLeaf)
+	}
+	return nil
+}
+
+// renameFile renames fileID to newName server-side. Like createDirectory,
+// it has no sleep of its own - retries go through f.pacer/f.shouldRetry,
+// which check ctx before and between attempts - so a cancelled ctx aborts
+// promptly instead of blocking. /api/v1/file/rename has no documented QPS
+// limit tighter than the rest of the API, so it's paced the same way as
+// every other call rather than needing anything special here.
+func (f *Fs) renameFile(ctx context.Context, fileID int64, newName string) error {
+	req := api.RenameRequest{FileID: fileID, Name: newName}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v1/file/rename",
+	}
+	var result api.Response
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	if !result.OK() {
+		return errors.New(result.Message)
+	}
+	return nil
+}
+
+// moveFile moves fileID to destParentID server-side
+func (f *Fs) moveFile(ctx context.Context, fileID, destParentID int64) error {
+	req := api.MoveRequest{FileIDs: []int64{fileID}, ToParentFileID: destParentID}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v1/file/move",
+	}
+	var result api.Response
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	if !result.OK() {
+		return errors.New(result.Message)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMoveTestFs builds a fake server handling rename, move and detail
+// lookups. renameMessage/moveMessage let each test decide what each
+// endpoint responds with - "" means success. detailParentID/detailFilename
+// control what the post-move detail lookup reports the file's actual
+// location and name to be, which may disagree with what was asked for when
+// simulating a benign-looking error that didn't really achieve the
+// requested end state. The root folder is fileID 7. renameCalls counts how
+// many requests hit /api/v1/file/rename, for tests that need to confirm
+// Move skipped it entirely rather than just tolerating its response.
+func newMoveTestFs(t *testing.T, renameMessage, moveMessage string, detailParentID int64, detailFilename string) (f *Fs, renameCalls func() int) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/file/rename":
+			atomic.AddInt32(&calls, 1)
+			if renameMessage != "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": renameMessage})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/api/v1/file/move":
+			if moveMessage != "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": moveMessage})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/api/v1/file/detail":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID":       42,
+					"filename":     detailFilename,
+					"parentFileID": detailParentID,
+					"type":         0,
+					"etag":         "abc123",
+					"size":         1234,
+					"createAt":     "2026-01-01 00:00:00",
+				},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:        newCacheState(),
+		cacheConfig:  DefaultCacheConfig(),
+		srv:          rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:        fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		rootFolderID: 7,
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("subdir", 99)
+	return f, func() int { return int(atomic.LoadInt32(&calls)) }
+}
+
+func newMoveTestSrcObject(f *Fs, remote string) *Object {
+	return &Object{
+		fs:     f,
+		remote: remote,
+		id:     42,
+		size:   1234,
+	}
+}
+
+// TestRenameFileAndGetFileInfoUseSharedPacer confirms /api/v1/file/rename
+// and /api/v1/file/detail both go through f.pacer, the single pacer every
+// call in this backend shares, rather than needing (or falling through to)
+// any endpoint-specific handling.
+func TestRenameFileAndGetFileInfoUseSharedPacer(t *testing.T) {
+	f, renameCalls := newMoveTestFs(t, "", "", 7, "dst.bin")
+
+	err := f.renameFile(context.Background(), 42, "dst.bin")
+	require.NoError(t, err)
+	assert.Equal(t, 1, renameCalls())
+
+	info, err := f.getFileInfo(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, "dst.bin", info.Filename)
+}
+
+func TestMoveSucceeds(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "", 7, "dst.bin")
+	dst, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "dst.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "dst.bin", dst.Remote())
+}
+
+func TestMoveTreatsDuplicateNameInDestAsSuccess(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "当前目录有重名文件", 7, "dst.bin")
+	dst, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "dst.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "dst.bin", dst.Remote())
+}
+
+func TestMoveTreatsAlreadyInFolderAsSuccess(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "文件已在当前文件夹", 7, "dst.bin")
+	dst, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "dst.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "dst.bin", dst.Remote())
+}
+
+func TestMoveReturnsGenuineErrors(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "some other failure", 7, "dst.bin")
+	_, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "dst.bin")
+	require.Error(t, err)
+}
+
+// TestMoveSameDirNoOpNeverRenames confirms a move that keeps both the leaf
+// name and the directory unchanged never calls renameFile at all.
+func TestMoveSameDirNoOpNeverRenames(t *testing.T) {
+	f, renameCalls := newMoveTestFs(t, "", "文件已在当前文件夹", 7, "same.bin")
+	dst, err := f.Move(context.Background(), newMoveTestSrcObject(f, "same.bin"), "same.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "same.bin", dst.Remote())
+	assert.Equal(t, 0, renameCalls())
+}
+
+// TestMoveSameDirRename confirms a rename within the same directory (leaf
+// changes, directory doesn't) renames and reports the move as already
+// achieved once it lands back in the same folder.
+func TestMoveSameDirRename(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "文件已在当前文件夹", 7, "renamed.bin")
+	dst, err := f.Move(context.Background(), newMoveTestSrcObject(f, "old.bin"), "renamed.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "renamed.bin", dst.Remote())
+}
+
+// TestMoveCrossDirNameAlreadyExists confirms a cross-directory move into a
+// directory that already has an entry with the destination name succeeds
+// when that entry really is the moved file (detail confirms it landed in
+// the target directory under the target name).
+func TestMoveCrossDirNameAlreadyExists(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "当前目录有重名文件", 99, "dst.bin")
+	dst, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "subdir/dst.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "subdir/dst.bin", dst.Remote())
+}
+
+// TestMoveCrossDirBlockedByDifferentFile confirms that when the
+// "duplicate name" error actually means a different file is occupying the
+// destination - so the file never really moved, despite the API's error
+// looking like the usual benign one - Move surfaces a real error instead
+// of reporting success.
+func TestMoveCrossDirBlockedByDifferentFile(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "当前目录有重名文件", 7, "src.bin")
+	_, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "subdir/dst.bin")
+	require.Error(t, err)
+}
+
+// TestMoveRenameBlockedByDifferentFile covers the analogous case for the
+// rename step: the "duplicate name" error on rename turns out to mean the
+// source file kept its old name, not that the desired rename was already
+// in effect.
+func TestMoveRenameBlockedByDifferentFile(t *testing.T) {
+	f, _ := newMoveTestFs(t, "当前目录有重名文件", "", 7, "old.bin")
+	_, err := f.Move(context.Background(), newMoveTestSrcObject(f, "old.bin"), "renamed.bin")
+	require.Error(t, err)
+}
+
+// TestMoveOnlyInvalidatesAffectedCacheEntries confirms a Move with a
+// cached source parent only drops the source and destination parents'
+// directory listings, the moved file's own path/parent cache entries -
+// and leaves every unrelated cached directory and path alone.
+func TestMoveOnlyInvalidatesAffectedCacheEntries(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "", 7, "dst.bin")
+	f.saveParentToCache(42, 7)
+	f.savePathToIDToCache("src.bin", 42)
+	f.saveDirListToCache(7, []int64{42})
+	f.saveDirListToCache(55, []int64{100}) // unrelated sibling directory
+
+	_, err := f.Move(context.Background(), newMoveTestSrcObject(f, "src.bin"), "dst.bin")
+	require.NoError(t, err)
+
+	_, ok := f.getPathFromCache("src.bin")
+	assert.False(t, ok, "the moved file's old path entry should be gone")
+	_, ok = f.getParentFromCache(42)
+	assert.False(t, ok, "the moved file's old parent entry should be gone")
+	_, ok = f.getDirListFromCache(7)
+	assert.False(t, ok, "the source parent's listing should be gone")
+
+	_, ok = f.getPathFromCache("subdir")
+	assert.True(t, ok, "an unrelated cached path must survive the move")
+	_, ok = f.getDirListFromCache(55)
+	assert.True(t, ok, "an unrelated sibling directory's listing must survive the move")
+}
+
+func TestIsBenignMoveError(t *testing.T) {
+	assert.False(t, isBenignMoveError(nil))
+	assert.True(t, isBenignMoveError(errors.New("当前目录有重名文件")))
+	assert.True(t, isBenignMoveError(errors.New("文件已在当前文件夹")))
+	assert.False(t, isBenignMoveError(errors.New("some other failure")))
+}
@@ -0,0 +1,64 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNoCrossCloudDownloadTestFs(t *testing.T) *Fs {
+	f := &Fs{
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 1024*1024) },
+		},
+		memoryManager: newMemoryManager(0),
+	}
+	f.opt.ProgressDir = t.TempDir()
+	f.opt.NoCrossCloudDownload = true
+	return f
+}
+
+// TestUploadRefusesRemoteSourceWhenNoCrossCloudDownloadSet confirms upload
+// rejects a non-local source before ever touching prepareUploadSource (and
+// so before any network call), when no_cross_cloud_download is set.
+func TestUploadRefusesRemoteSourceWhenNoCrossCloudDownloadSet(t *testing.T) {
+	f := newNoCrossCloudDownloadTestFs(t)
+	o := &Object{fs: f, remote: "dst.bin"}
+	src := newStubObjectInfo(stubFs{name: "s3", isLocal: false})
+
+	err := o.upload(context.Background(), bytes.NewReader([]byte("content")), src, 0, "dst.bin")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no_cross_cloud_download")
+}
+
+// TestUploadAllowsLocalSourceWhenNoCrossCloudDownloadSet confirms a local
+// source isn't affected by no_cross_cloud_download: it gets past the
+// check and fails later for an unrelated reason (the stub source reports
+// 1234 bytes but the reader holds 7, so prepareUploadSource rejects it),
+// not the cross-cloud refusal.
+func TestUploadAllowsLocalSourceWhenNoCrossCloudDownloadSet(t *testing.T) {
+	f := newNoCrossCloudDownloadTestFs(t)
+	o := &Object{fs: f, remote: "dst.bin"}
+	src := newStubObjectInfo(stubFs{name: "local", isLocal: true})
+
+	err := o.upload(context.Background(), bytes.NewReader([]byte("content")), src, 0, "dst.bin")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "no_cross_cloud_download")
+}
+
+// TestUploadAllowsRemoteSourceByDefault confirms the option is off by
+// default, so a remote source isn't rejected unless explicitly configured.
+func TestUploadAllowsRemoteSourceByDefault(t *testing.T) {
+	f := newNoCrossCloudDownloadTestFs(t)
+	f.opt.NoCrossCloudDownload = false
+	o := &Object{fs: f, remote: "dst.bin"}
+	src := newStubObjectInfo(stubFs{name: "s3", isLocal: false})
+
+	err := o.upload(context.Background(), bytes.NewReader([]byte("content")), src, 0, "dst.bin")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "no_cross_cloud_download")
+}
@@ -0,0 +1,77 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNoPartialUploadsDirectlyToFinalName confirms that, whether or not
+// --123-no-partial is set, the create-session call is made with the
+// final destination filename and no separate rename call ever happens -
+// this backend has no ".partial" staging name to disable in the first
+// place.
+func TestNoPartialUploadsDirectlyToFinalName(t *testing.T) {
+	for _, noPartial := range []bool{false, true} {
+		var createdFilename string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/upload/v1/file/create":
+				var req api.UploadCreateRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				createdFilename = req.Filename
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"code": 0,
+					"data": map[string]interface{}{"preuploadID": "pre-1", "reuse": false, "sliceSize": 1024},
+				})
+			case "/upload/v1/file/slice":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+			case "/upload/v1/file/upload_complete":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"code": 0,
+					"data": map[string]interface{}{"completed": true, "fileID": 42},
+				})
+			default:
+				t.Fatalf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		f := &Fs{
+			// IgnoreEtagMismatch: this fixture doesn't stub /api/v1/file/detail,
+			// which is unrelated to what this test is checking.
+			opt:           Options{NoPartial: noPartial, IgnoreEtagMismatch: true},
+			cache:         newCacheState(),
+			cacheConfig:   DefaultCacheConfig(),
+			memoryManager: newMemoryManager(0),
+			bufPool: &sync.Pool{
+				New: func() interface{} { return make([]byte, 64*1024) },
+			},
+			srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+			pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		}
+		f.srv.SetRoot(server.URL)
+
+		o := &Object{fs: f, remote: "final-name.bin"}
+		content := []byte("direct to final name")
+		src := mockobject.New("final-name.bin").WithContent(content, mockobject.SeekModeNone)
+		err := o.upload(context.Background(), bytes.NewReader(content), src, 7, "final-name.bin")
+		require.NoError(t, err)
+		assert.Equal(t, "final-name.bin", createdFilename)
+	}
+}
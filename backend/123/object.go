@@ -0,0 +1,662 @@
+package _123
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	o.mu.Lock()
+	id := o.id
+	status := o.status
+	o.mu.Unlock()
+
+	if status >= reviewRejectedStatus {
+		return nil, errors.Errorf("%s: rejected by content review (status %d), file is not downloadable", o.remote, status)
+	}
+
+	var hasher *hash.MultiHasher
+	for _, option := range options {
+		if x, ok := option.(*fs.HashesOption); ok {
+			// We can only verify MD5 - any other requested hash types are
+			// silently skipped rather than failing the whole Open.
+			supported := x.Hashes.Overlap(hash.NewHashSet(hash.MD5))
+			if supported.Count() > 0 {
+				var err error
+				hasher, err = hash.NewMultiHasherTypes(supported)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	downloadURL, err := o.fs.getDownloadURL(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch download URL")
+	}
+
+	downloadOpts := rest.Opts{
+		Method:  "GET",
+		RootURL: downloadURL,
+		Options: options,
+	}
+	transferStart := time.Now()
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		var err error
+		resp, err = o.fs.srv.Call(ctx, &downloadOpts)
+		return o.fs.shouldRetry(ctx, resp, err)
+	})
+	if isDownloadURLExpiredResponse(resp) {
+		o.fs.evictDownloadURLFromCache(id)
+		downloadURL, err = o.fs.getDownloadURL(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to refresh expired download URL")
+		}
+		downloadOpts.RootURL = downloadURL
+		err = o.fs.pacer.Call(func() (bool, error) {
+			var err error
+			resp, err = o.fs.srv.Call(ctx, &downloadOpts)
+			return o.fs.shouldRetry(ctx, resp, err)
+		})
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file for download")
+	}
+	// Measured from the raw transfer request to receiving its response,
+	// excluding the preceding download_info round trip, so it isolates
+	// transfer latency from URL-resolution latency - see ttfbStats.
+	o.fs.recordTTFB(time.Since(transferStart))
+	if hasher == nil {
+		return resp.Body, nil
+	}
+	return &hashVerifyReadCloser{o: o, in: resp.Body, hasher: hasher}, nil
+}
+
+// isDownloadURLExpiredResponse reports whether resp looks like the
+// download host rejecting an expired or otherwise invalid signed URL,
+// rather than an ordinary transfer failure. There's no structured
+// "url_expired" error code to key off here - 123pan's download hosts
+// reject a bad URL with a plain 403 or 410 - so those statuses are taken
+// as the signal to evict the cached URL and retry once with a fresh one.
+func isDownloadURLExpiredResponse(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusGone
+}
+
+// downloadURLDefaultTTL is used when download_info doesn't report an
+// expiry (not every deployment of the API returns one), so a cached URL
+// is still treated as stale after a conservative fixed window rather
+// than either never expiring or not being cached at all.
+const downloadURLDefaultTTL = 5 * time.Minute
+
+// getDownloadURL returns a download URL for fileID, reusing a cached one
+// while it remains outside downloadURLSafetyWindow of its expiry, and
+// otherwise fetching a fresh one from download_info.
+func (f *Fs) getDownloadURL(ctx context.Context, fileID int64) (string, error) {
+	if url, ok := f.getDownloadURLFromCache(fileID); ok {
+		return url, nil
+	}
+
+	var result api.DownloadInfoResponse
+	opts := rest.Opts{
+		Method:     "GET",
+		Path:       "/api/v1/file/download_info",
+		Parameters: map[string][]string{"fileID": {strconv.FormatInt(fileID, 10)}},
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return "", err
+	}
+	if !result.OK() {
+		return "", errors.Errorf("%s", result.Message)
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, result.Data.ExpireAt)
+	if err != nil {
+		expiresAt = time.Now().Add(downloadURLDefaultTTL)
+	}
+	f.saveDownloadURLToCache(fileID, result.Data.DownloadURL, expiresAt)
+	return result.Data.DownloadURL, nil
+}
+
+// hashVerifyReadCloser wraps a download body, accumulating a hash as the
+// data is read and validating it against the object's known MD5 once the
+// caller closes it having read the whole stream.
+type hashVerifyReadCloser struct {
+	o      *Object
+	in     io.ReadCloser
+	hasher *hash.MultiHasher
+}
+
+// Read implements io.Reader
+func (h *hashVerifyReadCloser) Read(p []byte) (n int, err error) {
+	n, err = h.in.Read(p)
+	if n > 0 {
+		// Hash routines never return an error
+		_, _ = h.hasher.Write(p[:n])
+	}
+	return n, err
+}
+
+// Close implements io.Closer, validating the accumulated hash against the
+// object's known MD5 if the whole stream was read.
+func (h *hashVerifyReadCloser) Close() error {
+	err := h.in.Close()
+	if err != nil {
+		return err
+	}
+	sums := h.hasher.Sums()
+	got, ok := sums[hash.MD5]
+	if !ok || got == "" {
+		return nil
+	}
+	h.o.mu.Lock()
+	want := h.o.md5sum
+	h.o.mu.Unlock()
+	if want != "" && h.hasher.Size() == h.o.Size() && !strings.EqualFold(got, want) {
+		return errors.Errorf("corrupted on transfer: MD5 hashes differ src(computed) %q vs dst(expected) %q", got, want)
+	}
+	return nil
+}
+
+// Update the object with new content
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	o.mu.Lock()
+	id := o.id
+	o.mu.Unlock()
+
+	// 123 Pan has no in-place content update API: the simplest correct
+	// approach is to remove the stale object and upload a replacement in
+	// its place, picking up the new fileID.
+	if id != 0 {
+		if err := o.fs.deleteFiles(ctx, []int64{id}); err != nil {
+			return err
+		}
+	}
+
+	remote := src.Remote()
+	fullPath := o.fs.root + "/" + remote
+	dir, leaf := splitDirLeaf(fullPath)
+	parentID, err := o.fs.pathToFileID(ctx, dir, true)
+	if err != nil {
+		return err
+	}
+	return o.upload(ctx, in, src, parentID, o.fs.opt.Enc.FromStandardName(leaf))
+}
+
+// splitDirLeaf splits fullPath into its containing directory and leaf name
+func splitDirLeaf(fullPath string) (dir, leaf string) {
+	i := len(fullPath) - 1
+	for i >= 0 && fullPath[i] != '/' {
+		i--
+	}
+	return fullPath[:i+1], fullPath[i+1:]
+}
+
+// upload uploads the content of in as leaf under parentID, populating o on
+// success. It supports the instant-upload ("秒传") fast path when the
+// server already holds this content.
+func (o *Object) upload(ctx context.Context, in io.Reader, src fs.ObjectInfo, parentID int64, leaf string) error {
+	f := o.fs
+	size := src.Size()
+
+	// leaf is already server-encoded, which can only have grown it (e.g.
+	// quoting reserved characters), so the byte budget is enforced here
+	// rather than before encoding.
+	leaf = cleanFileName(leaf)
+
+	if f.opt.NoCrossCloudDownload && isRemoteSource(src) {
+		return errors.Errorf("123pan: refusing to buffer %q from a non-local source through a temp file - unset no_cross_cloud_download to allow it", src.Remote())
+	}
+
+	// size may be negative here (e.g. "rclone rcat", where the source's
+	// length isn't known up front); prepareUploadSource resolves it to
+	// the actual byte count once the content has been read, which is
+	// what every downstream call below needs - 123pan's create API
+	// requires a real size, not -1.
+	source, size, etag, sha1sum, cleanup, err := f.prepareUploadSource(ctx, in, size, src)
+	if err != nil {
+		return errors.Wrap(err, "failed to buffer upload content")
+	}
+	defer cleanup()
+
+	if f.opt.SkipIfExists {
+		existing, ok, err := f.findExistingByNameAndEtag(ctx, parentID, leaf, etag)
+		if err != nil {
+			return err
+		}
+		if ok {
+			o.mu.Lock()
+			o.id = existing.FileID
+			o.size = existing.Size
+			o.md5sum = existing.Etag
+			o.sha1sum = sha1sum
+			o.modTime = src.ModTime(ctx)
+			o.hasMetaData = true
+			o.mu.Unlock()
+			return nil
+		}
+	}
+
+	// An overwrite duplicate_policy means there's no collision to avoid -
+	// the upload is meant to replace whatever's already there - so the
+	// suffixing logic is skipped even if avoid_name_collisions is set.
+	if f.opt.AvoidNameCollisions && f.opt.DuplicatePolicy != "overwrite" {
+		leaf, err = f.resolveUniqueFilename(ctx, parentID, leaf)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The MD5 was already computed while preparing source, so no second
+	// read of the data is needed here.
+	fileID, reused, err := f.uploadContent(ctx, parentID, leaf, size, etag, source, o.Remote())
+	if err != nil {
+		return err
+	}
+	if !reused {
+		if f.opt.UploadVerifySize {
+			if err := f.verifyUploadedSize(ctx, fileID, size); err != nil {
+				return err
+			}
+		}
+		if err := f.verifyUploadedEtag(ctx, fileID, etag); err != nil {
+			return err
+		}
+	}
+
+	if f.opt.FailOnReviewReject {
+		if err := f.checkReviewStatus(ctx, fileID); err != nil {
+			return err
+		}
+	}
+
+	o.mu.Lock()
+	o.id = fileID
+	o.size = size
+	o.md5sum = etag
+	o.sha1sum = sha1sum
+	o.modTime = src.ModTime(ctx)
+	o.hasMetaData = true
+	o.mu.Unlock()
+	// Only parentID's listing needs to reflect the new/updated entry;
+	// every other cached path and directory in the tree is still valid.
+	f.clearDirCacheFor(parentID)
+	return nil
+}
+
+// sliceBounds is the offset and length of a single upload slice
+type sliceBounds struct {
+	index  int
+	offset int64
+	length int64
+}
+
+// planSlices divides size into sliceSize-sized sections.
+//
+// There is no separate "single-step" upload path in this backend: small
+// files aren't routed through a different API call, they simply end up
+// as the single slice this produces whenever size <= sliceSize.
+// sliceSize itself is never chosen locally - it's session.Data.SliceSize,
+// whatever createUploadSession's response said for this file's size (see
+// uploadSlices) - so there is no local size-threshold constant to get
+// out of sync with the server's real limits.
+func planSlices(size, sliceSize int64) []sliceBounds {
+	var slices []sliceBounds
+	index := 0
+	for offset := int64(0); offset < size; offset += sliceSize {
+		n := sliceSize
+		if offset+n > size {
+			n = size - offset
+		}
+		slices = append(slices, sliceBounds{index: index, offset: offset, length: n})
+		index++
+	}
+	return slices
+}
+
+// uploadContent creates (or resumes) an upload session for etag/size
+// under parentID, uploads whatever slices aren't already confirmed, and
+// completes it, returning the new fileID and whether it was an instant
+// ("秒传") upload that never touched uploadSlices at all.
+//
+// If a resumeRecord exists for this exact content (etag, size and
+// parentFileID all matching - see resumableUploadSession), its
+// preuploadID is reused so slices already confirmed uploaded don't have
+// to be sent again. If the server has since expired that preuploadID,
+// the resume attempt fails fast and a fresh session is created to start
+// the upload over, rather than getting stuck retrying a dead session.
+func (f *Fs) uploadContent(ctx context.Context, parentID int64, leaf string, size int64, etag string, source io.ReaderAt, remote string) (fileID int64, reused bool, err error) {
+	session, resumeFromIndex := f.resumableUploadSession(etag, size, parentID)
+	if session == nil {
+		session, err = f.createUploadSession(ctx, parentID, leaf, size, etag)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	if session.Data.Reuse {
+		return session.Data.FileID, true, nil
+	}
+	if err := f.uploadSlices(ctx, session, source, size, remote, etag, parentID, resumeFromIndex); err != nil {
+		if resumeFromIndex == 0 {
+			return 0, false, err
+		}
+		fs.Debugf(f, "resuming %q against its previous upload session failed, starting a fresh session: %v", remote, err)
+		f.removeResumeRecord(etag)
+		session, err = f.createUploadSession(ctx, parentID, leaf, size, etag)
+		if err != nil {
+			return 0, false, err
+		}
+		if session.Data.Reuse {
+			return session.Data.FileID, true, nil
+		}
+		if err := f.uploadSlices(ctx, session, source, size, remote, etag, parentID, 0); err != nil {
+			return 0, false, err
+		}
+	}
+	fileID, err = f.completeUpload(ctx, session.Data.PreuploadID, size)
+	if err != nil {
+		return 0, false, err
+	}
+	if resumeFromIndex > 0 {
+		if err := f.removeResumeRecord(etag); err != nil {
+			fs.Debugf(f, "failed to remove resume record for completed upload of %q: %v", remote, err)
+		}
+	}
+	return fileID, false, nil
+}
+
+// uploadSlices uploads the whole of r, split into the session's slice
+// size, using a pool of --123-upload-concurrency workers. Slices can
+// therefore complete out of order, so once the pool drains, the set of
+// succeeded indices is checked against the full set planned: any missing
+// index (a worker error that was swallowed, or one that never got picked
+// up) is re-uploaded directly rather than retrying the whole transfer.
+//
+// resumeFromIndex skips the first resumeFromIndex slices entirely,
+// treating them as already confirmed uploaded against this session (see
+// resumableUploadSession) - pass 0 for a session with nothing confirmed
+// yet.
+//
+// If ctx is cancelled mid-upload, whatever slices had already succeeded
+// (on top of whatever resumeFromIndex already accounted for) are
+// persisted as a resume record (keyed by etag) exactly once, so a later
+// retry of the same content can in principle pick up from there, before
+// the cancellation error is returned.
+func (f *Fs) uploadSlices(ctx context.Context, session *api.UploadCreateResponse, r io.ReaderAt, size int64, remote, etag string, parentID int64, resumeFromIndex int) error {
+	sliceSize := session.Data.SliceSize
+	if sliceSize <= 0 {
+		sliceSize = int64(f.opt.ChunkSize)
+	}
+	slices := planSlices(size, sliceSize)
+	baseUploadedTo := int64(0)
+	if resumeFromIndex > 0 && resumeFromIndex <= len(slices) {
+		baseUploadedTo = int64(resumeFromIndex) * sliceSize
+		slices = slices[resumeFromIndex:]
+	}
+	domains := f.preferSelectedUploadDomain(f.uploadDomainCandidates(session.Data.Servers))
+	concurrency := f.effectiveUploadConcurrency()
+	res, err := f.uploadSlicesOnce(ctx, domains, session.Data.PreuploadID, r, slices, concurrency)
+	if err != nil {
+		f.savePartialUploadProgress(session, remote, size, etag, parentID, baseUploadedTo, res.succeeded, sliceSize)
+		return err
+	}
+	if len(res.missing) > 0 {
+		fs.Logf(f, "%d slice(s) missing after upload, retrying: %v", len(res.missing), res.missing)
+		retry := make([]sliceBounds, 0, len(res.missing))
+		for _, s := range slices {
+			if missingSet(res.missing)[s.index] {
+				retry = append(retry, s)
+			}
+		}
+		// The slice size itself is fixed for the life of this session
+		// (set once above from session.Data.SliceSize), but the
+		// throughput measured uploading the first batch is real
+		// information about how much the link can actually sustain -
+		// use it to retune concurrency for this not-yet-sent batch.
+		retryConcurrency := concurrency
+		if res.duration > 0 {
+			bytesPerSec := float64(res.bytesUploaded) / res.duration.Seconds()
+			retryConcurrency = adjustUploadConcurrency(concurrency, bytesPerSec, 1, maxUploadConcurrencyLimit)
+			if retryConcurrency != concurrency {
+				fs.Debugf(f, "%s: retuning upload concurrency from %d to %d based on measured throughput", remote, concurrency, retryConcurrency)
+			}
+		}
+		retryRes, err := f.uploadSlicesOnce(ctx, domains, session.Data.PreuploadID, r, retry, retryConcurrency)
+		if err != nil {
+			f.savePartialUploadProgress(session, remote, size, etag, parentID, baseUploadedTo, append(res.succeeded, retryRes.succeeded...), sliceSize)
+			return err
+		}
+		if len(retryRes.missing) > 0 {
+			return errors.Errorf("failed to upload slice(s) %v after retry", retryRes.missing)
+		}
+	}
+	return nil
+}
+
+// savePartialUploadProgress persists a resumeRecord recording how many
+// bytes of etag's upload had already succeeded when it was interrupted,
+// counting both baseUploadedTo (already confirmed before this attempt,
+// e.g. by a previous resume) and whatever newly succeeded this time.
+// Failing to save it is logged but not fatal: the caller is already on
+// its way to returning the original cancellation error.
+func (f *Fs) savePartialUploadProgress(session *api.UploadCreateResponse, remote string, size int64, etag string, parentID, baseUploadedTo int64, succeeded []int, sliceSize int64) {
+	if len(succeeded) == 0 && baseUploadedTo == 0 {
+		return
+	}
+	rec := &resumeRecord{
+		PreuploadID:  session.Data.PreuploadID,
+		Remote:       remote,
+		Size:         size,
+		Etag:         etag,
+		ParentFileID: parentID,
+		SliceSize:    sliceSize,
+		UploadedTo:   baseUploadedTo + int64(len(succeeded))*sliceSize,
+		Servers:      session.Data.Servers,
+		CreatedAt:    time.Now(),
+	}
+	if err := f.saveResumeRecord(rec); err != nil {
+		fs.Debugf(f, "failed to save resume record for interrupted upload of %q: %v", remote, err)
+	}
+}
+
+// missingSet turns a slice of indices into a lookup set
+func missingSet(indices []int) map[int]bool {
+	set := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		set[i] = true
+	}
+	return set
+}
+
+// sliceUploadResult is the outcome of one pass of uploadSlicesOnce: the
+// indices that succeeded and the indices that didn't (empty if all did),
+// plus how many bytes were uploaded and how long it took - the raw
+// numbers adjustUploadConcurrency needs to retune the worker count for
+// the next batch, if any (see uploadSlices).
+type sliceUploadResult struct {
+	succeeded     []int
+	missing       []int
+	bytesUploaded int64
+	duration      time.Duration
+}
+
+// uploadSlicesOnce runs slices through a worker pool of concurrency
+// size (normally f.effectiveUploadConcurrency(), but uploadSlices passes
+// a value retuned by adjustUploadConcurrency for a retry batch). If ctx
+// is cancelled, both the dispatch goroutine and every worker stop
+// picking up new work promptly rather than draining the plan; whatever
+// had already succeeded by then is still reported back so progress
+// isn't lost.
+func (f *Fs) uploadSlicesOnce(ctx context.Context, domains []string, preuploadID string, r io.ReaderAt, slices []sliceBounds, concurrency int) (sliceUploadResult, error) {
+	if concurrency > len(slices) {
+		concurrency = len(slices)
+	}
+	if concurrency == 0 {
+		return sliceUploadResult{}, nil
+	}
+
+	work := make(chan sliceBounds)
+	type result struct {
+		index int
+		size  int64
+		err   error
+	}
+	results := make(chan result, len(slices))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case s, ok := <-work:
+					if !ok {
+						return
+					}
+					section := io.NewSectionReader(r, s.offset, s.length)
+					err := f.uploadSlice(ctx, domains, preuploadID, s.index, section)
+					select {
+					case results <- result{index: s.index, size: s.length, err: err}:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(work)
+		for _, s := range slices {
+			select {
+			case work <- s:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	succeeded := make(map[int]bool, len(slices))
+	var bytesUploaded int64
+	var cancelled bool
+	for res := range results {
+		if res.err != nil {
+			if ctx.Err() != nil {
+				cancelled = true
+				continue
+			}
+			fs.Debugf(f, "slice %d failed, will retry: %v", res.index, res.err)
+			continue
+		}
+		succeeded[res.index] = true
+		bytesUploaded += res.size
+	}
+	duration := time.Since(start)
+
+	succeededIndices := make([]int, 0, len(succeeded))
+	for _, s := range slices {
+		if succeeded[s.index] {
+			succeededIndices = append(succeededIndices, s.index)
+		}
+	}
+	if cancelled || ctx.Err() != nil {
+		return sliceUploadResult{succeeded: succeededIndices, bytesUploaded: bytesUploaded, duration: duration}, ctx.Err()
+	}
+
+	var missing []int
+	for _, s := range slices {
+		if !succeeded[s.index] {
+			missing = append(missing, s.index)
+		}
+	}
+	return sliceUploadResult{succeeded: succeededIndices, missing: missing, bytesUploaded: bytesUploaded, duration: duration}, nil
+}
+
+// uploadSlice uploads a single numbered slice of a multipart upload. The
+// call is bounded by --123-slice-upload-timeout, applied per-slice and
+// separately from the global --timeout, so a slice stuck on a flaky
+// connection is aborted and retried quickly rather than blocking the whole
+// upload until the global timeout fires.
+//
+// domains is the ordered list of upload domains to try (see
+// uploadDomainCandidates): if a domain fails outright (a transport-level
+// error, after the pacer's own retries are exhausted) the next one is
+// tried against the same slice rather than failing the upload, so a
+// region-blocked domain doesn't take the whole transfer down with it.
+func (f *Fs) uploadSlice(ctx context.Context, domains []string, preuploadID string, sliceNum int, r *io.SectionReader) error {
+	if len(domains) == 0 {
+		domains = []string{""} // fall back to f.srv's default root
+	}
+	var lastErr error
+	for i, domain := range domains {
+		if i > 0 {
+			if _, err := r.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		opts := rest.Opts{
+			Method:               "POST",
+			RootURL:              domain,
+			Path:                 "/upload/v1/file/slice",
+			Body:                 r,
+			MultipartContentName: "slice",
+			MultipartFileName:    "slice",
+			MultipartParams: map[string][]string{
+				"preuploadID": {preuploadID},
+				"sliceNo":     {fmt.Sprintf("%d", sliceNum+1)},
+			},
+		}
+		var result api.UploadSliceResponse
+		lastErr = f.pacer.Call(func() (bool, error) {
+			sliceCtx := ctx
+			if timeout := time.Duration(f.opt.SliceUploadTimeout); timeout > 0 {
+				var cancel context.CancelFunc
+				sliceCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			resp, err := f.srv.CallJSON(sliceCtx, &opts, nil, &result)
+			return f.shouldRetry(sliceCtx, resp, err)
+		})
+		if lastErr == nil {
+			f.setSelectedUploadDomain(domain)
+			return nil
+		}
+		f.invalidateSelectedUploadDomain(domain)
+		if ctx.Err() != nil {
+			return lastErr
+		}
+		if i < len(domains)-1 {
+			fs.Debugf(f, "upload domain %q failed for slice %d, trying next: %v", domain, sliceNum, lastErr)
+		}
+	}
+	return lastErr
+}
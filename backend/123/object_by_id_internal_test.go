@@ -0,0 +1,111 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// objectByIDTestTree maps fileID -> (filename, parentFileID, type, size,
+// etag) for a tiny fake directory structure: root(0) -> a(1) -> f.txt(2).
+var objectByIDTestTree = map[int64][5]interface{}{
+	1: {"a", int64(0), 1, int64(0), ""},
+	2: {"f.txt", int64(1), 0, int64(42), "deadbeef"},
+}
+
+// newObjectByIDTestFs serves only /api/v1/file/detail, so if
+// objectByID ever fell back to a directory listing to resolve the
+// fileID the test would fail the moment that listing endpoint was hit.
+func newObjectByIDTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/file/detail" {
+			t.Errorf("unexpected request to %s; objectByID must not list directories", r.URL.Path)
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		fileID, _ := strconv.ParseInt(r.URL.Query().Get("fileID"), 10, 64)
+		entry, ok := objectByIDTestTree[fileID]
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "not found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"fileID":       fileID,
+				"filename":     entry[0],
+				"parentFileID": entry[1],
+				"type":         entry[2],
+				"size":         entry[3],
+				"etag":         entry[4],
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestObjectByIDResolvesWithoutListing(t *testing.T) {
+	f := newObjectByIDTestFs(t)
+
+	o, err := f.objectByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "a/f.txt", o.Remote())
+	assert.EqualValues(t, 42, o.Size())
+}
+
+func TestObjectByIDRejectsDirectory(t *testing.T) {
+	f := newObjectByIDTestFs(t)
+
+	_, err := f.objectByID(context.Background(), 1)
+	assert.Equal(t, fs.ErrorNotAFile, err)
+}
+
+func TestObjectByIDUnreachable(t *testing.T) {
+	f := newObjectByIDTestFs(t)
+
+	_, err := f.objectByID(context.Background(), 999)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
+
+func TestCommandObjectByID(t *testing.T) {
+	f := newObjectByIDTestFs(t)
+
+	res, err := f.Command(context.Background(), "object-by-id", []string{"2"}, nil)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 2, m["fileID"])
+	assert.Equal(t, "a/f.txt", m["path"])
+	assert.EqualValues(t, 42, m["size"])
+}
+
+func TestCommandObjectByIDNeedsExactlyOneArgument(t *testing.T) {
+	f := newObjectByIDTestFs(t)
+
+	_, err := f.Command(context.Background(), "object-by-id", nil, nil)
+	assert.Error(t, err)
+}
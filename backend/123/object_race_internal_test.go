@@ -0,0 +1,54 @@
+package _123
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// TestObjectConcurrentAccessDuringUpdate exercises Object's accessors
+// concurrently with the same field writes Update/upload perform, to catch
+// unguarded reads/writes under -race. It doesn't hit the network: it calls
+// the same locked field-group assignment upload uses directly.
+func TestObjectConcurrentAccessDuringUpdate(t *testing.T) {
+	o := &Object{fs: &Fs{}, remote: "race.txt"}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			o.mu.Lock()
+			o.id = int64(i)
+			o.size = int64(i)
+			o.md5sum = "deadbeef"
+			o.modTime = time.Now()
+			o.hasMetaData = true
+			o.mu.Unlock()
+		}
+	}()
+
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		_ = o.Size()
+		_ = o.ID()
+		_ = o.ModTime(ctx)
+		hashVal, err := o.Hash(ctx, hash.MD5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = hashVal
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,111 @@
+package _123
+
+import (
+	"context"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// offlineTaskStatusNames maps OfflineTaskProcessResponse.Data.Status to a
+// human-readable label, since the API reports it as a bare int.
+var offlineTaskStatusNames = map[int]string{
+	0: "downloading",
+	1: "done",
+	2: "failed",
+}
+
+// offlineTaskStatusName returns status's human-readable label, or the raw
+// int rendered as a string if it's not one of the known values.
+func offlineTaskStatusName(status int) string {
+	if name, ok := offlineTaskStatusNames[status]; ok {
+		return name
+	}
+	return strconv.Itoa(status)
+}
+
+// createOfflineDownload starts an offline download of url (an HTTP(S)
+// link or magnet URI) into dirID, optionally under fileName, returning
+// the new task's ID. This is a low-frequency endpoint, so it goes through
+// the same shared pacer as every other call rather than anything special.
+func (f *Fs) createOfflineDownload(ctx context.Context, url, fileName string, dirID int64) (taskID int64, err error) {
+	req := api.OfflineDownloadRequest{
+		URL:      url,
+		FileName: fileName,
+		DirID:    dirID,
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v1/offline/download",
+	}
+	var result api.OfflineDownloadResponse
+	callErr := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if callErr != nil {
+		return 0, errors.Wrap(callErr, "failed to create offline download")
+	}
+	if !result.OK() {
+		return 0, errors.Errorf("failed to create offline download: %s", result.Message)
+	}
+	return result.Data.TaskID, nil
+}
+
+// offlineDownloadProcess polls a single offline-download task's progress.
+func (f *Fs) offlineDownloadProcess(ctx context.Context, taskID int64) (status, process int, fileID int64, err error) {
+	opts := rest.Opts{
+		Method:     "GET",
+		Path:       "/api/v1/offline/download/process",
+		Parameters: map[string][]string{"taskID": {strconv.FormatInt(taskID, 10)}},
+	}
+	var result api.OfflineTaskProcessResponse
+	callErr := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if callErr != nil {
+		return 0, 0, 0, errors.Wrapf(callErr, "failed to poll offline download task %d", taskID)
+	}
+	if !result.OK() {
+		return 0, 0, 0, errors.Errorf("failed to poll offline download task %d: %s", taskID, result.Message)
+	}
+	return result.Data.Status, result.Data.Process, result.Data.FileID, nil
+}
+
+// commandOfflineAdd implements the "offline-add" backend command: resolve
+// destDir to a fileID (creating it if necessary) and start an offline
+// download of url into it.
+func (f *Fs) commandOfflineAdd(ctx context.Context, url, fileName, destDir string) (interface{}, error) {
+	dirID, err := f.pathToFileID(ctx, path.Join(f.root, destDir), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "offline-add: failed to resolve destination directory")
+	}
+	taskID, err := f.createOfflineDownload(ctx, url, fileName, dirID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"taskID": taskID}, nil
+}
+
+// commandOfflineList implements the "offline-list" backend command: poll
+// the status of one or more offline-download tasks by ID.
+func (f *Fs) commandOfflineList(ctx context.Context, taskIDs []int64) (interface{}, error) {
+	tasks := make([]map[string]interface{}, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		status, process, fileID, err := f.offlineDownloadProcess(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, map[string]interface{}{
+			"taskID":  taskID,
+			"status":  offlineTaskStatusName(status),
+			"process": process,
+			"fileID":  fileID,
+		})
+	}
+	return map[string]interface{}{"tasks": tasks}, nil
+}
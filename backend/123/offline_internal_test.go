@@ -0,0 +1,119 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOfflineTestFs returns an Fs backed by a fake server that resolves an
+// empty root directory, accepts offline-download creation, and reports a
+// fixed status for a known taskID.
+func newOfflineTestFs(t *testing.T) (f *Fs, lastCreate *api.OfflineDownloadRequest) {
+	lastCreate = new(api.OfflineDownloadRequest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": []interface{}{}},
+			})
+		case "/upload/v1/file/mkdir":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"dirID": 55},
+			})
+		case "/api/v1/offline/download":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(lastCreate))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"taskID": 7},
+			})
+		case "/api/v1/offline/download/process":
+			taskID := r.URL.Query().Get("taskID")
+			switch taskID {
+			case "7":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"code": 0,
+					"data": map[string]interface{}{"status": 1, "process": 100, "fileID": 99},
+				})
+			case "8":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"code": 0,
+					"data": map[string]interface{}{"status": 0, "process": 42, "fileID": 0},
+				})
+			default:
+				t.Fatalf("unexpected taskID %q", taskID)
+			}
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, lastCreate
+}
+
+func TestCommandOfflineAddCreatesTaskInResolvedDir(t *testing.T) {
+	f, lastCreate := newOfflineTestFs(t)
+
+	result, err := f.commandOfflineAdd(context.Background(), "magnet:?xt=urn:btih:abc", "movie.mkv", "downloads")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"taskID": int64(7)}, result)
+	assert.Equal(t, "magnet:?xt=urn:btih:abc", lastCreate.URL)
+	assert.Equal(t, "movie.mkv", lastCreate.FileName)
+	assert.EqualValues(t, 55, lastCreate.DirID)
+}
+
+func TestCommandOfflineListReportsStatusForEachTask(t *testing.T) {
+	f, _ := newOfflineTestFs(t)
+
+	result, err := f.commandOfflineList(context.Background(), []int64{7, 8})
+	require.NoError(t, err)
+
+	out, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	tasks := out["tasks"].([]map[string]interface{})
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "done", tasks[0]["status"])
+	assert.EqualValues(t, 99, tasks[0]["fileID"])
+	assert.Equal(t, "downloading", tasks[1]["status"])
+	assert.EqualValues(t, 42, tasks[1]["process"])
+}
+
+func TestCommandDispatchesOfflineSubcommands(t *testing.T) {
+	f, lastCreate := newOfflineTestFs(t)
+
+	_, err := f.Command(context.Background(), "offline-add", []string{"http://example.com/file.iso", "downloads"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/file.iso", lastCreate.URL)
+
+	_, err = f.Command(context.Background(), "offline-list", []string{"7"}, nil)
+	require.NoError(t, err)
+
+	_, err = f.Command(context.Background(), "offline-add", []string{"only-one-arg"}, nil)
+	assert.Error(t, err)
+
+	_, err = f.Command(context.Background(), "offline-list", nil, nil)
+	assert.Error(t, err)
+}
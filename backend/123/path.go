@@ -0,0 +1,245 @@
+package _123
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// pathToFileID resolves a full (root-relative) slash-separated path to its
+// fileID, walking one directory component at a time and consulting the
+// path/dir caches before hitting the API. If create is true, missing
+// directory components are created along the way.
+//
+// Every intermediate prefix is checked against the path cache, not just
+// the full path, and each component resolved via findChild is stored
+// back into it - so a warm walk only has to list the components that
+// weren't already cached, rather than re-listing the whole chain. A
+// cached ID that no longer exists server-side still gets caught
+// downstream: findChild's listing of the next level will simply not find
+// it, which surfaces as the normal ErrorObjectNotFound/create path.
+func (f *Fs) pathToFileID(ctx context.Context, fullPath string, create bool) (int64, error) {
+	fullPath = strings.Trim(fullPath, "/")
+	if fullPath == "" {
+		return f.rootFolderID, nil
+	}
+	cacheKey := strings.ToLower(fullPath)
+	if id, ok := f.getPathFromCache(cacheKey); ok {
+		return id, nil
+	}
+	if !create && f.getNegativePathFromCache(cacheKey) {
+		return 0, fs.ErrorObjectNotFound
+	}
+
+	parts := strings.Split(fullPath, "/")
+	parentID := f.rootFolderID
+	var walked []string
+	for i, leaf := range parts {
+		walked = append(walked, leaf)
+		partial := strings.ToLower(strings.Join(walked, "/"))
+		if id, ok := f.getPathFromCache(partial); ok {
+			parentID = id
+			continue
+		}
+		// Every segment but the last must be a directory to continue the
+		// walk; the leaf segment can be either a file or a directory.
+		intermediate := i < len(parts)-1
+		id, err := f.findChild(ctx, parentID, leaf, intermediate, create)
+		if err != nil {
+			if !create && errors.Cause(err) == fs.ErrorObjectNotFound {
+				f.saveNegativePathToCache(partial, parentID)
+			}
+			return 0, err
+		}
+		f.savePathToIDToCache(partial, id)
+		f.saveParentToCache(id, parentID)
+		parentID = id
+	}
+	return parentID, nil
+}
+
+// findChild looks up leaf inside parentID, optionally creating it as a
+// directory if it doesn't exist and create is true. If wantDir is true,
+// only directory entries are considered, since 123 allows a file and a
+// directory to share the same name within a parent, and an intermediate
+// path segment can only ever mean the directory.
+//
+// If more than one entry of the wanted type shares the same name (the API
+// allows duplicate filenames within a directory, e.g. after a suffix-race)
+// the most recently created match is preferred, since that's almost always
+// the one the user meant, and a warning is logged so the ambiguity isn't
+// silent.
+func (f *Fs) findChild(ctx context.Context, parentID int64, leaf string, wantDir, create bool) (int64, error) {
+	infos, err := f.listDir(ctx, parentID)
+	if err != nil {
+		return 0, err
+	}
+	encodedLeaf := f.opt.Enc.FromStandardName(leaf)
+	var matches []*api.FileInfo
+	for i := range infos {
+		info := &infos[i]
+		if info.Trashed != 0 {
+			continue
+		}
+		if info.Filename != encodedLeaf {
+			continue
+		}
+		const typeDir = 1
+		if wantDir && info.Type != typeDir {
+			continue
+		}
+		matches = append(matches, info)
+	}
+	if len(matches) > 1 {
+		fs.Logf(f, "multiple entries named %q found in the same parent - using the most recently created one", leaf)
+	}
+	var best *api.FileInfo
+	for _, info := range matches {
+		if best == nil || info.CreateAt > best.CreateAt {
+			best = info
+		}
+	}
+	if best != nil {
+		return best.FileID, nil
+	}
+	if !create {
+		return 0, fs.ErrorObjectNotFound
+	}
+	return f.createDirectory(ctx, parentID, encodedLeaf)
+}
+
+// createDirectory creates a new directory named leaf under parentID.
+// Retries (and the single underlying HTTP request) go entirely through
+// f.pacer/f.shouldRetry, which already check ctx before and between
+// attempts, so a cancelled ctx aborts promptly rather than blocking on a
+// bare, ctx-unaware sleep.
+func (f *Fs) createDirectory(ctx context.Context, parentID int64, leaf string) (int64, error) {
+	var result api.MkdirResponse
+	req := api.MkdirRequest{Name: leaf, ParentID: parentID}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/upload/v1/file/mkdir",
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create directory")
+	}
+	if !result.OK() {
+		return 0, errors.Errorf("failed to create directory: %s", result.Message)
+	}
+	f.clearDirCacheFor(parentID)
+	return result.Data.DirID, nil
+}
+
+// clearDirCacheFor drops the cached listing of parentID so the next List
+// sees newly created or removed children.
+func (f *Fs) clearDirCacheFor(parentID int64) {
+	f.cache.mu.Lock()
+	delete(f.cache.dirCache, parentID)
+	f.cache.mu.Unlock()
+	f.invalidateNegativePathCacheForParent(parentID)
+}
+
+// invalidateCachesForMove drops exactly what moving or renaming a single
+// file or directory can make stale - its own path cache entry and parent
+// cache entry (its parent fileID changed), plus the directory listing of
+// whichever parent(s) the move actually touched - instead of clearCache's
+// blanket flush of every path the whole tree has ever resolved.
+// oldFullPath is the full (root-relative) path before the move; pass ""
+// if it's not known (e.g. a brand new file from Copy, which has no stale
+// path entry to remove) or if the caller already handled the path cache
+// itself (e.g. invalidatePathCacheForSubtree, for a moved directory).
+func (f *Fs) invalidateCachesForMove(oldFullPath string, fileID, oldParentID, newParentID int64) {
+	f.cache.mu.Lock()
+	if oldFullPath != "" {
+		delete(f.cache.pathCache, strings.ToLower(oldFullPath))
+	}
+	delete(f.cache.parentCache, fileID)
+	f.cache.mu.Unlock()
+
+	f.clearDirCacheFor(oldParentID)
+	if newParentID != oldParentID {
+		f.clearDirCacheFor(newParentID)
+	}
+}
+
+// invalidatePathCacheForSubtree drops every path cache and negative path
+// cache entry at or under oldFullPath (case-insensitively). Moving or
+// renaming a directory doesn't change any descendant's fileID, so their
+// parent-cache entries (child fileID -> immediate parent fileID) stay
+// correct - but every cached path *string* under the old location now
+// points at the wrong place, the same problem a dirListCache
+// DeletePrefix("dirlist_%d_") would solve for a listing cache.
+func (f *Fs) invalidatePathCacheForSubtree(oldFullPath string) {
+	key := strings.ToLower(oldFullPath)
+	prefix := key + "/"
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	for path := range f.cache.pathCache {
+		if path == key || strings.HasPrefix(path, prefix) {
+			delete(f.cache.pathCache, path)
+		}
+	}
+	for path := range f.cache.negativePathCache {
+		if path == key || strings.HasPrefix(path, prefix) {
+			delete(f.cache.negativePathCache, path)
+		}
+	}
+}
+
+// getParentID returns the parent fileID of fileID, consulting the parent
+// cache before falling back to the API.
+func (f *Fs) getParentID(ctx context.Context, fileID int64) (int64, error) {
+	if parentID, ok := f.getParentFromCache(fileID); ok {
+		return parentID, nil
+	}
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return 0, err
+	}
+	f.saveParentToCache(fileID, info.ParentFileID)
+	return info.ParentFileID, nil
+}
+
+// idToPath reconstructs the full root-relative path of fileID by walking
+// its ancestors one parent at a time via getParentID. It is the inverse of
+// pathToFileID.
+func (f *Fs) idToPath(ctx context.Context, fileID int64) (string, error) {
+	if fileID == f.rootFolderID {
+		return "", nil
+	}
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	leaf := f.opt.Enc.ToStandardName(info.Filename)
+	parts = append(parts, leaf)
+
+	current := info.ParentFileID
+	for current != f.rootFolderID {
+		parentInfo, err := f.getFileInfo(ctx, current)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to resolve ancestor %d", current)
+		}
+		parts = append(parts, f.opt.Enc.ToStandardName(parentInfo.Filename))
+		f.saveParentToCache(current, parentInfo.ParentFileID)
+		current, err = f.getParentID(ctx, current)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to resolve ancestor %d", current)
+		}
+	}
+
+	// parts was built leaf-first, so reverse it before joining.
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "/"), nil
+}
@@ -0,0 +1,84 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pathToFileIDCacheTestTree maps parentFileID -> children (fileID, name,
+// type) for a tiny fake directory structure:
+//
+//	root(0) -> a(1, dir) -> b(2, dir) -> c.txt(3), d.txt(4)
+var pathToFileIDCacheTestTree = map[int64][]api.FileInfo{
+	0: {{FileID: 1, Filename: "a", Type: 1}},
+	1: {{FileID: 2, Filename: "b", Type: 1}},
+	2: {{FileID: 3, Filename: "c.txt", Type: 0}, {FileID: 4, Filename: "d.txt", Type: 0}},
+}
+
+// newPathToFileIDCacheTestFs counts how many times a directory is listed
+// so tests can tell whether pathToFileID re-walked an intermediate
+// component it had already cached.
+func newPathToFileIDCacheTestFs(t *testing.T) (f *Fs, listCalls *int) {
+	listCalls = new(int)
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/file/list" {
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+		mu.Lock()
+		*listCalls++
+		mu.Unlock()
+		var req api.FileListRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"lastFileId": -1,
+				"fileList":   pathToFileIDCacheTestTree[req.ParentFileID],
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, listCalls
+}
+
+// TestPathToFileIDCachesEachIntermediateComponent confirms that once a/b
+// has been walked once, a second lookup under the same parent only needs
+// to list that one directory again - the "a" and "a/b" components are
+// already cached, so they don't get re-listed.
+func TestPathToFileIDCachesEachIntermediateComponent(t *testing.T) {
+	f, listCalls := newPathToFileIDCacheTestFs(t)
+
+	id, err := f.pathToFileID(context.Background(), "a/b/c.txt", false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, id)
+	assert.Equal(t, 3, *listCalls, "cold walk of a 3-level path should list each level once")
+
+	id, err = f.pathToFileID(context.Background(), "a/b/d.txt", false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, id)
+	assert.Equal(t, 4, *listCalls, "a and a/b are already cached, so only a/b's own listing should repeat")
+}
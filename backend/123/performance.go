@@ -0,0 +1,202 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// maxTTFBSamples bounds the in-memory window of time-to-first-byte samples
+// kept for ttfbStats, so a long-running process doesn't grow this
+// unbounded. Oldest samples are dropped once the window is full.
+const maxTTFBSamples = 1000
+
+// recordTTFB appends a time-to-first-byte sample, dropping the oldest
+// sample once maxTTFBSamples is exceeded.
+func (f *Fs) recordTTFB(d time.Duration) {
+	f.ttfbMu.Lock()
+	defer f.ttfbMu.Unlock()
+	f.ttfbSamplesMs = append(f.ttfbSamplesMs, float64(d.Milliseconds()))
+	if len(f.ttfbSamplesMs) > maxTTFBSamples {
+		f.ttfbSamplesMs = f.ttfbSamplesMs[len(f.ttfbSamplesMs)-maxTTFBSamples:]
+	}
+}
+
+// ttfbStats returns the average and 95th percentile of the current
+// time-to-first-byte window, in milliseconds, and the number of samples it
+// was computed from.
+func (f *Fs) ttfbStats() (avgMs, p95Ms float64, n int) {
+	f.ttfbMu.Lock()
+	samples := append([]float64(nil), f.ttfbSamplesMs...)
+	f.ttfbMu.Unlock()
+
+	n = len(samples)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	sort.Float64s(samples)
+	var sum float64
+	for _, s := range samples {
+		sum += s
+	}
+	avgMs = sum / float64(n)
+	p95Index := int(float64(n) * 0.95)
+	if p95Index >= n {
+		p95Index = n - 1
+	}
+	p95Ms = samples[p95Index]
+	return avgMs, p95Ms, n
+}
+
+// defaultPerformanceLogInterval is used when --123-performance-log-interval
+// is 0 and --123-enable-performance-log is set.
+const defaultPerformanceLogInterval = time.Minute
+
+// performanceMonitor runs in the background, periodically logging cache
+// and memory-manager statistics, until ctx is cancelled or done is closed.
+// done is passed in rather than read from f.performanceMonitorDone on each
+// iteration because stopPerformanceMonitoring mutates that field under
+// f.performanceMonitorMu, a lock this goroutine doesn't otherwise take;
+// capturing it once at start time avoids racing with that mutation.
+func (f *Fs) performanceMonitor(ctx context.Context, done <-chan struct{}) {
+	interval := time.Duration(f.opt.PerformanceLogInterval)
+	if interval <= 0 {
+		interval = defaultPerformanceLogInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.logPerformanceStats()
+			if f.opt.MetricsFile != "" {
+				if err := f.writePerformanceMetricsFile(f.opt.MetricsFile); err != nil {
+					fs.Logf(f, "performance: failed to write metrics file: %v", err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// performanceSnapshot holds one sample of the stats logPerformanceStats and
+// writePerformanceMetricsFile both report, gathered under the relevant
+// locks just once rather than twice.
+type performanceSnapshot struct {
+	dirEntries, pathEntries, parentEntries int
+	memoryAllocated                        int64
+	ttfbAvgMs, ttfbP95Ms                   float64
+	ttfbSamples                            int
+	uploadConcurrency, downloadConcurrency int
+}
+
+// gatherPerformanceSnapshot collects the current cache, memory-manager,
+// time-to-first-byte and concurrency stats.
+func (f *Fs) gatherPerformanceSnapshot() performanceSnapshot {
+	f.cache.mu.Lock()
+	s := performanceSnapshot{
+		dirEntries:    len(f.cache.dirCache),
+		pathEntries:   len(f.cache.pathCache),
+		parentEntries: len(f.cache.parentCache),
+	}
+	f.cache.mu.Unlock()
+
+	if f.memoryManager != nil {
+		f.memoryManager.mu.Lock()
+		s.memoryAllocated = f.memoryManager.allocated
+		f.memoryManager.mu.Unlock()
+	}
+
+	s.ttfbAvgMs, s.ttfbP95Ms, s.ttfbSamples = f.ttfbStats()
+	s.uploadConcurrency = f.effectiveUploadConcurrency()
+	s.downloadConcurrency = f.effectiveDownloadConcurrency()
+	return s
+}
+
+// logPerformanceStats emits a single summary line of current cache and
+// memory-manager usage.
+func (f *Fs) logPerformanceStats() {
+	s := f.gatherPerformanceSnapshot()
+	fs.Logf(f, "performance: dirCache=%d pathCache=%d parentCache=%d memoryAllocated=%d",
+		s.dirEntries, s.pathEntries, s.parentEntries, s.memoryAllocated)
+}
+
+// writePerformanceMetricsFile marshals the current performance snapshot to
+// path as JSON, the same stats logPerformanceStats logs plus
+// time-to-first-byte and upload/download concurrency. The write is atomic:
+// it's written to a temp file alongside path first, then renamed over it,
+// so a concurrent reader never observes a partially written file.
+func (f *Fs) writePerformanceMetricsFile(path string) error {
+	s := f.gatherPerformanceSnapshot()
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"dirCacheEntries":     s.dirEntries,
+		"pathCacheEntries":    s.pathEntries,
+		"parentCacheEntries":  s.parentEntries,
+		"memoryAllocated":     s.memoryAllocated,
+		"ttfbAvgMs":           s.ttfbAvgMs,
+		"ttfbP95Ms":           s.ttfbP95Ms,
+		"ttfbSamples":         s.ttfbSamples,
+		"uploadConcurrency":   s.uploadConcurrency,
+		"downloadConcurrency": s.downloadConcurrency,
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal performance metrics")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "123-metrics-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create performance metrics temp file")
+	}
+	tmpName := tmp.Name()
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil {
+		_ = os.Remove(tmpName)
+		return errors.Wrap(writeErr, "failed to write performance metrics temp file")
+	}
+	if closeErr != nil {
+		_ = os.Remove(tmpName)
+		return errors.Wrap(closeErr, "failed to close performance metrics temp file")
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return errors.Wrap(err, "failed to replace performance metrics file")
+	}
+	return nil
+}
+
+// startPerformanceMonitoring starts the background performance monitor
+// goroutine. It is safe to call at most once per Fs.
+func (f *Fs) startPerformanceMonitoring(ctx context.Context) {
+	f.performanceMonitorOnce.Do(func() {
+		done := make(chan struct{})
+		f.performanceMonitorMu.Lock()
+		f.performanceMonitorDone = done
+		f.performanceMonitorMu.Unlock()
+		go f.performanceMonitor(ctx, done)
+	})
+}
+
+// stopPerformanceMonitoring stops the background performance monitor
+// goroutine, if running.
+func (f *Fs) stopPerformanceMonitoring() {
+	f.performanceMonitorMu.Lock()
+	defer f.performanceMonitorMu.Unlock()
+	if f.performanceMonitorDone != nil {
+		close(f.performanceMonitorDone)
+		f.performanceMonitorDone = nil
+		f.performanceMonitorOnce = sync.Once{}
+	}
+}
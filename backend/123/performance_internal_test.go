@@ -0,0 +1,138 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerformanceMonitorTicksAtConfiguredInterval(t *testing.T) {
+	f := &Fs{
+		opt:   Options{PerformanceLogInterval: fs.Duration(5 * time.Millisecond)},
+		cache: newCacheState(),
+	}
+	f.saveDirListToCache(1, []int64{2})
+
+	f.startPerformanceMonitoring(context.Background())
+	time.Sleep(30 * time.Millisecond) // a handful of ticks should have fired
+	f.stopPerformanceMonitoring()
+
+	assert.Nil(t, f.performanceMonitorDone, "stopPerformanceMonitoring should clear the done channel")
+}
+
+func TestPerformanceMonitorStopsOnContextCancellation(t *testing.T) {
+	f := &Fs{
+		opt:   Options{PerformanceLogInterval: fs.Duration(5 * time.Millisecond)},
+		cache: newCacheState(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.startPerformanceMonitoring(ctx)
+	cancel()
+
+	// Give the goroutine a moment to observe cancellation and return; there's
+	// no direct way to join it, so this is a best-effort timing check.
+	time.Sleep(20 * time.Millisecond)
+
+	// A second start after cancellation is then possible because
+	// performanceMonitorOnce is independent of ctx, so this is really just
+	// confirming the monitor doesn't panic or hang once cancelled.
+	f.stopPerformanceMonitoring()
+}
+
+func TestTTFBStatsEmptyWithNoSamples(t *testing.T) {
+	f := &Fs{}
+	avgMs, p95Ms, n := f.ttfbStats()
+	assert.Zero(t, avgMs)
+	assert.Zero(t, p95Ms)
+	assert.Zero(t, n)
+}
+
+func TestTTFBStatsComputesAverageAndP95(t *testing.T) {
+	f := &Fs{}
+	for i := 1; i <= 100; i++ {
+		f.recordTTFB(time.Duration(i) * time.Millisecond)
+	}
+	avgMs, p95Ms, n := f.ttfbStats()
+	assert.Equal(t, 100, n)
+	assert.InDelta(t, 50.5, avgMs, 0.01)
+	assert.Equal(t, 96.0, p95Ms)
+}
+
+func TestTTFBStatsBoundsSampleWindow(t *testing.T) {
+	f := &Fs{}
+	for i := 0; i < maxTTFBSamples+10; i++ {
+		f.recordTTFB(time.Millisecond)
+	}
+	_, _, n := f.ttfbStats()
+	assert.Equal(t, maxTTFBSamples, n)
+}
+
+func TestWritePerformanceMetricsFileWritesValidJSON(t *testing.T) {
+	f := &Fs{cache: newCacheState(), memoryManager: newMemoryManager(0)}
+	f.saveDirListToCache(1, []int64{2, 3})
+	f.recordTTFB(10 * time.Millisecond)
+	f.recordTTFB(20 * time.Millisecond)
+
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	require.NoError(t, f.writePerformanceMetricsFile(path))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, 2.0, m["ttfbSamples"])
+	assert.Equal(t, 15.0, m["ttfbAvgMs"])
+
+	// No leftover temp file from the atomic write.
+	entries, err := ioutil.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWritePerformanceMetricsFileOverwritesAtomically(t *testing.T) {
+	f := &Fs{cache: newCacheState(), memoryManager: newMemoryManager(0)}
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte("stale"), 0644))
+
+	require.NoError(t, f.writePerformanceMetricsFile(path))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, "stale", string(data))
+}
+
+func TestPerformanceMonitorWritesMetricsFileWhenConfigured(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	f := &Fs{
+		opt:   Options{PerformanceLogInterval: fs.Duration(5 * time.Millisecond), MetricsFile: path},
+		cache: newCacheState(),
+	}
+
+	f.startPerformanceMonitoring(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	f.stopPerformanceMonitoring()
+
+	_, err := os.Stat(path)
+	assert.NoError(t, err, "metrics file should have been written by the monitor loop")
+}
+
+func TestCommandPerfStatsReportsSamples(t *testing.T) {
+	f := &Fs{}
+	f.recordTTFB(10 * time.Millisecond)
+	f.recordTTFB(20 * time.Millisecond)
+
+	res, err := f.commandPerfStats(context.Background())
+	assert.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, 2, m["samples"])
+	assert.Equal(t, 15.0, m["avg_ttfb_ms"])
+}
@@ -0,0 +1,35 @@
+package _123
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPlanSlicesSingleSliceForSmallFiles locks in that a file no larger
+// than the session's sliceSize uploads as exactly one slice - this
+// backend's only approximation of a "single-step" upload path, driven
+// entirely by the server's returned sliceSize rather than any local
+// size-threshold constant.
+func TestPlanSlicesSingleSliceForSmallFiles(t *testing.T) {
+	slices := planSlices(100, 1024)
+	assert.Len(t, slices, 1)
+	assert.Equal(t, sliceBounds{index: 0, offset: 0, length: 100}, slices[0])
+}
+
+func TestPlanSlicesExactMultipleOfSliceSize(t *testing.T) {
+	slices := planSlices(2048, 1024)
+	assert.Equal(t, []sliceBounds{
+		{index: 0, offset: 0, length: 1024},
+		{index: 1, offset: 1024, length: 1024},
+	}, slices)
+}
+
+func TestPlanSlicesLastSliceIsShort(t *testing.T) {
+	slices := planSlices(2500, 1024)
+	assert.Equal(t, []sliceBounds{
+		{index: 0, offset: 0, length: 1024},
+		{index: 1, offset: 1024, length: 1024},
+		{index: 2, offset: 2048, length: 452},
+	}, slices)
+}
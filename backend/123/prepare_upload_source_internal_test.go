@@ -0,0 +1,154 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPrepareUploadSourceTestFs returns a minimal Fs with just enough
+// state (buffer pool, progress dir) for prepareUploadSource and its
+// memoryBufferedCrossCloudTransfer fallback to run.
+func newPrepareUploadSourceTestFs(t testing.TB) *Fs {
+	f := &Fs{
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 1024*1024) },
+		},
+		memoryManager: newMemoryManager(0),
+	}
+	f.opt.ProgressDir = t.TempDir()
+	return f
+}
+
+func md5Hex(content []byte) string {
+	h := md5.Sum(content)
+	return hex.EncodeToString(h[:])
+}
+
+// sequentialOnlyReader hides any io.ReaderAt the underlying reader might
+// implement, forcing prepareUploadSource down the buffered fallback path.
+type sequentialOnlyReader struct {
+	io.Reader
+}
+
+func TestPrepareUploadSourceReusesReaderAtWithoutBuffering(t *testing.T) {
+	f := newPrepareUploadSourceTestFs(t)
+	content := bytes.Repeat([]byte("abcde"), 1000)
+
+	before, err := os.ReadDir(f.opt.ProgressDir)
+	require.NoError(t, err)
+
+	source, resolvedSize, etag, sha1sum, cleanup, err := f.prepareUploadSource(context.Background(), bytes.NewReader(content), int64(len(content)), nil)
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.EqualValues(t, len(content), resolvedSize)
+	assert.Equal(t, md5Hex(content), etag)
+	assert.Empty(t, sha1sum)
+
+	got := make([]byte, len(content))
+	_, err = source.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// No temp file should have been created for an already-ReaderAt source.
+	after, err := os.ReadDir(f.opt.ProgressDir)
+	require.NoError(t, err)
+	assert.Equal(t, len(before), len(after))
+}
+
+func TestPrepareUploadSourceBuffersPlainReaderToTempFile(t *testing.T) {
+	f := newPrepareUploadSourceTestFs(t)
+	content := bytes.Repeat([]byte("fghij"), 1000)
+
+	source, resolvedSize, etag, sha1sum, cleanup, err := f.prepareUploadSource(
+		context.Background(),
+		sequentialOnlyReader{bytes.NewReader(content)},
+		int64(len(content)),
+		nil,
+	)
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.EqualValues(t, len(content), resolvedSize)
+	assert.Equal(t, md5Hex(content), etag)
+	assert.Empty(t, sha1sum)
+
+	got := make([]byte, len(content))
+	_, err = source.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// The fallback must have gone through a real on-disk temp file, which
+	// cleanup() should remove.
+	file, ok := source.(*os.File)
+	require.True(t, ok, "fallback source should be a real *os.File")
+	tmpPath := file.Name()
+	cleanup()
+	_, err = os.Stat(tmpPath)
+	assert.True(t, os.IsNotExist(err), "cleanup should have removed the temp file")
+}
+
+func TestPrepareUploadSourceComputesSHA1WhenEnabled(t *testing.T) {
+	content := bytes.Repeat([]byte("abcde"), 1000)
+	sum := sha1.Sum(content)
+	wantSHA1 := hex.EncodeToString(sum[:])
+
+	f := newPrepareUploadSourceTestFs(t)
+	f.opt.ComputeSHA1OnUpload = true
+	_, _, _, sha1sum, cleanup, err := f.prepareUploadSource(context.Background(), bytes.NewReader(content), int64(len(content)), nil)
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, wantSHA1, sha1sum)
+
+	f2 := newPrepareUploadSourceTestFs(t)
+	f2.opt.ComputeSHA1OnUpload = true
+	_, _, _, sha1sum2, cleanup2, err := f2.prepareUploadSource(context.Background(), sequentialOnlyReader{bytes.NewReader(content)}, int64(len(content)), nil)
+	require.NoError(t, err)
+	defer cleanup2()
+	assert.Equal(t, wantSHA1, sha1sum2)
+}
+
+// BenchmarkPrepareUploadSource compares the io.ReaderAt fast path against
+// the buffered temp-file fallback for a large source, demonstrating the
+// savings from skipping the extra copy when the source already supports
+// random access.
+func BenchmarkPrepareUploadSource(b *testing.B) {
+	const size = 64 * 1024 * 1024
+	content := bytes.Repeat([]byte("x"), size)
+
+	b.Run("ReaderAt", func(b *testing.B) {
+		f := newPrepareUploadSourceTestFs(b)
+		b.SetBytes(size)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _, _, _, cleanup, err := f.prepareUploadSource(context.Background(), bytes.NewReader(content), size, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			cleanup()
+		}
+	})
+
+	b.Run("BufferedFallback", func(b *testing.B) {
+		f := newPrepareUploadSourceTestFs(b)
+		b.SetBytes(size)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _, _, _, cleanup, err := f.prepareUploadSource(context.Background(), sequentialOnlyReader{bytes.NewReader(content)}, size, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			cleanup()
+		}
+	})
+}
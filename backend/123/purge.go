@@ -0,0 +1,66 @@
+package _123
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// typeDir is the FileInfo.Type value for a directory entry.
+const typeDir = 1
+
+// Purge deletes the whole directory tree under f.root, collecting every
+// file and subdirectory fileID first and then handing the lot to
+// deleteFiles in as few batched /api/v1/file/trash calls as possible -
+// rather than falling back to rclone's generic recursive List+Remove,
+// which would issue one trash call per file.
+func (f *Fs) Purge(ctx context.Context) error {
+	if f.root == "" {
+		return errors.New("can't purge root directory")
+	}
+
+	dirID, err := f.pathToFileID(ctx, f.root, false)
+	if err != nil {
+		if err == fs.ErrorObjectNotFound {
+			return fs.ErrorDirNotFound
+		}
+		return err
+	}
+
+	fileIDs, err := f.collectSubtreeFileIDs(ctx, dirID)
+	if err != nil {
+		return errors.Wrap(err, "purge: failed to list directory tree")
+	}
+	if err := f.deleteFiles(ctx, fileIDs); err != nil {
+		return errors.Wrap(err, "purge: failed to delete directory tree")
+	}
+	return nil
+}
+
+// collectSubtreeFileIDs returns dirID and the fileID of every file and
+// subdirectory nested beneath it, so the whole tree can be handed to
+// deleteFiles, which trashes it in deleteBatchSize-sized calls, instead of
+// being walked again during deletion.
+func (f *Fs) collectSubtreeFileIDs(ctx context.Context, dirID int64) ([]int64, error) {
+	ids := []int64{dirID}
+	infos, err := f.listDir(ctx, dirID)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Trashed != 0 {
+			continue
+		}
+		if info.Type == typeDir {
+			nested, err := f.collectSubtreeFileIDs(ctx, info.FileID)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, nested...)
+			continue
+		}
+		ids = append(ids, info.FileID)
+	}
+	return ids, nil
+}
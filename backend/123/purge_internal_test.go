@@ -0,0 +1,101 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPurgeTestFs builds a fake server whose root directory (fileID 7)
+// contains n plain files plus one subdirectory (fileID 8) containing m
+// more files, and records every batch of fileIDs handed to
+// /api/v1/file/trash.
+func newPurgeTestFs(t *testing.T, n, m int) (f *Fs, deleteCalls *[][]int64) {
+	deleteCalls = new([][]int64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			var req api.FileListRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var fileList []map[string]interface{}
+			switch req.ParentFileID {
+			case 7:
+				for i := 0; i < n; i++ {
+					fileList = append(fileList, map[string]interface{}{
+						"fileID": 100 + i, "filename": "f.bin", "type": 0, "size": 1,
+					})
+				}
+				fileList = append(fileList, map[string]interface{}{
+					"fileID": 8, "filename": "subdir", "type": 1, "size": 0,
+				})
+			case 8:
+				for i := 0; i < m; i++ {
+					fileList = append(fileList, map[string]interface{}{
+						"fileID": 200 + i, "filename": "g.bin", "type": 0, "size": 1,
+					})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": fileList},
+			})
+		case "/api/v1/file/trash":
+			var req api.DeleteRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*deleteCalls = append(*deleteCalls, req.FileIDs)
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		root:         "dir",
+		cache:        newCacheState(),
+		cacheConfig:  DefaultCacheConfig(),
+		srv:          rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:        fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		rootFolderID: 7,
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("dir", 7)
+	return f, deleteCalls
+}
+
+func TestPurgeDeletesWholeTreeInOneBatch(t *testing.T) {
+	f, deleteCalls := newPurgeTestFs(t, 2, 3)
+	err := f.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(*deleteCalls))
+	// root dir itself + 2 root files + subdir + 3 subdir files = 7 ids
+	assert.Equal(t, 7, len((*deleteCalls)[0]))
+}
+
+func TestPurgeBatchesLargeTrees(t *testing.T) {
+	n := deleteBatchSize + 10
+	f, deleteCalls := newPurgeTestFs(t, n, 0)
+	err := f.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, len(*deleteCalls))
+	assert.Equal(t, deleteBatchSize, len((*deleteCalls)[0]))
+}
+
+func TestPurgeRefusesEmptyRoot(t *testing.T) {
+	f, _ := newPurgeTestFs(t, 0, 0)
+	f.root = ""
+	err := f.Purge(context.Background())
+	require.Error(t, err)
+}
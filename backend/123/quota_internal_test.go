@@ -0,0 +1,129 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuotaTestFs(t *testing.T, spaceTempExpr string) *Fs {
+	f, _ := newQuotaTestFsWithCallCount(t, spaceTempExpr)
+	return f
+}
+
+// newQuotaTestFsWithCallCount is newQuotaTestFs plus a counter of how many
+// times the fake server's user/info endpoint was actually hit, so
+// getUserInfoCached's caching can be verified.
+func newQuotaTestFsWithCallCount(t *testing.T, spaceTempExpr string) (f *Fs, calls func() int) {
+	var n int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"uid":            42,
+				"nickname":       "tester",
+				"spaceUsed":      100,
+				"spacePermanent": 1000,
+				"spaceTemp":      500,
+				"spaceTempExpr":  spaceTempExpr,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, func() int { return n }
+}
+
+func TestAboutExcludesExpiredSpaceTemp(t *testing.T) {
+	f := newQuotaTestFs(t, time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	usage, err := f.About(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1000, *usage.Total) // permanent only, temp expired
+	assert.EqualValues(t, 900, *usage.Free)
+}
+
+func TestAboutIncludesValidSpaceTemp(t *testing.T) {
+	f := newQuotaTestFs(t, time.Now().Add(time.Hour).Format(time.RFC3339))
+
+	usage, err := f.About(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1500, *usage.Total) // permanent + still-valid temp
+	assert.EqualValues(t, 1400, *usage.Free)
+}
+
+func TestCommandQuotaReportsExpiredTemp(t *testing.T) {
+	f := newQuotaTestFs(t, time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	res, err := f.commandQuota(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 0, m["spaceTemp"])
+	assert.Contains(t, m["spaceTempUntil"], "expired at")
+}
+
+func TestCommandQuotaReportsValidTemp(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	f := newQuotaTestFs(t, expiry.Format(time.RFC3339))
+
+	res, err := f.commandQuota(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 500, m["spaceTemp"])
+	assert.Equal(t, expiry.Format(time.RFC3339), m["spaceTempUntil"])
+}
+
+func TestCommandQuotaReportsNoTemp(t *testing.T) {
+	f := newQuotaTestFs(t, "")
+
+	res, err := f.commandQuota(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, "none", m["spaceTempUntil"])
+}
+
+func TestCommandQuotaIncludesUIDAndNickname(t *testing.T) {
+	f := newQuotaTestFs(t, "")
+
+	res, err := f.commandQuota(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.EqualValues(t, 42, m["uid"])
+	assert.Equal(t, "tester", m["nickname"])
+}
+
+// TestCommandQuotaCachesBriefly confirms a second quota call made right
+// after the first is served from getUserInfoCached without a second
+// user/info request, but a call made once the cache has expired hits the
+// API again.
+func TestCommandQuotaCachesBriefly(t *testing.T) {
+	f, calls := newQuotaTestFsWithCallCount(t, "")
+
+	_, err := f.commandQuota(context.Background())
+	require.NoError(t, err)
+	_, err = f.commandQuota(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls())
+
+	time.Sleep(userInfoCacheTTL + 50*time.Millisecond)
+	_, err = f.commandQuota(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls())
+}
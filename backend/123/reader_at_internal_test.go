@@ -0,0 +1,132 @@
+package _123
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/require"
+)
+
+const readerAtTestSliceSize = 4
+
+// countingReaderAt wraps an io.ReaderAt and counts how many ReadAt calls
+// were made, to confirm uploadSlicesOnce shares one handle across workers
+// rather than opening the source once per slice. Workers call ReadAt
+// concurrently, so calls is updated atomically.
+type countingReaderAt struct {
+	io.ReaderAt
+	calls int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	atomic.AddInt64(&c.calls, 1)
+	return c.ReaderAt.ReadAt(p, off)
+}
+
+// TestUploadSlicesSharesSingleFileHandle confirms the concurrent slice
+// upload path reads a local source via a single already-open io.ReaderAt
+// (as object.go's upload does by opening the buffered temp file exactly
+// once) rather than reopening it per slice.
+func TestUploadSlicesSharesSingleFileHandle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	f := &Fs{
+		opt:   Options{UploadConcurrency: 4},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+
+	tmp, err := ioutil.TempFile("", "123-readerat-test-*")
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+	data := make([]byte, 5*readerAtTestSliceSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	_, err = tmp.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, tmp.Close())
+
+	// Open the source exactly once, as object.go's upload does for the
+	// buffered temp file, then hand the same handle to every worker.
+	file, err := os.Open(tmp.Name())
+	require.NoError(t, err)
+	defer file.Close()
+	counting := &countingReaderAt{ReaderAt: file}
+
+	slices := planSlices(int64(len(data)), readerAtTestSliceSize)
+	res, err := f.uploadSlicesOnce(context.Background(), nil, "preupload", counting, slices, f.effectiveUploadConcurrency())
+	require.NoError(t, err)
+	require.Empty(t, res.missing)
+	require.Greater(t, atomic.LoadInt64(&counting.calls), int64(0), "ReadAt must be used to read each slice")
+}
+
+// BenchmarkUploadPerChunkOpen simulates the pattern this request asks to
+// avoid: opening the source file once per chunk.
+func BenchmarkUploadPerChunkOpen(b *testing.B) {
+	path, cleanup := benchTempFile(b)
+	defer cleanup()
+	slices := planSlices(5*readerAtTestSliceSize, readerAtTestSliceSize)
+
+	for i := 0; i < b.N; i++ {
+		for _, s := range slices {
+			file, err := os.Open(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			buf := make([]byte, s.length)
+			_, _ = file.ReadAt(buf, s.offset)
+			_ = file.Close()
+		}
+	}
+}
+
+// BenchmarkUploadSingleHandleReadAt is the approach this backend actually
+// uses: one open, many ReadAt calls via io.NewSectionReader.
+func BenchmarkUploadSingleHandleReadAt(b *testing.B) {
+	path, cleanup := benchTempFile(b)
+	defer cleanup()
+	slices := planSlices(5*readerAtTestSliceSize, readerAtTestSliceSize)
+
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, s := range slices {
+			section := io.NewSectionReader(file, s.offset, s.length)
+			buf := make([]byte, s.length)
+			_, _ = io.ReadFull(section, buf)
+		}
+		_ = file.Close()
+	}
+}
+
+func benchTempFile(b *testing.B) (path string, cleanup func()) {
+	tmp, err := ioutil.TempFile("", "123-readerat-bench-*")
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := make([]byte, 5*readerAtTestSliceSize)
+	if _, err := tmp.Write(data); err != nil {
+		b.Fatal(err)
+	}
+	_ = tmp.Close()
+	return tmp.Name(), func() { os.Remove(tmp.Name()) }
+}
@@ -0,0 +1,78 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRefreshUploadDomainTestFs builds an Fs whose srv root is goodDomain;
+// goodDomain answers HEAD requests and http://127.0.0.1:1, listed before it
+// in UploadDomains, refuses all connections.
+func newRefreshUploadDomainTestFs(t *testing.T) (f *Fs, goodDomain string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		opt:           Options{UploadDomains: "http://127.0.0.1:1," + server.URL},
+		cache:         newCacheState(),
+		cacheConfig:   DefaultCacheConfig(),
+		memoryManager: newMemoryManager(0),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 64*1024) },
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, server.URL
+}
+
+func TestPreferSelectedUploadDomainReordersCachedDomainFirst(t *testing.T) {
+	f, good := newRefreshUploadDomainTestFs(t)
+	candidates := []string{"http://127.0.0.1:1", good, "http://127.0.0.1:2"}
+
+	f.setSelectedUploadDomain(good)
+	reordered := f.preferSelectedUploadDomain(candidates)
+	assert.Equal(t, []string{good, "http://127.0.0.1:1", "http://127.0.0.1:2"}, reordered)
+}
+
+func TestPreferSelectedUploadDomainLeavesOrderWhenNotCached(t *testing.T) {
+	f, _ := newRefreshUploadDomainTestFs(t)
+	candidates := []string{"http://127.0.0.1:1", "http://127.0.0.1:2"}
+	assert.Equal(t, candidates, f.preferSelectedUploadDomain(candidates))
+}
+
+func TestSelectUploadDomainSkipsUnreachableCandidates(t *testing.T) {
+	f, good := newRefreshUploadDomainTestFs(t)
+	selected := f.selectUploadDomain(context.Background(), []string{"http://127.0.0.1:1", good})
+	assert.Equal(t, good, selected)
+}
+
+func TestCommandRefreshUploadDomainCachesSelection(t *testing.T) {
+	// "" (the already-configured root, itself pointed at the fake server)
+	// is always the first candidate and is treated as always reachable,
+	// so a fresh selection with no dynamic servers lands on it.
+	f, _ := newRefreshUploadDomainTestFs(t)
+
+	f.setSelectedUploadDomain("http://stale.invalid")
+
+	result, err := f.commandRefreshUploadDomain(context.Background())
+	require.NoError(t, err)
+	m, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "", m["domain"])
+	assert.Equal(t, "", f.getSelectedUploadDomain())
+}
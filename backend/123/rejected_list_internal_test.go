@@ -0,0 +1,85 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRejectedListTestFs serves a single directory containing one normal
+// file and one file rejected by content review (status 150).
+func newRejectedListTestFs(t *testing.T, showRejected bool) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"lastFileId": -1,
+				"fileList": []map[string]interface{}{
+					{"fileID": 1, "filename": "ok.txt", "type": 0, "size": 5, "status": 0, "createAt": "2020-01-01 00:00:00"},
+					{"fileID": 2, "filename": "blocked.mp4", "type": 0, "size": 9, "status": 150, "createAt": "2020-01-01 00:00:00"},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		opt:   Options{ShowRejected: showRejected},
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:      rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:    fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+		features: &fs.Features{},
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestListHidesRejectedFilesByDefault(t *testing.T) {
+	f := newRejectedListTestFs(t, false)
+
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+
+	var remotes []string
+	for _, e := range entries {
+		remotes = append(remotes, e.Remote())
+	}
+	assert.ElementsMatch(t, []string{"ok.txt"}, remotes)
+}
+
+func TestListSurfacesRejectedFilesWhenShowRejectedIsSet(t *testing.T) {
+	f := newRejectedListTestFs(t, true)
+
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+
+	var remotes []string
+	for _, e := range entries {
+		remotes = append(remotes, e.Remote())
+	}
+	assert.ElementsMatch(t, []string{"ok.txt", "blocked.mp4"}, remotes)
+}
+
+func TestOpenRefusesRejectedFile(t *testing.T) {
+	o := &Object{fs: &Fs{}, remote: "blocked.mp4", status: 150}
+
+	_, err := o.Open(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected by content review")
+}
@@ -0,0 +1,41 @@
+package _123
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRetryResourceExhaustion(t *testing.T) {
+	f := &Fs{}
+
+	retry, err := f.shouldRetry(context.Background(), nil, errors.New("http: connection pool exhausted"))
+	assert.True(t, retry)
+	assert.Error(t, err)
+
+	retry, err = f.shouldRetry(context.Background(), nil, errors.New("dial tcp: socket: too many open files"))
+	assert.True(t, retry)
+	assert.Error(t, err)
+}
+
+func TestThrottleConcurrencyReducesEffectiveConcurrencyTemporarily(t *testing.T) {
+	f := &Fs{opt: Options{UploadConcurrency: 8}}
+	assert.Equal(t, 8, f.effectiveUploadConcurrency())
+
+	f.throttleConcurrency()
+	assert.Equal(t, 1, f.effectiveUploadConcurrency(), "concurrency should drop to 1 right after exhaustion is seen")
+
+	// Simulate the cooldown having already elapsed.
+	f.resourceExhaustedUntil = time.Now().Add(-time.Millisecond).UnixNano()
+	assert.Equal(t, 8, f.effectiveUploadConcurrency(), "concurrency should recover once the cooldown passes")
+}
+
+func TestShouldRetryResourceExhaustionThrottlesConcurrency(t *testing.T) {
+	f := &Fs{opt: Options{UploadConcurrency: 8}}
+
+	_, _ = f.shouldRetry(context.Background(), nil, errors.New("connection pool exhausted"))
+	assert.Equal(t, 1, f.effectiveUploadConcurrency())
+}
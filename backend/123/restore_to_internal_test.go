@@ -0,0 +1,150 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreToTestEntry is one file or directory tracked by
+// newRestoreToTestFs's fake server.
+type restoreToTestEntry struct {
+	fileID   int64
+	filename string
+	parentID int64
+	typ      int
+	trashed  int
+}
+
+// newRestoreToTestFs builds a stateful fake server backing a small file
+// tree that supports recover, move, mkdir, list and detail, so restore-to
+// can be exercised end to end.
+func newRestoreToTestFs(t *testing.T, entries []restoreToTestEntry) (f *Fs, tree map[int64]*restoreToTestEntry) {
+	tree = make(map[int64]*restoreToTestEntry, len(entries))
+	var nextID int64 = 1000
+	for i := range entries {
+		e := entries[i]
+		tree[e.fileID] = &e
+		if e.fileID >= nextID {
+			nextID = e.fileID + 1
+		}
+	}
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			var req api.FileListRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var list []map[string]interface{}
+			for _, e := range tree {
+				if e.parentID != req.ParentFileID || e.trashed != 0 {
+					continue
+				}
+				list = append(list, map[string]interface{}{
+					"fileID": e.fileID, "filename": e.filename, "parentFileID": e.parentID,
+					"type": e.typ, "createAt": "2026-01-01 00:00:00",
+				})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"lastFileId": -1, "fileList": list},
+			})
+		case "/api/v1/file/recover":
+			var req api.RestoreRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for _, id := range req.FileIDs {
+				e := tree[id]
+				require.NotNil(t, e)
+				e.trashed = 0
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/api/v1/file/move":
+			var req api.MoveRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for _, id := range req.FileIDs {
+				e := tree[id]
+				require.NotNil(t, e)
+				e.parentID = req.ToParentFileID
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/upload/v1/file/mkdir":
+			var req api.MkdirRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			nextID++
+			tree[nextID] = &restoreToTestEntry{fileID: nextID, filename: req.Name, parentID: req.ParentID, typ: 1}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"dirID": nextID},
+			})
+		case "/api/v1/file/detail":
+			fileID := int64(0)
+			_, _ = fmt.Sscanf(r.URL.Query().Get("fileID"), "%d", &fileID)
+			e := tree[fileID]
+			require.NotNil(t, e)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID": e.fileID, "filename": e.filename, "parentFileID": e.parentID,
+					"type": e.typ, "etag": "etag", "size": 123, "createAt": "2026-01-01 00:00:00",
+				},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, tree
+}
+
+func TestCommandRestoreToMovesFileIntoNewDestination(t *testing.T) {
+	f, tree := newRestoreToTestFs(t, []restoreToTestEntry{
+		{fileID: 7, filename: "", parentID: 0, typ: 1},
+		{fileID: 8, filename: "orphaned-parent", parentID: 7, typ: 1},
+		{fileID: 1, filename: "restored.bin", parentID: 8, typ: 0, trashed: 1},
+	})
+	f.rootFolderID = 7
+
+	result, err := f.commandRestoreTo(context.Background(), 1, "recovered")
+	require.NoError(t, err)
+
+	out, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "recovered/restored.bin", out["path"])
+
+	restored := tree[1]
+	assert.Equal(t, 0, restored.trashed)
+
+	var newDirID int64
+	for _, e := range tree {
+		if e.typ == 1 && e.filename == "recovered" && e.parentID == 7 {
+			newDirID = e.fileID
+		}
+	}
+	require.NotZero(t, newDirID)
+	assert.Equal(t, newDirID, restored.parentID)
+}
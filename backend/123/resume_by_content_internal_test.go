@@ -0,0 +1,152 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newResumeByContentTestFs builds an Fs and fake server for uploadContent
+// tests, routing by path: /upload/v1/file/create, /upload/v1/file/slice,
+// /upload/v1/file/upload_complete. sliceShouldFail reports whether a
+// slice upload against the given preuploadID should fail, to simulate an
+// expired resume session.
+func newResumeByContentTestFs(t *testing.T, sliceShouldFail func(preuploadID string) bool) (f *Fs, createCalls, sliceCalls *int32) {
+	createCalls = new(int32)
+	sliceCalls = new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/upload/v1/file/create":
+			atomic.AddInt32(createCalls, 1)
+			_, _ = w.Write([]byte(`{"code":0,"data":{"preuploadID":"fresh-id","sliceSize":4}}`))
+		case "/upload/v1/file/slice":
+			atomic.AddInt32(sliceCalls, 1)
+			_ = r.ParseMultipartForm(1 << 20)
+			preuploadID := r.MultipartForm.Value["preuploadID"][0]
+			if sliceShouldFail != nil && sliceShouldFail(preuploadID) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":1,"message":"preuploadID expired"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+		case "/upload/v1/file/upload_complete":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"completed":true,"fileID":99}}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		opt:         Options{UploadConcurrency: 1, ProgressDir: t.TempDir(), UploadDomains: "http://127.0.0.1:1"},
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, createCalls, sliceCalls
+}
+
+func TestUploadContentResumesFromPriorRecord(t *testing.T) {
+	f, createCalls, sliceCalls := newResumeByContentTestFs(t, nil)
+
+	content := make([]byte, 12) // 3 slices of size 4
+	r := newBytesReaderAt(content)
+
+	rec := &resumeRecord{
+		PreuploadID:  "resume-id",
+		Remote:       "big.bin",
+		Size:         12,
+		Etag:         "etag-resume",
+		ParentFileID: 5,
+		SliceSize:    4,
+		UploadedTo:   4, // slice 0 already confirmed
+		CreatedAt:    time.Now(),
+	}
+	require.NoError(t, f.saveResumeRecord(rec))
+
+	fileID, reused, err := f.uploadContent(context.Background(), 5, "big.bin", 12, "etag-resume", r, "big.bin")
+	require.NoError(t, err)
+	assert.False(t, reused)
+	assert.EqualValues(t, 99, fileID)
+
+	assert.EqualValues(t, 0, atomic.LoadInt32(createCalls), "resuming shouldn't need a fresh session")
+	assert.EqualValues(t, 2, atomic.LoadInt32(sliceCalls), "only the 2 unconfirmed slices should be uploaded")
+
+	// A completed upload's resume record is no longer useful.
+	remaining, err := f.getAllResumeKeys()
+	require.NoError(t, err)
+	assert.NotContains(t, remaining, "etag-resume")
+}
+
+func TestUploadContentFallsBackWhenResumeSessionExpired(t *testing.T) {
+	f, createCalls, sliceCalls := newResumeByContentTestFs(t, func(preuploadID string) bool {
+		return preuploadID == "resume-id"
+	})
+
+	content := make([]byte, 12)
+	r := newBytesReaderAt(content)
+
+	rec := &resumeRecord{
+		PreuploadID:  "resume-id",
+		Remote:       "big.bin",
+		Size:         12,
+		Etag:         "etag-resume",
+		ParentFileID: 5,
+		SliceSize:    4,
+		UploadedTo:   4,
+		CreatedAt:    time.Now(),
+	}
+	require.NoError(t, f.saveResumeRecord(rec))
+
+	fileID, reused, err := f.uploadContent(context.Background(), 5, "big.bin", 12, "etag-resume", r, "big.bin")
+	require.NoError(t, err)
+	assert.False(t, reused)
+	assert.EqualValues(t, 99, fileID)
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(createCalls), "a failed resume should fall back to a fresh session")
+	// 2 unconfirmed slices, attempted then retried once against the
+	// expired session (4 calls), plus all 3 slices freshly uploaded
+	// against the new session (3 calls).
+	assert.EqualValues(t, 7, atomic.LoadInt32(sliceCalls))
+}
+
+func TestUploadContentIgnoresRecordForDifferentParent(t *testing.T) {
+	f, createCalls, sliceCalls := newResumeByContentTestFs(t, nil)
+
+	content := make([]byte, 12)
+	r := newBytesReaderAt(content)
+
+	rec := &resumeRecord{
+		PreuploadID:  "resume-id",
+		Remote:       "big.bin",
+		Size:         12,
+		Etag:         "etag-resume",
+		ParentFileID: 5,
+		SliceSize:    4,
+		UploadedTo:   4,
+		CreatedAt:    time.Now(),
+	}
+	require.NoError(t, f.saveResumeRecord(rec))
+
+	// Same etag and size, but a different destination directory - the
+	// record must not be trusted.
+	fileID, _, err := f.uploadContent(context.Background(), 6, "big.bin", 12, "etag-resume", r, "big.bin")
+	require.NoError(t, err)
+	assert.EqualValues(t, 99, fileID)
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(createCalls))
+	assert.EqualValues(t, 3, atomic.LoadInt32(sliceCalls))
+}
@@ -0,0 +1,98 @@
+package _123
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResumeKeyIndexTestFs(t *testing.T) *Fs {
+	return &Fs{opt: Options{ProgressDir: t.TempDir()}}
+}
+
+// TestGetAllResumeKeysFindsRecordsWrittenByAnotherProcess confirms the
+// first call still discovers a *.resume.json file that was never written
+// through this Fs's own saveResumeRecord - e.g. left behind by a prior
+// rclone invocation that crashed - by falling back to a directory scan
+// before the in-memory index has been populated.
+func TestGetAllResumeKeysFindsRecordsWrittenByAnotherProcess(t *testing.T) {
+	f := newResumeKeyIndexTestFs(t)
+	dir, err := f.progressDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orphan-etag.resume.json"), []byte(`{}`), 0600))
+
+	keys, err := f.getAllResumeKeys()
+	require.NoError(t, err)
+	assert.Contains(t, keys, "orphan-etag")
+}
+
+// TestGetAllResumeKeysReflectsSavesAndRemovesWithoutRescanning confirms
+// that once the index is loaded, saveResumeRecord and removeResumeRecord
+// keep it up to date without getAllResumeKeys needing to touch the
+// filesystem again: a file dropped directly into the progress directory
+// after the index was loaded is invisible until the next process restart.
+func TestGetAllResumeKeysReflectsSavesAndRemovesWithoutRescanning(t *testing.T) {
+	f := newResumeKeyIndexTestFs(t)
+
+	keys, err := f.getAllResumeKeys() // loads the (empty) index
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	rec := &resumeRecord{Etag: "fresh-etag", CreatedAt: time.Now()}
+	require.NoError(t, f.saveResumeRecord(rec))
+
+	keys, err = f.getAllResumeKeys()
+	require.NoError(t, err)
+	assert.Contains(t, keys, "fresh-etag")
+
+	require.NoError(t, f.removeResumeRecord("fresh-etag"))
+
+	keys, err = f.getAllResumeKeys()
+	require.NoError(t, err)
+	assert.NotContains(t, keys, "fresh-etag")
+
+	// Written directly to disk, bypassing saveResumeRecord - the now-loaded
+	// index has no way to know about it until the process restarts.
+	dir, err := f.progressDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bypassed-etag.resume.json"), []byte(`{}`), 0600))
+
+	keys, err = f.getAllResumeKeys()
+	require.NoError(t, err)
+	assert.NotContains(t, keys, "bypassed-etag")
+}
+
+// TestResumeKeyIndexConcurrentSavesAndRemoves exercises saveResumeRecord,
+// removeResumeRecord and getAllResumeKeys concurrently to confirm
+// resumeKeysMu actually guards every access to the shared index.
+func TestResumeKeyIndexConcurrentSavesAndRemoves(t *testing.T) {
+	f := newResumeKeyIndexTestFs(t)
+	_, err := f.getAllResumeKeys()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			etag := etagFor(n)
+			_ = f.saveResumeRecord(&resumeRecord{Etag: etag, CreatedAt: time.Now()})
+			_, _ = f.getAllResumeKeys()
+			_ = f.removeResumeRecord(etag)
+		}(i)
+	}
+	wg.Wait()
+
+	keys, err := f.getAllResumeKeys()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func etagFor(n int) string {
+	return "etag-" + string(rune('a'+n))
+}
@@ -0,0 +1,192 @@
+package _123
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+)
+
+// resourceExhaustionCooldown is how long concurrent slice uploads are
+// temporarily throttled to 1-at-a-time after seeing a resource exhaustion
+// error, giving the OS/pool time to recover before ramping back up.
+const resourceExhaustionCooldown = 10 * time.Second
+
+// resourceExhaustionSubstrings are the fragments of a transient
+// resource-exhaustion error message that fserrors.ShouldRetry doesn't
+// already recognise (they arrive as opaque error strings rather than a
+// syscall.Errno, since they're raised by the HTTP transport or OS rather
+// than returned directly from a syscall).
+var resourceExhaustionSubstrings = []string{
+	"connection pool exhausted",
+	"too many open files",
+}
+
+// isResourceExhaustionError reports whether err looks like a transient
+// resource-exhaustion condition (an overloaded connection pool or an
+// EMFILE-style file descriptor limit) rather than a genuine failure.
+func isResourceExhaustionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, substr := range resourceExhaustionSubstrings {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryJitterFraction is the maximum fraction of calculateRetryDelay's
+// base delay added as jitter, so that many clients hitting the same
+// transient condition at once (e.g. polling for a just-uploaded file to
+// become visible) don't all wake up and retry in lockstep.
+const retryJitterFraction = 0.2
+
+// calculateRetryDelay returns how long to sleep before retrying the
+// attempt'th (0-based) request: a capped exponential backoff, plus up to
+// retryJitterFraction of extra random delay on top.
+func calculateRetryDelay(attempt int) time.Duration {
+	const (
+		base = 200 * time.Millisecond
+		cap_ = 30 * time.Second
+	)
+	if attempt < 0 {
+		attempt = 0
+	}
+	delay := base << uint(attempt)
+	if delay > cap_ || delay <= 0 {
+		delay = cap_
+	}
+	jitter := time.Duration(rand.Float64() * retryJitterFraction * float64(delay))
+	return delay + jitter
+}
+
+// throttleConcurrency temporarily drops uploadSlicesOnce's effective
+// concurrency to 1, called when a resource-exhaustion error is seen so
+// that a flurry of concurrent requests doesn't keep re-exhausting the
+// same limit while it's retried.
+func (f *Fs) throttleConcurrency() {
+	atomic.StoreInt64(&f.resourceExhaustedUntil, time.Now().Add(resourceExhaustionCooldown).UnixNano())
+}
+
+// maxUploadConcurrencyLimit bounds --123-upload-concurrency: the slice
+// API handles on the order of 5 QPS, so a misconfigured value shouldn't
+// be able to fire an unbounded number of simultaneous slice uploads
+// against a single file, independently of rclone's file-level --transfers
+// concurrency.
+const maxUploadConcurrencyLimit = 16
+
+// defaultUploadConcurrency falls back to --transfers when
+// --123-upload-concurrency is unset, so a user who already asked rclone
+// for more (or less) parallelism on the command line gets a consistent
+// amount of slice parallelism too, rather than a flat value disconnected
+// from it.
+func defaultUploadConcurrency() int {
+	if fs.Config.Transfers > 0 {
+		return fs.Config.Transfers
+	}
+	return 1
+}
+
+// clampUploadConcurrency validates n against maxUploadConcurrencyLimit,
+// falling back to defaultUploadConcurrency when unset and clamping
+// out-of-range values rather than letting a misconfigured value through
+// unchecked.
+func clampUploadConcurrency(n int) int {
+	if n <= 0 {
+		n = defaultUploadConcurrency()
+	}
+	if n > maxUploadConcurrencyLimit {
+		return maxUploadConcurrencyLimit
+	}
+	return n
+}
+
+// minHealthyUploadThroughputPerWorker is a conservative floor heuristic
+// for adjustUploadConcurrency: below this, a worker isn't considered to
+// be usefully contributing, suggesting the pool is oversubscribed for
+// the link's actual bandwidth.
+const minHealthyUploadThroughputPerWorker = 256 * 1024 // bytes/sec
+
+// adjustUploadConcurrency recalculates the worker count to use for the
+// next not-yet-sent batch of slices, given the throughput actually
+// measured uploading the previous batch.
+//
+// 123pan's create API fixes sliceSize for the life of an upload session
+// (session.Data.SliceSize, set once by createUploadSession) - unlike the
+// chunked-upload schemes in some other backends, this one can never grow
+// or shrink the chunk size once a session exists. The only knob left to
+// tune in response to measured throughput is how many slices are in
+// flight at once, which is what this adjusts.
+//
+// A session's slices are uploaded in at most two batches - the initial
+// pass, then a retry pass for whatever the server reports missing (see
+// uploadSlices) - so this runs once, between those two passes, rather
+// than on a running timer: comfortably healthy per-worker throughput
+// grows the pool by one for the retry batch, comfortably unhealthy
+// shrinks it by one, otherwise it's left alone. The result is clamped to
+// [min, max].
+func adjustUploadConcurrency(current int, bytesPerSec float64, min, max int) int {
+	if current <= 0 || bytesPerSec <= 0 {
+		return current
+	}
+	perWorker := bytesPerSec / float64(current)
+	next := current
+	switch {
+	case perWorker >= 2*minHealthyUploadThroughputPerWorker && current < max:
+		next = current + 1
+	case perWorker < minHealthyUploadThroughputPerWorker && current > min:
+		next = current - 1
+	}
+	if next < min {
+		return min
+	}
+	if next > max {
+		return max
+	}
+	return next
+}
+
+// effectiveUploadConcurrency returns --123-upload-concurrency, clamped to
+// a sane range, or 1 if a resource-exhaustion error was seen recently and
+// the cooldown hasn't passed yet.
+func (f *Fs) effectiveUploadConcurrency() int {
+	if until := atomic.LoadInt64(&f.resourceExhaustedUntil); until != 0 && time.Now().UnixNano() < until {
+		return 1
+	}
+	concurrency := clampUploadConcurrency(f.opt.UploadConcurrency)
+	if concurrency != f.opt.UploadConcurrency {
+		fs.Debugf(f, "upload_concurrency %d out of range, using %d instead", f.opt.UploadConcurrency, concurrency)
+	}
+	return concurrency
+}
+
+// shouldRetry returns a boolean as to whether this resp and err deserve to
+// be retried. It follows the conventions of fs.Pacer.
+func (f *Fs) shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	f.recordServerDate(resp)
+	if err != nil {
+		if isResourceExhaustionError(err) {
+			f.throttleConcurrency()
+			return true, err
+		}
+		return fserrors.ShouldRetry(err), err
+	}
+	if resp != nil {
+		switch resp.StatusCode {
+		case 429, 503:
+			return true, err
+		}
+	}
+	return false, err
+}
@@ -0,0 +1,40 @@
+package _123
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateRetryDelayGrowsExponentiallyWithinJitterBudget(t *testing.T) {
+	const base = 200 * time.Millisecond
+	for attempt := 0; attempt < 6; attempt++ {
+		unjittered := base << uint(attempt)
+		maxWithJitter := unjittered + time.Duration(float64(unjittered)*retryJitterFraction)
+		for i := 0; i < 20; i++ {
+			delay := calculateRetryDelay(attempt)
+			if delay < unjittered || delay > maxWithJitter {
+				t.Fatalf("attempt %d: delay %v out of range [%v, %v]", attempt, delay, unjittered, maxWithJitter)
+			}
+		}
+	}
+}
+
+func TestCalculateRetryDelayCapsAtThirtySecondsPlusJitter(t *testing.T) {
+	const cap_ = 30 * time.Second
+	maxWithJitter := cap_ + time.Duration(float64(cap_)*retryJitterFraction)
+	for i := 0; i < 20; i++ {
+		delay := calculateRetryDelay(20) // far beyond where the exponential would overflow/exceed the cap
+		if delay < cap_ || delay > maxWithJitter {
+			t.Fatalf("delay %v out of range [%v, %v]", delay, cap_, maxWithJitter)
+		}
+	}
+}
+
+func TestCalculateRetryDelayTreatsNegativeAttemptAsZero(t *testing.T) {
+	const base = 200 * time.Millisecond
+	maxWithJitter := base + time.Duration(float64(base)*retryJitterFraction)
+	delay := calculateRetryDelay(-5)
+	if delay < base || delay > maxWithJitter {
+		t.Fatalf("delay %v out of range [%v, %v]", delay, base, maxWithJitter)
+	}
+}
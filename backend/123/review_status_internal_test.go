@@ -0,0 +1,53 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReviewStatusTestFs(t *testing.T, status int) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"fileID": 1,
+				"status": status,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestCheckReviewStatusRejected(t *testing.T) {
+	f := newReviewStatusTestFs(t, 150)
+
+	err := f.checkReviewStatus(context.Background(), 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected by content review")
+}
+
+func TestCheckReviewStatusOK(t *testing.T) {
+	f := newReviewStatusTestFs(t, 0)
+
+	err := f.checkReviewStatus(context.Background(), 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,45 @@
+package _123
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetryNotFoundSucceedsOnSecondLookup simulates a file that is still
+// "under review" on the first lookup but visible on the second.
+func TestRetryNotFoundSucceedsOnSecondLookup(t *testing.T) {
+	calls := 0
+	fn := func() (*api.FileInfo, error) {
+		calls++
+		if calls == 1 {
+			return nil, fs.ErrorObjectNotFound
+		}
+		return &api.FileInfo{FileID: 123, Filename: "ready.txt"}, nil
+	}
+
+	info, err := retryNotFound(context.Background(), time.Now().Add(time.Second), fn)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), info.FileID)
+	assert.Equal(t, 2, calls)
+}
+
+// TestRetryNotFoundGivesUpAfterDeadline checks that a file that never
+// appears eventually surfaces the not-found error rather than retrying
+// forever.
+func TestRetryNotFoundGivesUpAfterDeadline(t *testing.T) {
+	calls := 0
+	fn := func() (*api.FileInfo, error) {
+		calls++
+		return nil, fs.ErrorObjectNotFound
+	}
+
+	_, err := retryNotFound(context.Background(), time.Now().Add(-time.Second), fn)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	assert.Equal(t, 1, calls)
+}
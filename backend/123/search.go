@@ -0,0 +1,134 @@
+package _123
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// searchModeFuzzy and searchModeExact are the two values the API accepts
+// for FileListRequest.SearchMode: a filename substring match, or an exact
+// filename match.
+const (
+	searchModeFuzzy = 0
+	searchModeExact = 1
+)
+
+// parseSearchMode translates the "mode" backend-command option into the
+// API's SearchMode value. An empty/unrecognised value falls back to the
+// fuzzy (substring) mode, the more generally useful default.
+func parseSearchMode(mode string) (int, error) {
+	switch mode {
+	case "", "fuzzy", "substring":
+		return searchModeFuzzy, nil
+	case "exact":
+		return searchModeExact, nil
+	default:
+		return 0, errors.Errorf("search: unknown mode %q, expected \"fuzzy\" or \"exact\"", mode)
+	}
+}
+
+// searchFiles pages through the API's search (searchData/searchMode
+// on /api/v2/file/list), starting from parentID, stopping once maxResults
+// entries have been collected (0 means unbounded). Search responses are
+// deliberately never written to or read from the directory listing cache
+// - they're a different, usually much larger and recursive result set
+// than a plain directory listing, and caching them would mostly just
+// evict the entries List/pathToFileID actually rely on.
+func (f *Fs) searchFiles(ctx context.Context, parentID int64, query string, searchMode, maxResults int) ([]api.FileInfo, error) {
+	var all []api.FileInfo
+	lastFileID := int64(0)
+	pagesLimit := f.opt.ListAllPagesLimit
+	if pagesLimit <= 0 {
+		pagesLimit = defaultListAllPagesLimit
+	}
+	for page := 0; ; page++ {
+		if page >= pagesLimit {
+			return nil, errors.Errorf("aborting search after %d pages without a terminating lastFileId - increase --123-list-all-pages-limit if this search genuinely has that many results", pagesLimit)
+		}
+		var result api.FileListResponse
+		req := api.FileListRequest{
+			ParentFileID: parentID,
+			Limit:        listChunkSize,
+			SearchData:   query,
+			SearchMode:   searchMode,
+			LastFileID:   lastFileID,
+		}
+		opts := rest.Opts{
+			Method: "POST",
+			Path:   "/api/v2/file/list",
+		}
+		err := f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+			return f.shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to search")
+		}
+		if !result.OK() {
+			return nil, errors.Errorf("failed to search: %s", result.Message)
+		}
+		for _, info := range result.Data.FileList {
+			all = append(all, info)
+			if maxResults > 0 && len(all) >= maxResults {
+				return all, nil
+			}
+		}
+		if result.Data.LastFileID < 0 || len(result.Data.FileList) == 0 {
+			break
+		}
+		lastFileID = result.Data.LastFileID
+	}
+	return all, nil
+}
+
+// remoteFromAbsolutePath converts an idToPath-style path - decoded and
+// rooted at the API's absolute root - into the rclone-relative remote path
+// List/NewObject would report, by stripping f.root's own prefix.
+func (f *Fs) remoteFromAbsolutePath(absPath string) string {
+	remote := strings.TrimPrefix(absPath, f.root)
+	return strings.TrimPrefix(remote, "/")
+}
+
+// commandSearch implements the "search" backend command.
+func (f *Fs) commandSearch(ctx context.Context, dir, query, mode string, maxResults int) (interface{}, error) {
+	searchMode, err := parseSearchMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID, err := f.pathToFileID(ctx, path.Join(f.root, dir), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "search: failed to resolve starting directory")
+	}
+
+	infos, err := f.searchFiles(ctx, parentID, query, searchMode, maxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]map[string]interface{}, 0, len(infos))
+	for _, info := range infos {
+		if info.Trashed != 0 {
+			continue
+		}
+		absPath, err := f.idToPath(ctx, info.FileID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "search: failed to reconstruct path for fileID %d", info.FileID)
+		}
+		matches = append(matches, map[string]interface{}{
+			"remote":       f.remoteFromAbsolutePath(absPath),
+			"fileID":       info.FileID,
+			"parentFileID": info.ParentFileID,
+			"isDir":        info.Type == typeDir,
+			"size":         info.Size,
+			"etag":         info.Etag,
+			"createAt":     info.CreateAt,
+		})
+	}
+	return map[string]interface{}{"matches": matches}, nil
+}
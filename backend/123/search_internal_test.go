@@ -0,0 +1,156 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchTestTree maps fileID -> (filename, parentFileID), used both to
+// answer /api/v1/file/detail (for idToPath's ancestor walk) and to build
+// the /api/v2/file/list search results: root(0) -> docs(1) -> a.txt(2),
+// report.txt(3); root(0) -> report-final.txt(4).
+var searchTestTree = map[int64][2]interface{}{
+	1: {"docs", int64(0)},
+	2: {"a.txt", int64(1)},
+	3: {"report.txt", int64(1)},
+	4: {"report-final.txt", int64(0)},
+}
+
+// newSearchTestFs serves both /api/v1/file/detail (single-fileID lookups,
+// driving idToPath) and /api/v2/file/list (search, driving searchFiles).
+// The search endpoint ignores pagination beyond returning everything in
+// one page and records every request it receives so tests can assert on
+// what searchData/searchMode/parentFileID were actually sent.
+func newSearchTestFs(t *testing.T, root string) (f *Fs, requests *[]api.FileListRequest) {
+	var reqs []api.FileListRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/file/detail":
+			fileID, _ := strconv.ParseInt(r.URL.Query().Get("fileID"), 10, 64)
+			entry, ok := searchTestTree[fileID]
+			if !ok {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "not found"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"fileID":       fileID,
+					"filename":     entry[0],
+					"parentFileID": entry[1],
+				},
+			})
+		case "/api/v2/file/list":
+			var req api.FileListRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			reqs = append(reqs, req)
+			var matches []map[string]interface{}
+			for id, entry := range searchTestTree {
+				name := entry[0].(string)
+				if req.SearchData != "" && !containsSubstring(name, req.SearchData) {
+					continue
+				}
+				matches = append(matches, map[string]interface{}{
+					"fileID":       id,
+					"filename":     name,
+					"parentFileID": entry[1],
+					"type":         0,
+				})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList":   matches,
+				},
+			})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "unexpected path " + r.URL.Path})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		root:  root,
+		cache: newCacheState(),
+		cacheConfig: CacheConfig{
+			ParentCacheTTL: time.Minute,
+			DirCacheTTL:    time.Minute,
+			PathCacheTTL:   time.Minute,
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("docs", 1)
+	f.saveParentToCache(1, 0)
+	return f, &reqs
+}
+
+func containsSubstring(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCommandSearchReconstructsRemotePaths(t *testing.T) {
+	f, _ := newSearchTestFs(t, "")
+
+	res, err := f.commandSearch(context.Background(), "", "report", "", 0)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	matches := m["matches"].([]map[string]interface{})
+	require.Len(t, matches, 2)
+
+	remotes := map[string]bool{}
+	for _, match := range matches {
+		remotes[match["remote"].(string)] = true
+	}
+	assert.True(t, remotes["docs/report.txt"])
+	assert.True(t, remotes["report-final.txt"])
+}
+
+func TestCommandSearchHonoursMaxResults(t *testing.T) {
+	f, _ := newSearchTestFs(t, "")
+
+	res, err := f.commandSearch(context.Background(), "", "", "", 1)
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	matches := m["matches"].([]map[string]interface{})
+	assert.Len(t, matches, 1)
+}
+
+func TestCommandSearchRejectsUnknownMode(t *testing.T) {
+	f, _ := newSearchTestFs(t, "")
+
+	_, err := f.commandSearch(context.Background(), "", "x", "bogus", 0)
+	require.Error(t, err)
+}
+
+func TestCommandSearchSendsSearchDataAndMode(t *testing.T) {
+	f, requests := newSearchTestFs(t, "")
+
+	_, err := f.commandSearch(context.Background(), "", "report", "exact", 0)
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+	got := (*requests)[0]
+	assert.Equal(t, "report", got.SearchData)
+	assert.Equal(t, searchModeExact, got.SearchMode)
+}
@@ -0,0 +1,65 @@
+package _123
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// serverTimeState tracks the offset between the API server's clock and
+// the local clock, derived from the standard HTTP Date response header.
+// Expiry decisions (token expiry, download URL TTLs) are evaluated
+// against the server's clock via now(), so a skewed local clock doesn't
+// cause a token or URL to be treated as valid for longer or shorter than
+// the server actually intends.
+type serverTimeState struct {
+	mu     sync.Mutex
+	offset time.Duration
+	known  bool
+}
+
+// observe records the skew between resp's Date header and the local
+// clock, if the header is present and parseable. Responses without a
+// usable Date header leave the previously observed offset untouched.
+func (s *serverTimeState) observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	dateHeader := resp.Header.Get("Date")
+	if dateHeader == "" {
+		return
+	}
+	serverTime, err := http.ParseTime(dateHeader)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset = serverTime.Sub(time.Now())
+	s.known = true
+}
+
+// now returns the current time adjusted by the most recently observed
+// clock skew against the API server, falling back to the local clock if
+// no server Date header has been seen yet.
+func (s *serverTimeState) now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known {
+		return time.Now()
+	}
+	return time.Now().Add(s.offset)
+}
+
+// recordServerDate updates f's view of the server's clock from resp's
+// Date header, called from shouldRetry so every API response
+// contributes, not just a dedicated endpoint.
+func (f *Fs) recordServerDate(resp *http.Response) {
+	f.serverTime.observe(resp)
+}
+
+// serverNow returns the current time per the API server's clock, per the
+// most recently observed Date response header.
+func (f *Fs) serverNow() time.Time {
+	return f.serverTime.now()
+}
@@ -0,0 +1,65 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTimeStateNowFallsBackToLocalClockUntilObserved(t *testing.T) {
+	var s serverTimeState
+	before := time.Now()
+	now := s.now()
+	after := time.Now()
+	assert.False(t, before.After(now))
+	assert.False(t, now.After(after))
+}
+
+func TestServerTimeStateObserveParsesDateHeader(t *testing.T) {
+	var s serverTimeState
+	// Server claims to be an hour ahead of the local clock.
+	serverTime := time.Now().Add(time.Hour)
+	resp := &http.Response{Header: http.Header{"Date": []string{serverTime.Format(http.TimeFormat)}}}
+
+	s.observe(resp)
+
+	got := s.now()
+	assert.WithinDuration(t, serverTime, got, 2*time.Second)
+}
+
+func TestServerTimeStateObserveIgnoresMissingOrUnparseableDate(t *testing.T) {
+	var s serverTimeState
+	s.observe(&http.Response{Header: http.Header{}})
+	assert.False(t, s.known)
+
+	s.observe(&http.Response{Header: http.Header{"Date": []string{"not a date"}}})
+	assert.False(t, s.known)
+
+	s.observe(nil)
+	assert.False(t, s.known)
+}
+
+// TestRefreshTokenIfNecessaryUsesServerClockNotLocalClock simulates a
+// local clock that's fast by an hour: without server-time correction the
+// token would look expired and trigger a spurious refresh, but once the
+// server's actual Date header has been observed as an hour behind, the
+// still-valid token is correctly kept.
+func TestRefreshTokenIfNecessaryUsesServerClockNotLocalClock(t *testing.T) {
+	f, _ := newMoveTestFs(t, "", "", 7, "dst.bin")
+	f.token = "still-valid"
+
+	// Local clock thinks it's an hour later than the server does, so a
+	// token that expires 50 minutes from the server's present would look
+	// already-expired under a naive time.Now() check.
+	f.tokenExpiry = time.Now().Add(-time.Hour + 50*time.Minute)
+	f.serverTime.offset = -time.Hour
+	f.serverTime.known = true
+
+	err := f.refreshTokenIfNecessary(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "still-valid", f.token, "token should not have been refreshed: server clock says it's not expired yet")
+}
@@ -0,0 +1,36 @@
+package _123
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandSetPacer(t *testing.T) {
+	f := &Fs{pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(10 * time.Millisecond)))}
+
+	result, err := f.commandSetPacer("upload", "100ms")
+	require.NoError(t, err)
+	summary, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "upload", summary["pacer"])
+	assert.InDelta(t, 10.0, summary["effectiveQPS"], 0.001)
+}
+
+func TestCommandSetPacerRejectsUnknownName(t *testing.T) {
+	f := &Fs{pacer: fs.NewPacer(pacer.NewDefault())}
+
+	_, err := f.commandSetPacer("bogus", "100ms")
+	assert.Error(t, err)
+}
+
+func TestCommandSetPacerRejectsBadDuration(t *testing.T) {
+	f := &Fs{pacer: fs.NewPacer(pacer.NewDefault())}
+
+	_, err := f.commandSetPacer("list", "not-a-duration")
+	assert.Error(t, err)
+}
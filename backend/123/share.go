@@ -0,0 +1,100 @@
+package _123
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// shareURLPrefix is prepended to a ShareCreateResponse's ShareKey to form
+// the link users actually share.
+const shareURLPrefix = "https://www.123pan.com/s/"
+
+// shareExpireDays are the only values the share-create API accepts: 1,
+// 7 or 30 days, or 0 for a permanent link.
+var shareExpireDays = []int{0, 1, 7, 30}
+
+// roundUpShareExpireDays maps an arbitrary requested lifetime to the
+// smallest supported expiry that covers it, since the API only accepts a
+// fixed set of day counts rather than an arbitrary duration. A duration
+// longer than the longest supported expiry (30 days) falls back to a
+// permanent link rather than silently truncating it to 30 days, since
+// that's the closer of the two to what was actually asked for.
+func roundUpShareExpireDays(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	days := int(d / (24 * time.Hour))
+	if d%(24*time.Hour) != 0 {
+		days++
+	}
+	for _, supported := range shareExpireDays[1:] {
+		if days <= supported {
+			return supported
+		}
+	}
+	return 0
+}
+
+// createShare creates a public share link covering fileIDs, returning
+// the shareable URL and, if a password was set, the extraction code
+// needed to unlock it (the password itself, in 123pan's scheme).
+func (f *Fs) createShare(ctx context.Context, shareName string, fileIDs []int64, expire time.Duration, password string) (shareURL, extractionCode string, err error) {
+	idStrings := make([]string, len(fileIDs))
+	for i, id := range fileIDs {
+		idStrings[i] = strconv.FormatInt(id, 10)
+	}
+	req := api.ShareCreateRequest{
+		ShareName:   shareName,
+		ShareExpire: roundUpShareExpireDays(expire),
+		FileIDList:  strings.Join(idStrings, ","),
+		SharePwd:    password,
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v1/share/create",
+	}
+	var result api.ShareCreateResponse
+	callErr := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if callErr != nil {
+		return "", "", errors.Wrapf(callErr, "share %q: failed to create share", shareName)
+	}
+	if !result.OK() {
+		return "", "", errors.Errorf("share %q: failed to create share: %s (code %d)", shareName, result.Message, result.Code)
+	}
+	extractionCode = password
+	return shareURLPrefix + result.Data.ShareKey, extractionCode, nil
+}
+
+// commandShare implements the "share" backend command: resolve remote to
+// a fileID (file or directory, both are shareable) and create a public
+// share link for it, optionally expiring after expire and/or protected
+// by password.
+func (f *Fs) commandShare(ctx context.Context, remote string, expire time.Duration, password string) (interface{}, error) {
+	fullPath := path.Join(f.root, remote)
+	fileID, err := f.pathToFileID(ctx, fullPath, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "share %q: failed to resolve path", remote)
+	}
+	shareName := path.Base(fullPath)
+	shareURL, extractionCode, err := f.createShare(ctx, shareName, []int64{fileID}, expire, password)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"url": shareURL,
+	}
+	if extractionCode != "" {
+		result["extractionCode"] = extractionCode
+	}
+	return result, nil
+}
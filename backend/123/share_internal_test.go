@@ -0,0 +1,97 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundUpShareExpireDays(t *testing.T) {
+	assert.Equal(t, 0, roundUpShareExpireDays(0))
+	assert.Equal(t, 1, roundUpShareExpireDays(12*time.Hour))
+	assert.Equal(t, 1, roundUpShareExpireDays(24*time.Hour))
+	assert.Equal(t, 7, roundUpShareExpireDays(25*time.Hour))
+	assert.Equal(t, 7, roundUpShareExpireDays(7*24*time.Hour))
+	assert.Equal(t, 30, roundUpShareExpireDays(8*24*time.Hour))
+	assert.Equal(t, 0, roundUpShareExpireDays(31*24*time.Hour))
+}
+
+// newShareTestFs returns an Fs backed by a fake server that resolves a
+// tiny directory tree and records the body of the share-create request.
+func newShareTestFs(t *testing.T) (f *Fs, lastRequest *api.ShareCreateRequest) {
+	lastRequest = new(api.ShareCreateRequest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList": []map[string]interface{}{
+						{"fileID": 1, "filename": "report.pdf", "parentFileID": 0, "type": 0, "size": 10, "createAt": "2026-01-01 00:00:00"},
+					},
+				},
+			})
+		case "/api/v1/share/create":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(lastRequest))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"shareID": 99, "shareKey": "abc123"},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, lastRequest
+}
+
+func TestCommandShareReturnsURLAndExtractionCode(t *testing.T) {
+	f, lastRequest := newShareTestFs(t)
+
+	result, err := f.commandShare(context.Background(), "report.pdf", 7*24*time.Hour, "s3cr3t")
+	require.NoError(t, err)
+
+	out, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "https://www.123pan.com/s/abc123", out["url"])
+	assert.Equal(t, "s3cr3t", out["extractionCode"])
+
+	assert.Equal(t, "1", lastRequest.FileIDList)
+	assert.Equal(t, 7, lastRequest.ShareExpire)
+	assert.Equal(t, "s3cr3t", lastRequest.SharePwd)
+	assert.Equal(t, "report.pdf", lastRequest.ShareName)
+}
+
+func TestCommandShareOmitsExtractionCodeWithoutPassword(t *testing.T) {
+	f, _ := newShareTestFs(t)
+
+	result, err := f.commandShare(context.Background(), "report.pdf", 0, "")
+	require.NoError(t, err)
+
+	out, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	_, hasCode := out["extractionCode"]
+	assert.False(t, hasCode)
+}
@@ -0,0 +1,84 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSkipIfExistsTestFs builds a fake server whose directory listing
+// already contains an entry named leaf with the given etag, and counts
+// calls to the list and create-upload-session endpoints.
+func newSkipIfExistsTestFs(t *testing.T, leaf, etag string, size int64) (f *Fs, listCalls, createCalls func() int32) {
+	var listCount, createCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			atomic.AddInt32(&listCount, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList": []map[string]interface{}{
+						{"fileID": 42, "filename": leaf, "size": size, "etag": etag, "createAt": "2026-01-01 00:00:00"},
+					},
+				},
+			})
+		case "/upload/v1/file/create":
+			atomic.AddInt32(&createCount, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{}})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		opt:           Options{SkipIfExists: true},
+		cache:         newCacheState(),
+		cacheConfig:   DefaultCacheConfig(),
+		memoryManager: newMemoryManager(0),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 64*1024) },
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, func() int32 { return atomic.LoadInt32(&listCount) }, func() int32 { return atomic.LoadInt32(&createCount) }
+}
+
+func TestUploadSkipsExistingFileWithMatchingEtag(t *testing.T) {
+	content := []byte("identical re-upload content")
+	sum := md5.Sum(content)
+	etag := hex.EncodeToString(sum[:])
+
+	f, listCalls, createCalls := newSkipIfExistsTestFs(t, "dup.bin", etag, int64(len(content)))
+	o := &Object{fs: f, remote: "dup.bin"}
+
+	src := mockobject.New("dup.bin").WithContent(content, mockobject.SeekModeNone)
+	err := o.upload(context.Background(), bytes.NewReader(content), src, 7, "dup.bin")
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 42, o.id)
+	assert.Equal(t, etag, o.md5sum)
+	assert.EqualValues(t, 1, listCalls())
+	assert.EqualValues(t, 0, createCalls())
+}
@@ -0,0 +1,51 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUploadSliceRespectsSliceUploadTimeout checks that a slice upload
+// which hangs past --123-slice-upload-timeout is aborted rather than
+// blocking forever (or until a much longer global timeout).
+func TestUploadSliceRespectsSliceUploadTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock // never returns within the test's lifetime
+	}))
+	// Unblock the handler before closing the server, otherwise Close
+	// would deadlock waiting for the still-blocked handler to return.
+	defer func() {
+		close(unblock)
+		server.Close()
+	}()
+
+	f := &Fs{
+		opt:   Options{SliceUploadTimeout: fs.Duration(50 * time.Millisecond)},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+
+	body := []byte("slice-body")
+	section := io.NewSectionReader(bytes.NewReader(body), 0, int64(len(body)))
+
+	start := time.Now()
+	err := f.uploadSlice(context.Background(), nil, "preupload-id", 0, section)
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Less(t, int64(elapsed), int64(2*time.Second), "uploadSlice should have been cancelled by the per-slice timeout, not hung")
+}
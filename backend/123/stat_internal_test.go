@@ -0,0 +1,70 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"fileID":       42,
+				"filename":     "f.bin",
+				"parentFileID": 7,
+				"type":         0,
+				"etag":         "abc123",
+				"size":         1234,
+				"status":       2,
+				"trashed":      1,
+				"punishFlag":   3,
+				"createAt":     "2026-01-01 00:00:00",
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	f.savePathToIDToCache("f.bin", 42)
+	return f
+}
+
+// TestCommandStatReturnsFullDetail confirms "stat" surfaces every raw
+// detail field, including ones NewObject's conversion to fs.Object drops
+// (status, parentFileID, trashed, punishFlag).
+func TestCommandStatReturnsFullDetail(t *testing.T) {
+	f := newStatTestFs(t)
+
+	res, err := f.commandStat(context.Background(), "f.bin")
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+
+	assert.EqualValues(t, 42, m["fileID"])
+	assert.EqualValues(t, 0, m["type"])
+	assert.EqualValues(t, 1234, m["size"])
+	assert.Equal(t, "abc123", m["etag"])
+	assert.EqualValues(t, 2, m["status"])
+	assert.EqualValues(t, 7, m["parentFileID"])
+	assert.Equal(t, "2026-01-01 00:00:00", m["createAt"])
+	assert.EqualValues(t, 1, m["trashed"])
+	assert.EqualValues(t, 3, m["punishFlag"])
+}
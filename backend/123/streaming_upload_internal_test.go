@@ -0,0 +1,79 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUploadResolvesUnknownSizeBeforeCreatingSession pipes a source whose
+// size is unknown up front (as rclone hands backends for e.g. "rclone
+// rcat") through o.upload and checks that the real, discovered size -
+// not -1 - is what reaches the create-session call and the resulting
+// Object, rather than the unresolved hint from src.Size().
+func TestUploadResolvesUnknownSizeBeforeCreatingSession(t *testing.T) {
+	content := bytes.Repeat([]byte("streamed content, size unknown up front"), 50)
+
+	var createdSize int64 = -1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/upload/v1/file/create":
+			var req api.UploadCreateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			createdSize = req.Size
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"preuploadID": "pre-1", "reuse": false, "sliceSize": 1024},
+			})
+		case "/upload/v1/file/slice":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/upload/v1/file/upload_complete":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"completed": true, "fileID": 42},
+			})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	f := &Fs{
+		opt:           Options{IgnoreEtagMismatch: true},
+		cache:         newCacheState(),
+		cacheConfig:   DefaultCacheConfig(),
+		memoryManager: newMemoryManager(0),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 64*1024) },
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	o := &Object{fs: f, remote: "streamed.bin"}
+
+	src := mockobject.New("streamed.bin").WithContent(content, mockobject.SeekModeNone)
+	src.SetUnknownSize(true)
+	require.EqualValues(t, -1, src.Size())
+
+	err := o.upload(context.Background(), bytes.NewReader(content), src, 7, "streamed.bin")
+	require.NoError(t, err)
+
+	assert.EqualValues(t, len(content), createdSize, "the create-session call should carry the real, discovered size, not -1")
+	assert.EqualValues(t, len(content), o.Size())
+}
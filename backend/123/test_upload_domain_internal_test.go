@@ -0,0 +1,83 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandTestUploadDomainReportsLatencyPerCandidate(t *testing.T) {
+	f, good := newRefreshUploadDomainTestFs(t)
+
+	result, err := f.commandTestUploadDomain(context.Background())
+	require.NoError(t, err)
+	m, ok := result.(map[string]interface{})
+	require.True(t, ok)
+
+	domains, ok := m["domains"].([]map[string]interface{})
+	require.True(t, ok)
+	// candidates are: "" (the configured root, itself the fake server,
+	// so always reachable), then the two --123-upload-domains entries.
+	require.Len(t, domains, 3)
+
+	seen := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		seen[d["domain"].(string)] = true
+		assert.IsType(t, int64(0), d["latencyMs"])
+		if d["domain"] == "http://127.0.0.1:1" {
+			assert.Equal(t, false, d["reachable"])
+		}
+		if d["domain"] == "" || d["domain"] == good {
+			assert.Equal(t, true, d["reachable"])
+		}
+	}
+	assert.True(t, seen[""])
+	assert.True(t, seen["http://127.0.0.1:1"])
+	assert.True(t, seen[good])
+
+	// "" sorts first among the candidates and is always reachable, so
+	// it's the one selected and cached.
+	assert.Equal(t, "", m["selected"])
+	assert.Equal(t, "", f.getSelectedUploadDomain())
+}
+
+// TestProbeUploadDomainLatencyHonoursConnectTimeout confirms a domain
+// that never responds is bounded by --contimeout (fs.Config.ConnectTimeout)
+// rather than hanging for as long as the domain takes to answer, so a
+// "test-upload-domain" sweep across several candidates can't be stalled
+// indefinitely by one unresponsive domain.
+func TestProbeUploadDomainLatencyHonoursConnectTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer func() {
+		close(unblock)
+		hung.Close()
+	}()
+
+	f, _ := newRefreshUploadDomainTestFs(t)
+
+	origTimeout := fs.Config.ConnectTimeout
+	defer func() { fs.Config.ConnectTimeout = origTimeout }()
+	fs.Config.ConnectTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	reachable, latency := f.probeUploadDomainLatency(context.Background(), hung.URL)
+	elapsed := time.Since(start)
+
+	assert.False(t, reachable, "a domain that never responds within the timeout must be reported unreachable")
+	if elapsed > time.Second {
+		t.Fatalf("probe took %v, expected it to be bounded by the %v connect timeout", elapsed, fs.Config.ConnectTimeout)
+	}
+	if latency <= 0 {
+		t.Fatalf("latency should be a positive duration, got %v", latency)
+	}
+}
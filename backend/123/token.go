@@ -0,0 +1,148 @@
+package _123
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// tokenExpiryLeeway is how long before the reported expiry we refresh
+// the token, to avoid racing a request against expiry.
+const tokenExpiryLeeway = 2 * time.Minute
+
+// GetAccessToken exchanges the configured client credentials for a fresh
+// access token.
+func (f *Fs) GetAccessToken(ctx context.Context) (string, time.Time, error) {
+	var result api.TokenResponse
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v1/access_token",
+	}
+	req := api.TokenRequest{
+		ClientID:     f.opt.ClientID,
+		ClientSecret: f.opt.ClientSecret,
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to obtain access token")
+	}
+	if !result.OK() {
+		return "", time.Time{}, errors.Errorf("failed to obtain access token: %s", result.Message)
+	}
+	expiry, err := time.Parse(time.RFC3339, result.Data.ExpiredAt)
+	if err != nil {
+		// Be lenient - a token we can't parse the expiry for is still
+		// usable, just treat it as short-lived so we refresh it soon.
+		expiry = time.Now().Add(time.Hour)
+	}
+	return result.Data.AccessToken, expiry, nil
+}
+
+// refreshTokenIfNecessary refreshes the access token if it is missing or
+// about to expire, and is safe to call concurrently.
+func (f *Fs) refreshTokenIfNecessary(ctx context.Context) error {
+	f.tokenMu.Lock()
+	defer f.tokenMu.Unlock()
+	if f.token != "" && f.serverNow().Add(tokenExpiryLeeway).Before(f.tokenExpiry) {
+		return nil
+	}
+	token, expiry, err := f.GetAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	f.token = token
+	f.tokenExpiry = expiry
+	f.saveToken()
+	return nil
+}
+
+// saveToken persists the current token and expiry into the backend config
+// so it survives a restart without a fresh login.
+func (f *Fs) saveToken() {
+	f.m.Set("token", f.token)
+	f.m.Set("token_expiry", f.tokenExpiry.Format(time.RFC3339))
+}
+
+// loadToken restores a previously saved token from the backend config, if any
+func (f *Fs) loadToken() {
+	token, ok := f.m.Get("token")
+	if !ok || token == "" {
+		return
+	}
+	expiryString, ok := f.m.Get("token_expiry")
+	if !ok {
+		return
+	}
+	expiry, err := time.Parse(time.RFC3339, expiryString)
+	if err != nil {
+		return
+	}
+	f.tokenMu.Lock()
+	defer f.tokenMu.Unlock()
+	f.token = token
+	f.tokenExpiry = expiry
+}
+
+// tokenRenewer runs in the background for the lifetime of the Fs,
+// proactively refreshing the access token shortly before it expires so
+// that in-flight requests never race a refresh.
+func (f *Fs) tokenRenewer(ctx context.Context) {
+	for {
+		f.tokenMu.Lock()
+		expiry := f.tokenExpiry
+		f.tokenMu.Unlock()
+
+		wait := expiry.Sub(f.serverNow()) - tokenExpiryLeeway
+		if wait < time.Second {
+			wait = time.Second
+		}
+
+		select {
+		case <-time.After(wait):
+			if err := f.refreshTokenIfNecessary(ctx); err != nil {
+				fs.Errorf(f, "token renewer: failed to refresh token: %v", err)
+			}
+		case <-f.tokenRenewerDone:
+			return
+		}
+	}
+}
+
+// startTokenRenewer starts the background token renewer goroutine. It is
+// safe to call at most once per Fs.
+func (f *Fs) startTokenRenewer() {
+	f.tokenRenewerOnce.Do(func() {
+		f.tokenRenewerDone = make(chan struct{})
+		go f.tokenRenewer(context.Background())
+	})
+}
+
+// stopTokenRenewer stops the background token renewer goroutine, if running.
+func (f *Fs) stopTokenRenewer() {
+	f.tokenRenewerMu.Lock()
+	defer f.tokenRenewerMu.Unlock()
+	if f.tokenRenewerDone != nil {
+		close(f.tokenRenewerDone)
+		f.tokenRenewerDone = nil
+		f.tokenRenewerOnce = sync.Once{}
+	}
+}
+
+// setAuthHeader is called before every API request to attach the bearer token
+func (f *Fs) setAuthHeader(req *http.Request) {
+	f.tokenMu.Lock()
+	token := f.token
+	f.tokenMu.Unlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
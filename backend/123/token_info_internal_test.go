@@ -0,0 +1,47 @@
+package _123
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandTokenInfoReportsExpiryAndImminence(t *testing.T) {
+	f := &Fs{}
+	f.tokenExpiry = time.Now().Add(time.Hour)
+
+	res, err := f.commandTokenInfo(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, f.tokenExpiry.Format(time.RFC3339), m["expiry"])
+	assert.Equal(t, false, m["refreshImminent"])
+	assert.NotEmpty(t, m["timeUntilExpiry"])
+}
+
+func TestCommandTokenInfoFlagsImminentRefresh(t *testing.T) {
+	f := &Fs{}
+	f.tokenExpiry = time.Now().Add(tokenExpiryLeeway / 2)
+
+	res, err := f.commandTokenInfo(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, true, m["refreshImminent"])
+}
+
+func TestCommandTokenInfoNeverExposesRawToken(t *testing.T) {
+	f := &Fs{}
+	f.token = "secret-token-value"
+	f.tokenExpiry = time.Now().Add(time.Hour)
+
+	res, err := f.commandTokenInfo(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	for _, v := range m {
+		if s, ok := v.(string); ok {
+			assert.NotContains(t, s, f.token)
+		}
+	}
+}
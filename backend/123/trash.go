@@ -0,0 +1,144 @@
+package _123
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// trashDeleteBatchSize caps how many fileIDs emptyTrash asks
+// permanentlyDeleteFiles to remove per call, matching listChunkSize since
+// both are bounded by the same kind of API-side per-request limit.
+const trashDeleteBatchSize = listChunkSize
+
+// listTrash pages through the recycle bin via its etag-carrying listing,
+// the same shape as a normal directory listing, so callers get size and
+// etag for every trashed file without an extra per-file detail call. It
+// goes through the normal pacer like every other listing call, so a large
+// recycle bin doesn't race ahead of the API's rate limit.
+func (f *Fs) listTrash(ctx context.Context) ([]api.FileInfo, error) {
+	var all []api.FileInfo
+	lastFileID := int64(0)
+	pagesLimit := f.opt.ListAllPagesLimit
+	if pagesLimit <= 0 {
+		pagesLimit = defaultListAllPagesLimit
+	}
+	for page := 0; ; page++ {
+		if page >= pagesLimit {
+			return nil, errors.Errorf("aborting trash list after %d pages without a terminating lastFileId - increase --123-list-all-pages-limit if the recycle bin is genuinely that large", pagesLimit)
+		}
+		var result api.FileListResponse
+		req := api.FileListRequest{
+			Limit:      listChunkSize,
+			LastFileID: lastFileID,
+		}
+		opts := rest.Opts{
+			Method: "POST",
+			Path:   "/api/v1/file/trash/list",
+		}
+		err := f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+			return f.shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list trash")
+		}
+		if !result.OK() {
+			return nil, errors.Errorf("failed to list trash: %s", result.Message)
+		}
+		all = append(all, result.Data.FileList...)
+		if result.Data.LastFileID < 0 || len(result.Data.FileList) == 0 {
+			break
+		}
+		lastFileID = result.Data.LastFileID
+	}
+	return all, nil
+}
+
+// restoreFiles restores the given fileIDs out of the recycle bin, back to
+// whatever parent directory they were trashed from.
+func (f *Fs) restoreFiles(ctx context.Context, fileIDs []int64) error {
+	req := api.RestoreRequest{FileIDs: fileIDs}
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/api/v1/file/recover",
+		NoResponse: true,
+	}
+	var result api.Response
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to restore files")
+	}
+	f.clearCache()
+	return nil
+}
+
+// permanentlyDeleteFiles irrevocably deletes the given already-trashed
+// fileIDs, rather than just moving them to the recycle bin. The API rate
+// limits this endpoint more tightly than most (around 1 QPS), but that's
+// handled by the shared pacer like every other call, not by anything
+// special here.
+func (f *Fs) permanentlyDeleteFiles(ctx context.Context, fileIDs []int64) error {
+	req := api.DeleteRequest{FileIDs: fileIDs}
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/api/v1/file/delete",
+		NoResponse: true,
+	}
+	var result api.Response
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to permanently delete files")
+	}
+	f.clearCache()
+	return nil
+}
+
+// emptyTrash permanently deletes everything currently in the recycle bin,
+// in trashDeleteBatchSize-sized batches through permanentlyDeleteFiles (so
+// also rate-limited by the shared pacer like every other call, rather than
+// anything batch-specific). A batch that fails doesn't stop the rest -
+// every remaining batch is still attempted - and any failures are
+// reported together in the returned error once everything that could be
+// removed has been, rather than abandoning the operation partway through
+// an already-large recycle bin.
+func (f *Fs) emptyTrash(ctx context.Context) (removed int, freedBytes int64, err error) {
+	trashed, err := f.listTrash(ctx)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to list trash")
+	}
+
+	var failures []string
+	for start := 0; start < len(trashed); start += trashDeleteBatchSize {
+		end := start + trashDeleteBatchSize
+		if end > len(trashed) {
+			end = len(trashed)
+		}
+		batch := trashed[start:end]
+		fileIDs := make([]int64, len(batch))
+		for i, info := range batch {
+			fileIDs[i] = info.FileID
+		}
+		if err := f.permanentlyDeleteFiles(ctx, fileIDs); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		for _, info := range batch {
+			removed++
+			freedBytes += info.Size
+		}
+	}
+	if len(failures) > 0 {
+		return removed, freedBytes, errors.Errorf("%d of %d batch(es) failed: %s", len(failures), (len(trashed)+trashDeleteBatchSize-1)/trashDeleteBatchSize, strings.Join(failures, "; "))
+	}
+	return removed, freedBytes, nil
+}
@@ -0,0 +1,104 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTrashByPathTestFs returns an Fs backed by a fake server with two
+// files at the root ("a.txt" fileID 1, "b.txt" fileID 2) and records the
+// fileIDs sent to the trash endpoint.
+func newTrashByPathTestFs(t *testing.T) (f *Fs, trashCalls *[][]int64) {
+	trashCalls = new([][]int64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/file/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList": []map[string]interface{}{
+						{"fileID": 1, "filename": "a.txt", "parentFileID": 0, "type": 0, "size": 1},
+						{"fileID": 2, "filename": "b.txt", "parentFileID": 0, "type": 0, "size": 2},
+					},
+				},
+			})
+		case "/api/v1/file/trash":
+			var req api.DeleteRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*trashCalls = append(*trashCalls, req.FileIDs)
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, trashCalls
+}
+
+func TestCommandTrashResolvesPathsAndBatchesDelete(t *testing.T) {
+	f, trashCalls := newTrashByPathTestFs(t)
+
+	result, err := f.commandTrash(context.Background(), []string{"a.txt", "b.txt"})
+	require.NoError(t, err)
+
+	m, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.ElementsMatch(t, []string{"a.txt", "b.txt"}, m["trashed"])
+	assert.ElementsMatch(t, []int64{1, 2}, m["fileIDs"])
+	assert.NotContains(t, m, "errors")
+
+	require.Len(t, *trashCalls, 1)
+	assert.ElementsMatch(t, []int64{1, 2}, (*trashCalls)[0])
+}
+
+func TestCommandTrashReportsUnresolvedPathsWithoutAbortingTheRest(t *testing.T) {
+	f, trashCalls := newTrashByPathTestFs(t)
+
+	result, err := f.commandTrash(context.Background(), []string{"a.txt", "missing.txt"})
+	require.NoError(t, err)
+
+	m, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, []string{"a.txt"}, m["trashed"])
+	assert.Equal(t, []int64{1}, m["fileIDs"])
+	errs, ok := m["errors"].(map[string]string)
+	require.True(t, ok)
+	assert.Contains(t, errs, "missing.txt")
+
+	require.Len(t, *trashCalls, 1)
+	assert.Equal(t, []int64{1}, (*trashCalls)[0])
+}
+
+func TestCommandDispatchesTrashByPath(t *testing.T) {
+	f, trashCalls := newTrashByPathTestFs(t)
+
+	_, err := f.Command(context.Background(), "trash", []string{"a.txt"}, nil)
+	require.NoError(t, err)
+	require.Len(t, *trashCalls, 1)
+	assert.Equal(t, []int64{1}, (*trashCalls)[0])
+
+	_, err = f.Command(context.Background(), "trash", nil, nil)
+	assert.Error(t, err)
+}
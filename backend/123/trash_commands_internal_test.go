@@ -0,0 +1,126 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileIDRejectsEmptyAndNonNumeric(t *testing.T) {
+	_, err := parseFileID("trash-restore", "")
+	assert.Error(t, err)
+
+	_, err = parseFileID("trash-restore", "not-a-number")
+	assert.Error(t, err)
+
+	id, err := parseFileID("trash-restore", "42")
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, id)
+}
+
+// newTrashCommandsTestFs returns an Fs backed by a fake server that
+// records which recycle-bin endpoints were hit and with which fileIDs.
+func newTrashCommandsTestFs(t *testing.T) (f *Fs, recoverCalls, deleteCalls *[][]int64) {
+	recoverCalls = new([][]int64)
+	deleteCalls = new([][]int64)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/file/trash/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": -1,
+					"fileList": []map[string]interface{}{
+						{"fileID": 1, "filename": "trashed.bin", "parentFileID": 0, "type": 0, "size": 10, "createAt": "2026-01-01 00:00:00"},
+					},
+				},
+			})
+		case "/api/v1/file/recover":
+			var req api.RestoreRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*recoverCalls = append(*recoverCalls, req.FileIDs)
+			w.WriteHeader(http.StatusOK)
+		case "/api/v1/file/delete":
+			var req api.DeleteRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*deleteCalls = append(*deleteCalls, req.FileIDs)
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, recoverCalls, deleteCalls
+}
+
+func TestCommandTrashListReturnsTrashedEntries(t *testing.T) {
+	f, _, _ := newTrashCommandsTestFs(t)
+
+	result, err := f.commandTrashList(context.Background())
+	require.NoError(t, err)
+
+	entries, ok := result.([]api.FileInfo)
+	require.True(t, ok)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "trashed.bin", entries[0].Filename)
+}
+
+func TestCommandTrashRestoreCallsRecoverWithFileID(t *testing.T) {
+	f, recoverCalls, _ := newTrashCommandsTestFs(t)
+
+	result, err := f.commandTrashRestore(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"fileID": int64(1), "restored": true}, result)
+	assert.Equal(t, [][]int64{{1}}, *recoverCalls)
+}
+
+func TestCommandTrashDeleteCallsDeleteWithFileID(t *testing.T) {
+	f, _, deleteCalls := newTrashCommandsTestFs(t)
+
+	result, err := f.commandTrashDelete(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"fileID": int64(1), "deleted": true}, result)
+	assert.Equal(t, [][]int64{{1}}, *deleteCalls)
+}
+
+func TestCommandDispatchesTrashSubcommands(t *testing.T) {
+	f, recoverCalls, deleteCalls := newTrashCommandsTestFs(t)
+
+	_, err := f.Command(context.Background(), "trash-list", nil, nil)
+	require.NoError(t, err)
+
+	_, err = f.Command(context.Background(), "trash-restore", []string{"1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]int64{{1}}, *recoverCalls)
+
+	_, err = f.Command(context.Background(), "trash-delete", []string{"1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]int64{{1}}, *deleteCalls)
+
+	_, err = f.Command(context.Background(), "trash-restore", nil, nil)
+	assert.Error(t, err)
+
+	_, err = f.Command(context.Background(), "trash-delete", []string{"not-a-number"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTrashTestFs builds a fake server paginating a two-page trash listing:
+// page one returns two entries and a lastFileId pointing at page two; page
+// two returns one entry and a terminating lastFileId of -1.
+func newTrashTestFs(t *testing.T) *Fs {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var req struct {
+			LastFileID int64 `json:"lastFileId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.LastFileID == 0 {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{
+					"lastFileId": 99,
+					"fileList": []map[string]interface{}{
+						{"fileID": 1, "filename": "a.bin", "size": 100, "etag": "etag-a"},
+						{"fileID": 2, "filename": "b.bin", "size": 200, "etag": "etag-b"},
+					},
+				},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"lastFileId": -1,
+				"fileList": []map[string]interface{}{
+					{"fileID": 3, "filename": "c.bin", "size": 300, "etag": "etag-c"},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestListTrashPagesThroughAllEntries(t *testing.T) {
+	f := newTrashTestFs(t)
+	trashed, err := f.listTrash(context.Background())
+	require.NoError(t, err)
+	require.Len(t, trashed, 3)
+	assert.Equal(t, "etag-a", trashed[0].Etag)
+	assert.Equal(t, "etag-c", trashed[2].Etag)
+}
+
+func TestCommandLsTrashSizeSumsSize(t *testing.T) {
+	f := newTrashTestFs(t)
+	res, err := f.commandLsTrashSize(context.Background())
+	require.NoError(t, err)
+	m := res.(map[string]interface{})
+	assert.Equal(t, 3, m["count"])
+	assert.EqualValues(t, 600, m["totalBytes"])
+}
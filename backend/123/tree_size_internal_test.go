@@ -0,0 +1,139 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTreeSizeTestFs builds a fake server for a tree:
+//
+//	root (id 1)
+//	  a.txt (id 2, size 100)
+//	  sub (id 3, dir)
+//	    b.txt (id 4, size 250)
+//
+// and counts how many listing requests each directory ID receives.
+func newTreeSizeTestFs(t *testing.T) (f *Fs, listCallsFor func(id int64) int32) {
+	var rootCalls, subCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ParentFileID int64 `json:"parentFileId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var files []map[string]interface{}
+		switch req.ParentFileID {
+		case 1:
+			atomic.AddInt32(&rootCalls, 1)
+			files = []map[string]interface{}{
+				{"fileID": 2, "filename": "a.txt", "type": 0, "size": 100},
+				{"fileID": 3, "filename": "sub", "type": 1, "size": 0},
+			}
+		case 3:
+			atomic.AddInt32(&subCalls, 1)
+			files = []map[string]interface{}{
+				{"fileID": 4, "filename": "b.txt", "type": 0, "size": 250},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"fileList": files, "lastFileId": -1},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		cache:       newCacheState(),
+		cacheConfig: DefaultCacheConfig(),
+		srv:         rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer:       fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, func(id int64) int32 {
+		if id == 1 {
+			return atomic.LoadInt32(&rootCalls)
+		}
+		return atomic.LoadInt32(&subCalls)
+	}
+}
+
+func TestTreeSizeSumsSubtreeAndCaches(t *testing.T) {
+	f, listCallsFor := newTreeSizeTestFs(t)
+
+	totalSize, fileCount, dirCount, cached, err := f.treeSize(context.Background(), 1, -1)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.EqualValues(t, 350, totalSize)
+	assert.EqualValues(t, 2, fileCount)
+	assert.EqualValues(t, 1, dirCount)
+	assert.EqualValues(t, 1, listCallsFor(1))
+	assert.EqualValues(t, 1, listCallsFor(3))
+
+	// A repeat query must be served from cache, with no further listing calls.
+	totalSize, fileCount, dirCount, cached, err = f.treeSize(context.Background(), 1, -1)
+	require.NoError(t, err)
+	assert.True(t, cached)
+	assert.EqualValues(t, 350, totalSize)
+	assert.EqualValues(t, 2, fileCount)
+	assert.EqualValues(t, 1, dirCount)
+	assert.EqualValues(t, 1, listCallsFor(1))
+	assert.EqualValues(t, 1, listCallsFor(3))
+}
+
+func TestTreeSizeCacheBustOnWrite(t *testing.T) {
+	f, listCallsFor := newTreeSizeTestFs(t)
+
+	_, _, _, _, err := f.treeSize(context.Background(), 1, -1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, listCallsFor(1))
+
+	f.clearCache()
+
+	_, _, _, cached, err := f.treeSize(context.Background(), 1, -1)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.EqualValues(t, 2, listCallsFor(1))
+}
+
+func TestTreeSizeRespectsMaxDepth(t *testing.T) {
+	f, _ := newTreeSizeTestFs(t)
+
+	// Depth 0 counts only the directory's own direct children: a.txt and
+	// the "sub" directory entry itself, without descending into it.
+	totalSize, fileCount, dirCount, cached, err := f.treeSize(context.Background(), 1, 0)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.EqualValues(t, 100, totalSize)
+	assert.EqualValues(t, 1, fileCount)
+	assert.EqualValues(t, 1, dirCount)
+}
+
+func TestTreeSizeMaxDepthQueriesBypassCache(t *testing.T) {
+	f, listCallsFor := newTreeSizeTestFs(t)
+
+	_, _, _, _, err := f.treeSize(context.Background(), 1, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, listCallsFor(1))
+
+	// A depth-limited query must never be served from or populate the
+	// unbounded cache, so an unbounded query right after still does its
+	// own full walk rather than trusting a partial total.
+	totalSize, fileCount, dirCount, cached, err := f.treeSize(context.Background(), 1, -1)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.EqualValues(t, 350, totalSize)
+	assert.EqualValues(t, 2, fileCount)
+	assert.EqualValues(t, 1, dirCount)
+}
@@ -0,0 +1,71 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUniqueFilenameTestFs builds a fake server whose root directory already
+// contains "dup.txt", "dup_1.txt", ..., up to collisions-1, so resolving a
+// unique name for "dup.txt" requires walking past that many taken names.
+func newUniqueFilenameTestFs(t *testing.T, collisions int) (*Fs, *int32) {
+	var listCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&listCalls, 1)
+		var files []map[string]interface{}
+		files = append(files, map[string]interface{}{"fileID": 1, "filename": "dup.txt", "type": 0})
+		for i := 1; i < collisions; i++ {
+			files = append(files, map[string]interface{}{
+				"fileID":   int64(i + 1),
+				"filename": fmt.Sprintf("dup_%d.txt", i),
+				"type":     0,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"fileList": files, "lastFileId": -1},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		cache: newCacheState(),
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, &listCalls
+}
+
+func TestResolveUniqueFilenameMakesOnlyOneListCallRegardlessOfCollisionCount(t *testing.T) {
+	const collisions = 50
+	f, listCalls := newUniqueFilenameTestFs(t, collisions)
+
+	name, err := f.resolveUniqueFilename(context.Background(), 0, "dup.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "dup_50.txt", name)
+	assert.EqualValues(t, 1, atomic.LoadInt32(listCalls), "only one listing should have been made")
+}
+
+func TestResolveUniqueFilenameReturnsLeafUnchangedWhenNoCollision(t *testing.T) {
+	f, listCalls := newUniqueFilenameTestFs(t, 1)
+
+	name, err := f.resolveUniqueFilename(context.Background(), 0, "fresh.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "fresh.txt", name)
+	assert.EqualValues(t, 1, atomic.LoadInt32(listCalls))
+}
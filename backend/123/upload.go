@@ -0,0 +1,1069 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// defaultUploadDomains are tried for slice uploads as a last resort, after
+// the configured root and any dynamic servers a create-session call
+// returned have all failed.
+var defaultUploadDomains = []string{
+	"https://openapi-upload.123242.com",
+	"https://openapi-upload.123pan.com",
+}
+
+// uploadDomainCandidates returns the ordered, deduplicated list of upload
+// domains to try for a slice upload: the dynamic servers returned by the
+// create-session call, then the configured root ("", i.e. --123-url or
+// the API default, which already fronts uploads for most deployments),
+// then --123-upload-domains if set, else the built-in defaults. This way
+// a region where only the primary root is blocked still has the
+// dynamic/configured domains to fall back to, without changing where an
+// already-working setup sends its first attempt. Entries that aren't
+// well-formed absolute URLs are dropped with a warning rather than
+// failing the whole upload.
+func (f *Fs) uploadDomainCandidates(servers []string) []string {
+	candidates := append([]string{}, servers...)
+	candidates = append(candidates, "")
+	if f.opt.UploadDomains != "" {
+		for _, d := range strings.Split(f.opt.UploadDomains, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				candidates = append(candidates, d)
+			}
+		}
+	} else {
+		candidates = append(candidates, defaultUploadDomains...)
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	valid := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		if c == "" {
+			valid = append(valid, c)
+			continue
+		}
+		u, err := url.Parse(c)
+		if err != nil || !u.IsAbs() {
+			fs.Logf(f, "ignoring malformed upload domain %q", c)
+			continue
+		}
+		valid = append(valid, c)
+	}
+	return valid
+}
+
+// uploadDomainCacheTTL bounds how long a sticky selected upload domain is
+// trusted without being re-probed. A domain that worked an hour ago may
+// have since been rate-limited or taken out of rotation server-side, so
+// the cache is treated as expired rather than indefinitely sticky.
+const uploadDomainCacheTTL = 1 * time.Hour
+
+// getSelectedUploadDomain returns the domain most recently seen to
+// successfully accept a slice upload, if any, so subsequent uploads in
+// the same session try it first instead of re-discovering it from
+// scratch through the full candidate list every time. Once
+// uploadDomainCacheTTL has elapsed since it was set, it's treated as
+// unset so the caller falls back to probing the full candidate list.
+func (f *Fs) getSelectedUploadDomain() string {
+	f.selectedUploadDomainMu.Lock()
+	defer f.selectedUploadDomainMu.Unlock()
+	if f.selectedUploadDomain != "" && time.Since(f.selectedUploadDomainSet) > uploadDomainCacheTTL {
+		return ""
+	}
+	return f.selectedUploadDomain
+}
+
+// setSelectedUploadDomain records domain as the one to prefer for future
+// uploads in this session, and resets the TTL clock.
+func (f *Fs) setSelectedUploadDomain(domain string) {
+	f.selectedUploadDomainMu.Lock()
+	defer f.selectedUploadDomainMu.Unlock()
+	if domain != f.selectedUploadDomain {
+		fs.Logf(f, "using upload domain %q", domain)
+	}
+	f.selectedUploadDomain = domain
+	f.selectedUploadDomainSet = time.Now()
+}
+
+// clearSelectedUploadDomain discards the cached preferred upload domain,
+// forcing the next upload to rediscover one from the full candidate list.
+func (f *Fs) clearSelectedUploadDomain() {
+	f.selectedUploadDomainMu.Lock()
+	defer f.selectedUploadDomainMu.Unlock()
+	f.selectedUploadDomain = ""
+}
+
+// invalidateSelectedUploadDomain clears the cached domain only if it's
+// still the one that just failed, so a slice failure against a domain
+// that has already been superseded (e.g. by a concurrent upload that
+// succeeded against a different one) doesn't discard newer, good
+// information.
+func (f *Fs) invalidateSelectedUploadDomain(domain string) {
+	f.selectedUploadDomainMu.Lock()
+	defer f.selectedUploadDomainMu.Unlock()
+	if f.selectedUploadDomain == domain {
+		f.selectedUploadDomain = ""
+	}
+}
+
+// preferSelectedUploadDomain moves the cached preferred domain, if it's
+// present in candidates, to the front so it's tried first.
+func (f *Fs) preferSelectedUploadDomain(candidates []string) []string {
+	selected := f.getSelectedUploadDomain()
+	if selected == "" {
+		return candidates
+	}
+	for i, c := range candidates {
+		if c == selected {
+			if i == 0 {
+				return candidates
+			}
+			reordered := make([]string, 0, len(candidates))
+			reordered = append(reordered, selected)
+			reordered = append(reordered, candidates[:i]...)
+			reordered = append(reordered, candidates[i+1:]...)
+			return reordered
+		}
+	}
+	return candidates
+}
+
+// probeUploadDomain reports whether domain responds to a lightweight
+// HEAD request. The empty domain (meaning "use the client's configured
+// root") is always considered reachable, since there's nothing separate
+// to probe. This bypasses the normal pacer/retry machinery, since a
+// probe is meant to answer quickly, not to doggedly retry a flaky
+// domain the way a real upload would.
+func (f *Fs) probeUploadDomain(ctx context.Context, domain string) bool {
+	if domain == "" {
+		return true
+	}
+	opts := rest.Opts{
+		Method:     "HEAD",
+		RootURL:    domain,
+		Path:       "/",
+		NoResponse: true,
+	}
+	_, err := f.srv.Call(ctx, &opts)
+	return err == nil
+}
+
+// probeUploadDomainLatency probes domain like probeUploadDomain, but also
+// times how long the probe took, for diagnostics (see the
+// "test-upload-domain" command). The probe is bounded by
+// --contimeout (fs.Config.ConnectTimeout) rather than running
+// unbounded, so a domain that's merely slow to connect doesn't stall a
+// sweep across several candidates any longer than a real connection
+// attempt would be allowed to take.
+func (f *Fs) probeUploadDomainLatency(ctx context.Context, domain string) (reachable bool, latency time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, fs.Config.ConnectTimeout)
+	defer cancel()
+	start := time.Now()
+	reachable = f.probeUploadDomain(ctx, domain)
+	return reachable, time.Since(start)
+}
+
+// selectUploadDomain probes candidates in order and returns the first
+// reachable one, falling back to the first candidate if none respond
+// (so a genuinely offline network still gets a concrete answer rather
+// than an empty one).
+func (f *Fs) selectUploadDomain(ctx context.Context, candidates []string) string {
+	for _, c := range candidates {
+		if f.probeUploadDomain(ctx, c) {
+			return c
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// memoryBufferMargin is added on top of a source's reported size when
+// bounding an in-memory read, so that a source which over-delivers by a
+// small amount doesn't immediately get rejected, while one that keeps
+// delivering past the margin still gets caught rather than exhausting
+// memory.
+const memoryBufferMargin = 1 * 1024 * 1024
+
+// defaultMemoryBufferLimit caps how much memory streamingPutWithMemoryBuffer
+// is willing to buffer across all concurrent transfers at once.
+const defaultMemoryBufferLimit = 256 * 1024 * 1024
+
+// memoryManager tracks how much memory is currently reserved for buffering
+// in-flight uploads, so that many concurrent transfers can't collectively
+// exhaust the process's RAM.
+type memoryManager struct {
+	mu        sync.Mutex
+	limit     int64
+	allocated int64
+}
+
+// newMemoryManager returns a memoryManager that allows at most limit bytes
+// to be reserved at once. limit <= 0 uses defaultMemoryBufferLimit.
+func newMemoryManager(limit int64) *memoryManager {
+	if limit <= 0 {
+		limit = defaultMemoryBufferLimit
+	}
+	return &memoryManager{limit: limit}
+}
+
+// CanAllocate reports whether size bytes can currently be reserved without
+// exceeding the configured limit. It does not itself reserve anything -
+// callers that go on to buffer should call reserve, then release once done.
+func (m *memoryManager) CanAllocate(size int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.allocated+size <= m.limit
+}
+
+// reserve accounts for size bytes now being buffered in memory
+func (m *memoryManager) reserve(size int64) {
+	m.mu.Lock()
+	m.allocated += size
+	m.mu.Unlock()
+}
+
+// release gives back memory previously reserved with reserve
+func (m *memoryManager) release(size int64) {
+	m.mu.Lock()
+	m.allocated -= size
+	m.mu.Unlock()
+}
+
+// putSmallFileWithMD5 reads the whole of in into memory, bounded to size
+// plus memoryBufferMargin, and returns the buffered bytes along with their
+// MD5. A source that delivers more than that bound is reported as an error
+// rather than allowed to grow the buffer without limit.
+func putSmallFileWithMD5(in io.Reader, size int64, computeSHA1 bool) ([]byte, string, string, error) {
+	bound := size + memoryBufferMargin
+	md5h := md5.New()
+	tee := io.Writer(md5h)
+	var sha1h hash.Hash
+	if computeSHA1 {
+		sha1h = sha1.New()
+		tee = io.MultiWriter(md5h, sha1h)
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.TeeReader(io.LimitReader(in, bound), tee))
+	if err != nil {
+		return nil, "", "", err
+	}
+	if n == bound {
+		// We filled the bound exactly - check whether the source had more
+		// to give, which means it over-delivered relative to its reported
+		// size rather than just hitting the bound by chance.
+		var probe [1]byte
+		if extra, _ := in.Read(probe[:]); extra > 0 {
+			return nil, "", "", errors.Errorf("source delivered more than its reported size of %d bytes", size)
+		}
+	}
+	sha1sum := ""
+	if sha1h != nil {
+		sha1sum = hex.EncodeToString(sha1h.Sum(nil))
+	}
+	return buf.Bytes(), hex.EncodeToString(md5h.Sum(nil)), sha1sum, nil
+}
+
+// resumeRecord is the on-disk representation of an in-progress upload,
+// persisted so that an interrupted `rclone copy` can resume a multipart
+// upload after the process is restarted.
+type resumeRecord struct {
+	PreuploadID  string    `json:"preuploadID"`
+	Remote       string    `json:"remote"`
+	Size         int64     `json:"size"`
+	Etag         string    `json:"etag"`
+	ParentFileID int64     `json:"parentFileID"` // guards against a stale record from a same-etag file that lived elsewhere
+	SliceSize    int64     `json:"sliceSize"`
+	UploadedTo   int64     `json:"uploadedTo"` // bytes confirmed uploaded so far
+	Servers      []string  `json:"servers"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// progressDir returns the directory used to store resume records and
+// progress files, creating it if necessary.
+func (f *Fs) progressDir() (string, error) {
+	dir := f.opt.ProgressDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "rclone-123-progress")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// resumeRecordPath returns the path of the resume record file for etag
+func (f *Fs) resumeRecordPath(etag string) (string, error) {
+	dir, err := f.progressDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.resume.json", etag)), nil
+}
+
+// saveResumeRecord persists a resumeRecord to disk and records its etag in
+// the in-memory resume-key index, if the index has been loaded, so a
+// subsequent getAllResumeKeys doesn't need to rescan the directory to see
+// it.
+func (f *Fs) saveResumeRecord(rec *resumeRecord) error {
+	path, err := f.resumeRecordPath(rec.Etag)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	f.resumeKeysMu.Lock()
+	if f.resumeKeysLoaded {
+		f.resumeKeysCache[rec.Etag] = true
+	}
+	f.resumeKeysMu.Unlock()
+	return nil
+}
+
+// loadResumeRecord reads back a previously saved resumeRecord, if any
+func (f *Fs) loadResumeRecord(etag string) (*resumeRecord, error) {
+	path, err := f.resumeRecordPath(etag)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rec := new(resumeRecord)
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// removeResumeRecord deletes the resume record for etag, if it exists, and
+// removes it from the in-memory resume-key index, if loaded.
+func (f *Fs) removeResumeRecord(etag string) error {
+	path, err := f.resumeRecordPath(etag)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f.resumeKeysMu.Lock()
+	if f.resumeKeysLoaded {
+		delete(f.resumeKeysCache, etag)
+	}
+	f.resumeKeysMu.Unlock()
+	return nil
+}
+
+// scanResumeKeys does the actual directory scan getAllResumeKeys falls
+// back to the first time it's called, reading every *.resume.json file's
+// name out of the progress directory.
+func (f *Fs) scanResumeKeys() (map[string]bool, error) {
+	dir, err := f.progressDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".resume.json") {
+			keys[strings.TrimSuffix(name, ".resume.json")] = true
+		}
+	}
+	return keys, nil
+}
+
+// getAllResumeKeys returns the etags of every in-progress upload this Fs
+// knows about, as an index kept in f.resumeKeysCache rather than a fresh
+// directory scan on every call.
+//
+// The index can't simply start empty, though: a resume record may have
+// been left behind by a previous process - one that crashed, or that this
+// rclone invocation never ran alongside - and the only way to discover
+// those is to look at what's actually on disk. So the first call to
+// getAllResumeKeys in this Fs's lifetime scans the progress directory once
+// to seed the index; every call after that, and every intervening
+// saveResumeRecord/removeResumeRecord, just updates the index in memory
+// instead of touching the filesystem again.
+func (f *Fs) getAllResumeKeys() ([]string, error) {
+	f.resumeKeysMu.Lock()
+	defer f.resumeKeysMu.Unlock()
+
+	if !f.resumeKeysLoaded {
+		keys, err := f.scanResumeKeys()
+		if err != nil {
+			return nil, err
+		}
+		f.resumeKeysCache = keys
+		f.resumeKeysLoaded = true
+	}
+
+	result := make([]string, 0, len(f.resumeKeysCache))
+	for etag := range f.resumeKeysCache {
+		result = append(result, etag)
+	}
+	return result, nil
+}
+
+// resumeRecordMaxAge is how long a resume record is kept around for a
+// transfer that never finished or got cleaned up, when the "cleanup-progress"
+// command is run without an explicit -o max-age. Past this age the
+// preupload session has almost certainly expired server-side anyway, so
+// the local record is just dead weight.
+const resumeRecordMaxAge = 24 * time.Hour
+
+// cleanupExpiredResumeInfo removes resume records older than maxAge,
+// returning how many were removed and the total size of the removed
+// files. Unlike upload-session-abort, this doesn't attempt a server-side
+// abort first, since a record this old almost certainly already expired on
+// the server.
+func (f *Fs) cleanupExpiredResumeInfo(maxAge time.Duration) (removed int, bytesReclaimed int64, err error) {
+	keys, err := f.getAllResumeKeys()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to scan for resume records")
+	}
+	for _, etag := range keys {
+		rec, err := f.loadResumeRecord(etag)
+		if err != nil || rec == nil {
+			continue
+		}
+		if time.Since(rec.CreatedAt) <= maxAge {
+			continue
+		}
+		path, err := f.resumeRecordPath(etag)
+		if err != nil {
+			return removed, bytesReclaimed, err
+		}
+		if info, err := os.Stat(path); err == nil {
+			bytesReclaimed += info.Size()
+		}
+		if err := f.removeResumeRecord(etag); err != nil {
+			return removed, bytesReclaimed, errors.Wrapf(err, "failed to remove expired resume record %s", etag)
+		}
+		removed++
+	}
+	return removed, bytesReclaimed, nil
+}
+
+// orphanTempFileMaxAge is how long a "123-xfer-*" temp file is allowed to
+// sit around before it's considered orphaned by a transfer that crashed or
+// was killed without cleaning up after itself.
+const orphanTempFileMaxAge = 1 * time.Hour
+
+// cleanupOrphanTempFiles removes leftover "123-xfer-*" temp files created
+// by memoryBufferedCrossCloudTransfer that are older than
+// orphanTempFileMaxAge, returning how many were removed and the total size
+// of the removed files.
+func (f *Fs) cleanupOrphanTempFiles() (removed int, bytesReclaimed int64, err error) {
+	dir := f.opt.ProgressDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to scan for orphan temp files")
+	}
+	cutoff := time.Now().Add(-orphanTempFileMaxAge)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "123-xfer-") || entry.IsDir() {
+			continue
+		}
+		if entry.ModTime().After(cutoff) {
+			continue
+		}
+		size := entry.Size()
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return removed, bytesReclaimed, errors.Wrapf(err, "failed to remove orphan temp file %s", name)
+		}
+		removed++
+		bytesReclaimed += size
+	}
+	return removed, bytesReclaimed, nil
+}
+
+// resumableUploadSession reconstructs an *api.UploadCreateResponse from a
+// previously saved resumeRecord for the same content, so uploadContent
+// can pick up against the old preuploadID instead of paying for a fresh
+// createUploadSession call and every slice already confirmed. The
+// returned resumeFromIndex is the first slice index not yet confirmed
+// uploaded, for the caller to skip ahead to.
+//
+// The record is only trusted if etag, size and parentFileID all match
+// exactly - a same-etag record left behind by a different destination
+// (or a stale record missing fields this version relies on) is ignored
+// rather than risking a corrupt resume.
+func (f *Fs) resumableUploadSession(etag string, size, parentID int64) (session *api.UploadCreateResponse, resumeFromIndex int) {
+	rec, err := f.loadResumeRecord(etag)
+	if err != nil || rec == nil {
+		return nil, 0
+	}
+	if rec.Size != size || rec.ParentFileID != parentID || rec.PreuploadID == "" || rec.SliceSize <= 0 {
+		return nil, 0
+	}
+	session = new(api.UploadCreateResponse)
+	session.Data.PreuploadID = rec.PreuploadID
+	session.Data.SliceSize = rec.SliceSize
+	session.Data.Servers = rec.Servers
+	resumeFromIndex = int(rec.UploadedTo / rec.SliceSize)
+	fs.Debugf(f, "found a previous upload session for %q, resuming from slice %d", rec.Remote, resumeFromIndex)
+	return session, resumeFromIndex
+}
+
+// createUploadSession asks the API for a preupload session for a file of
+// the given name, size and whole-file MD5. If the server already holds a
+// copy of this content it returns immediately with Reuse=true ("秒传",
+// instant upload) and no further slices need to be sent.
+func (f *Fs) createUploadSession(ctx context.Context, parentID int64, leaf string, size int64, etag string) (*api.UploadCreateResponse, error) {
+	duplicate, err := duplicatePolicyValue(f.opt.DuplicatePolicy)
+	if err != nil {
+		return nil, err
+	}
+	var result api.UploadCreateResponse
+	req := api.UploadCreateRequest{
+		ParentFileID: parentID,
+		Filename:     leaf,
+		Etag:         etag,
+		Size:         size,
+		Duplicate:    duplicate,
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/upload/v1/file/create",
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create upload session")
+	}
+	if !result.OK() {
+		return nil, errors.Errorf("failed to create upload session: %s", result.Message)
+	}
+	return &result, nil
+}
+
+// abortUploadSession cancels a preupload session server-side, if the API
+// supports it, and always cleans up any local resume state for it.
+func (f *Fs) abortUploadSession(ctx context.Context, etag, preuploadID string) error {
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/upload/v1/file/abort",
+		NoResponse: true,
+	}
+	req := api.UploadAbortRequest{PreuploadID: preuploadID}
+	serverErr := f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &req, nil)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if serverErr != nil {
+		fs.Debugf(f, "upload-session-abort: server-side abort of %s failed (continuing with local cleanup): %v", preuploadID, serverErr)
+	}
+	if err := f.removeResumeRecord(etag); err != nil {
+		return errors.Wrap(err, "failed to remove local resume record")
+	}
+	return serverErr
+}
+
+// defaultCompletePollBase and defaultCompletePollMax are completeUpload's
+// backoff bounds when --123-complete-poll-base/--123-complete-poll-max are
+// left at their zero value.
+const (
+	defaultCompletePollBase = time.Second
+	defaultCompletePollMax  = 15 * time.Second
+)
+
+// completePollDelay returns how long completeUpload should wait before its
+// attempt'th (0-based) re-poll, doubling from base up to max - the same
+// capped-exponential shape as calculateRetryDelay, but on its own
+// configurable base/cap since polling upload_complete on a huge file is a
+// much longer-running wait than a single request retry.
+func (f *Fs) completePollDelay(attempt int) time.Duration {
+	base := time.Duration(f.opt.CompletePollBase)
+	if base <= 0 {
+		base = defaultCompletePollBase
+	}
+	max := time.Duration(f.opt.CompletePollMax)
+	if max <= 0 {
+		max = defaultCompletePollMax
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+	delay := base << uint(attempt)
+	if delay > max || delay <= 0 {
+		delay = max
+	}
+	return delay
+}
+
+// defaultCompletePollMinAttempts and defaultCompletePollMaxAttempts bound
+// completePollMaxAttempts's size-scaled formula when
+// --123-complete-poll-min-attempts/--123-complete-poll-max-attempts are
+// left at their zero value.
+const (
+	defaultCompletePollMinAttempts = 20
+	defaultCompletePollMaxAttempts = 600
+)
+
+// completePollMaxAttempts returns how many times completeUpload may poll
+// upload_complete before giving up on size bytes. The size-scaled
+// midpoint (20 attempts, plus 60 more per 100MB) gives small files a
+// reasonable default while letting huge ones poll for longer, but a
+// small file stuck behind a slow server-side verification queue can
+// still need more patience than its size alone implies - the result is
+// clamped between --123-complete-poll-min-attempts and
+// --123-complete-poll-max-attempts so users on flaky connections can
+// raise the floor without recompiling.
+func (f *Fs) completePollMaxAttempts(size int64) int {
+	min := f.opt.CompletePollMinAttempts
+	if min <= 0 {
+		min = defaultCompletePollMinAttempts
+	}
+	max := f.opt.CompletePollMaxAttempts
+	if max <= 0 {
+		max = defaultCompletePollMaxAttempts
+	}
+	sizeMB := size / (1024 * 1024)
+	attempts := defaultCompletePollMinAttempts + int((sizeMB/100)*60)
+	if attempts < min {
+		attempts = min
+	}
+	if attempts > max {
+		attempts = max
+	}
+	return attempts
+}
+
+// completeUpload polls upload_complete until the server reports the
+// multipart upload as finished, the context is cancelled, or
+// completePollMaxAttempts(size) polls have been made without success.
+// The delay between polls backs off exponentially from
+// --123-complete-poll-base up to --123-complete-poll-max, rather than
+// polling at a flat 1s regardless of how long a huge file takes the
+// server to assemble.
+func (f *Fs) completeUpload(ctx context.Context, preuploadID string, size int64) (int64, error) {
+	maxAttempts := f.completePollMaxAttempts(size)
+	req := api.UploadCompleteRequest{PreuploadID: preuploadID}
+	for attempt := 0; ; attempt++ {
+		var result api.UploadCompleteResponse
+		opts := rest.Opts{
+			Method: "POST",
+			Path:   "/upload/v1/file/upload_complete",
+		}
+		err := f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, &req, &result)
+			return f.shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to complete upload")
+		}
+		if !result.OK() {
+			return 0, errors.Errorf("failed to complete upload: %s", result.Message)
+		}
+		if result.Data.Completed {
+			return result.Data.FileID, nil
+		}
+		if attempt+1 >= maxAttempts {
+			return 0, errors.Errorf("upload did not complete after %d attempts", maxAttempts)
+		}
+		select {
+		case <-time.After(f.completePollDelay(attempt)):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// verifyUploadedSize fetches fileID's server-side size and compares it
+// against expectedSize, the size of the source that was just uploaded.
+// A dropped slice that still passed upload_complete would otherwise go
+// unnoticed until the truncated content is actually read. On a mismatch
+// the now-known-bad file is removed on a best-effort basis so it doesn't
+// linger looking like a successful upload.
+func (f *Fs) verifyUploadedSize(ctx context.Context, fileID, expectedSize int64) error {
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify uploaded size")
+	}
+	if info.Size == expectedSize {
+		return nil
+	}
+	if delErr := f.deleteFiles(ctx, []int64{fileID}); delErr != nil {
+		fs.Logf(f, "failed to remove file %d after size mismatch: %v", fileID, delErr)
+	}
+	return errors.Errorf("uploaded file size mismatch: expected %d bytes, server reports %d", expectedSize, info.Size)
+}
+
+// verifyUploadedEtag fetches fileID's server-side etag and compares it
+// (case-insensitively) against expectedEtag, the MD5 computed locally
+// while reading the source for upload. upload_complete reporting success
+// doesn't rule out silent corruption in a slice that still happened to
+// land at the right size, so this is the strict-by-default backstop -
+// unlike verifyUploadedSize it isn't gated behind an option, since an
+// etag the server already computed is free to check and --123-upload
+// otherwise has no way to ever notice a corrupted upload. A server that
+// returns a blank etag is treated as having nothing to compare and is
+// not flagged as a mismatch. On a genuine mismatch the now-known-bad
+// file is removed on a best-effort basis so it doesn't linger looking
+// like a successful upload.
+func (f *Fs) verifyUploadedEtag(ctx context.Context, fileID int64, expectedEtag string) error {
+	if f.opt.IgnoreEtagMismatch || expectedEtag == "" {
+		return nil
+	}
+	info, err := f.getFileInfo(ctx, fileID)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify uploaded etag")
+	}
+	if info.Etag == "" || strings.EqualFold(info.Etag, expectedEtag) {
+		return nil
+	}
+	if delErr := f.deleteFiles(ctx, []int64{fileID}); delErr != nil {
+		fs.Logf(f, "failed to remove file %d after etag mismatch: %v", fileID, delErr)
+	}
+	return errors.Errorf("uploaded file etag mismatch: expected %s, server reports %s", expectedEtag, info.Etag)
+}
+
+// md5sumReader computes the MD5 hash of everything read from r
+func md5sumReader(r io.Reader) (string, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolveUniqueFilename returns leaf unchanged if parentID has no entry by
+// that name, or an available "base_N.ext" variant otherwise. It lists
+// parentID exactly once - via listDir, bypassing the dir cache so a
+// just-created sibling is seen - regardless of how many candidate names
+// collide, rather than probing for existence one candidate at a time.
+func (f *Fs) resolveUniqueFilename(ctx context.Context, parentID int64, leaf string) (string, error) {
+	infos, err := f.listDir(ctx, parentID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list directory to avoid a name collision")
+	}
+	existing := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		if info.Trashed == 0 {
+			existing[info.Filename] = true
+		}
+	}
+
+	encodedLeaf := f.opt.Enc.FromStandardName(leaf)
+	if !existing[encodedLeaf] {
+		return leaf, nil
+	}
+
+	ext := filepath.Ext(leaf)
+	base := strings.TrimSuffix(leaf, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+		if !existing[f.opt.Enc.FromStandardName(candidate)] {
+			return candidate, nil
+		}
+	}
+}
+
+// findExistingByNameAndEtag lists parentID once and looks for a
+// non-trashed entry named leaf whose etag matches. leaf must already be
+// server-encoded. If more than one entry matches, the most recently
+// created one is preferred, mirroring findChild's tie-break policy.
+func (f *Fs) findExistingByNameAndEtag(ctx context.Context, parentID int64, leaf, etag string) (*api.FileInfo, bool, error) {
+	infos, err := f.listDir(ctx, parentID)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to list directory for dedup check")
+	}
+	var best *api.FileInfo
+	for i := range infos {
+		info := &infos[i]
+		if info.Trashed != 0 || info.Filename != leaf || info.Etag != etag {
+			continue
+		}
+		if best == nil || info.CreateAt > best.CreateAt {
+			best = info
+		}
+	}
+	return best, best != nil, nil
+}
+
+// isRemoteSource returns whether src comes from a different backend to the
+// local disk, meaning a transfer into f should be treated as a cross-cloud
+// copy rather than a local upload.
+//
+// src.Fs() alone isn't trustworthy here: a wrapped Fs - crypt, chunker,
+// union and friends - can report its own Name and Features rather than the
+// underlying remote's, so a crypt-wrapped local disk would otherwise look
+// remote. This walks Features().UnWrap the same way fs.UnWrapFs does -
+// src.Fs() only hands back an fs.Info, not the full fs.Fs that helper
+// requires - down to the base Fs before checking it, matching how
+// fs.ConfigString tells a true local path apart from a remote one.
+func isRemoteSource(src fs.ObjectInfo) bool {
+	if src == nil || src.Fs() == nil {
+		return true
+	}
+	info := src.Fs()
+	for {
+		unwrap := info.Features().UnWrap
+		if unwrap == nil {
+			break
+		}
+		next := unwrap()
+		if next == nil {
+			break
+		}
+		info = next
+	}
+	return !(info.Name() == "local" && info.Features().IsLocal)
+}
+
+// getCopyBuffer fetches a reusable buffer sized according to
+// --123-copy-buffer-size from the pool, to avoid the default io.Copy 32KB
+// buffer's syscall overhead on multi-GB transfers.
+func (f *Fs) getCopyBuffer() []byte {
+	return f.bufPool.Get().([]byte)
+}
+
+// putCopyBuffer returns a buffer obtained from getCopyBuffer to the pool
+func (f *Fs) putCopyBuffer(buf []byte) {
+	f.bufPool.Put(buf) //nolint:staticcheck // buf came from the pool, not a new slice
+}
+
+// copyWithHash copies in to out through a pooled buffer, teeing the data
+// through an MD5 hasher so the caller gets the content's etag without a
+// second read of the data. When computeSHA1 is set (--123-compute-sha1-on-upload),
+// it's teed through a SHA1 hasher in the same pass too.
+func (f *Fs) copyWithHash(out io.Writer, in io.Reader, computeSHA1 bool) (int64, string, string, error) {
+	md5h := md5.New()
+	dest := io.MultiWriter(out, md5h)
+	var sha1h hash.Hash
+	if computeSHA1 {
+		sha1h = sha1.New()
+		dest = io.MultiWriter(out, md5h, sha1h)
+	}
+	buf := f.getCopyBuffer()
+	defer f.putCopyBuffer(buf)
+	n, err := io.CopyBuffer(dest, in, buf)
+	if err != nil {
+		return n, "", "", err
+	}
+	sha1sum := ""
+	if sha1h != nil {
+		sha1sum = hex.EncodeToString(sha1h.Sum(nil))
+	}
+	return n, hex.EncodeToString(md5h.Sum(nil)), sha1sum, nil
+}
+
+// streamingPutWithMemoryBuffer copies in to out, bounding how much of the
+// source is held in memory at once, and returns the content's MD5 (and,
+// if computeSHA1 is set, its SHA1) so the caller doesn't need a second
+// pass over the data to compute them. When size is known and f's
+// memoryManager has enough headroom, the whole read is buffered in
+// memory (bounded to size plus memoryBufferMargin, so a source that
+// over-delivers can't exhaust memory) and written to out in one go.
+// Otherwise it falls back to a pooled-buffer, hash-teeing copy, so memory
+// use stays bounded regardless of size whenever memory is tight.
+//
+// Either path can end up with fewer bytes than size without returning an
+// error of its own: io.Copy (and io.Reader generally) treats a clean EOF as
+// success, not failure, even if it arrives before size bytes were read. If
+// size is known, that short read is actually a mid-stream failure - the
+// source misreported its length, or a network error truncated it silently
+// - not a legitimate short file, so it's reported as io.ErrUnexpectedEOF
+// rather than let a truncated transfer through as if it were complete.
+func (f *Fs) streamingPutWithMemoryBuffer(in io.Reader, out io.Writer, size int64, computeSHA1 bool) (int64, string, string, error) {
+	n, md5sum, sha1sum, err := f.copyToHashedOut(in, out, size, computeSHA1)
+	if err != nil {
+		return n, "", "", err
+	}
+	if size >= 0 && n != size {
+		return n, "", "", errors.Wrapf(io.ErrUnexpectedEOF, "source delivered %d bytes, expected %d", n, size)
+	}
+	return n, md5sum, sha1sum, nil
+}
+
+// copyToHashedOut performs the actual copy for streamingPutWithMemoryBuffer,
+// without the length check, so callers that already know the exact
+// expected length can apply it uniformly regardless of which path ran.
+func (f *Fs) copyToHashedOut(in io.Reader, out io.Writer, size int64, computeSHA1 bool) (int64, string, string, error) {
+	if size > 0 && f.memoryManager.CanAllocate(size+memoryBufferMargin) {
+		f.memoryManager.reserve(size + memoryBufferMargin)
+		defer f.memoryManager.release(size + memoryBufferMargin)
+		data, md5sum, sha1sum, err := putSmallFileWithMD5(in, size, computeSHA1)
+		if err != nil {
+			return 0, "", "", err
+		}
+		n, err := out.Write(data)
+		return int64(n), md5sum, sha1sum, err
+	}
+	return f.copyWithHash(out, in, computeSHA1)
+}
+
+// prepareUploadSource returns an io.ReaderAt over in's content, ready for
+// createUploadSession/uploadSlices, plus the content's actual size
+// (which may differ from the size hint when it was unknown, size < 0 -
+// e.g. "rclone rcat" - since the temp-file path below always discovers
+// the real size by counting bytes written), its MD5, and a cleanup func
+// that must always be called once the caller is done with the source.
+//
+// When in already implements io.ReaderAt (an *os.File or *bytes.Reader
+// passed in directly, for example), there's no need to buffer it
+// into a temp file at all: the content is hashed with a single streaming
+// pass and the original ReaderAt is reused directly for the slice
+// uploads that follow, avoiding the full-file buffer/temp-copy that
+// memoryBufferedCrossCloudTransfer otherwise performs. This path
+// requires a known size up front, since io.SectionReader (used by
+// hashReaderAt) needs one. Any other source - a plain io.Reader, as
+// rclone hands backends for most remote-to-remote transfers, or any
+// source with an unknown size - still goes through that buffered
+// temp-file path, since slice uploads need random access that a
+// forward-only Reader can't provide, and an unknown size needs a first
+// full pass to discover anyway.
+//
+// src, when non-nil, identifies the transfer's source well enough (via
+// crossCloudMD5CacheKey) to reuse a hash already cached for it by a
+// previous attempt at the same Put, on the temp-file buffered path below -
+// see crossCloudMD5Cache.
+func (f *Fs) prepareUploadSource(ctx context.Context, in io.Reader, size int64, src fs.ObjectInfo) (source io.ReaderAt, resolvedSize int64, etag, sha1sum string, cleanup func(), err error) {
+	computeSHA1 := f.opt.ComputeSHA1OnUpload
+	if ra, ok := in.(io.ReaderAt); ok && size >= 0 {
+		etag, sha1sum, err := f.hashReaderAt(ra, size, computeSHA1)
+		if err != nil {
+			return nil, 0, "", "", nil, err
+		}
+		return ra, size, etag, sha1sum, func() {}, nil
+	}
+	var cacheKey string
+	if src != nil && src.Fs() != nil {
+		cacheKey = crossCloudMD5CacheKey(src.Fs().Name()+":"+src.Fs().Root(), src.Remote(), src.Size(), src.ModTime(ctx))
+	}
+	tmpPath, actualSize, etag, sha1sum, err := f.memoryBufferedCrossCloudTransfer(ctx, in, size, computeSHA1, cacheKey)
+	if err != nil {
+		return nil, 0, "", "", nil, err
+	}
+	file, err := os.Open(tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, 0, "", "", nil, err
+	}
+	cleanup = func() {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+	}
+	return file, actualSize, etag, sha1sum, cleanup, nil
+}
+
+// hashReaderAt computes the MD5 (and, if computeSHA1 is set, the SHA1) of
+// the first size bytes available through ra with a single pooled-buffer
+// streaming pass, without buffering the content anywhere.
+func (f *Fs) hashReaderAt(ra io.ReaderAt, size int64, computeSHA1 bool) (md5sum, sha1sum string, err error) {
+	md5h := md5.New()
+	dest := io.Writer(md5h)
+	var sha1h hash.Hash
+	if computeSHA1 {
+		sha1h = sha1.New()
+		dest = io.MultiWriter(md5h, sha1h)
+	}
+	buf := f.getCopyBuffer()
+	defer f.putCopyBuffer(buf)
+	sr := io.NewSectionReader(ra, 0, size)
+	n, err := io.CopyBuffer(dest, sr, buf)
+	if err != nil {
+		return "", "", err
+	}
+	if n != size {
+		return "", "", errors.Wrapf(io.ErrUnexpectedEOF, "source delivered %d bytes, expected %d", n, size)
+	}
+	if sha1h != nil {
+		sha1sum = hex.EncodeToString(sha1h.Sum(nil))
+	}
+	return hex.EncodeToString(md5h.Sum(nil)), sha1sum, nil
+}
+
+// memoryBufferedCrossCloudTransfer copies src to a temporary local file
+// with a bounded memory footprint, suitable for transfers where the
+// source is a remote (non-local) backend and the destination needs a
+// local, seekable file to compute etags and slice boundaries from. It
+// returns the path of the temp file, the content's MD5, and (if
+// computeSHA1 is set) its SHA1.
+//
+// cacheKey, if non-empty, is looked up in crossCloudMD5Cache first: on a
+// hit (a retry of the same Put within crossCloudMD5CacheTTL) the content
+// is copied to the temp file with a plain, unhashed copy and the cached
+// hash is returned, instead of hashing it again. On a miss the content is
+// hashed as usual and the result is cached under cacheKey for next time.
+func (f *Fs) memoryBufferedCrossCloudTransfer(ctx context.Context, in io.Reader, size int64, computeSHA1 bool, cacheKey string) (path string, actualSize int64, md5sum, sha1sum string, err error) {
+	tmp, err := ioutil.TempFile(f.opt.ProgressDir, "123-xfer-*")
+	if err != nil {
+		return "", 0, "", "", err
+	}
+	defer func() {
+		_ = tmp.Close()
+	}()
+
+	if cacheKey != "" {
+		if cachedMD5, cachedSHA1, ok := lookupCrossCloudMD5(cacheKey); ok && (!computeSHA1 || cachedSHA1 != "") {
+			buf := f.getCopyBuffer()
+			n, err := io.CopyBuffer(tmp, in, buf)
+			f.putCopyBuffer(buf)
+			if err != nil {
+				_ = os.Remove(tmp.Name())
+				return "", 0, "", "", err
+			}
+			if size >= 0 && n != size {
+				_ = os.Remove(tmp.Name())
+				return "", 0, "", "", errors.Wrapf(io.ErrUnexpectedEOF, "source delivered %d bytes, expected %d", n, size)
+			}
+			return tmp.Name(), n, cachedMD5, cachedSHA1, nil
+		}
+	}
+
+	actualSize, md5sum, sha1sum, err = f.streamingPutWithMemoryBuffer(in, tmp, size, computeSHA1)
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", 0, "", "", err
+	}
+	if cacheKey != "" {
+		cacheCrossCloudMD5(cacheKey, md5sum, sha1sum, f.opt.MD5CacheMaxEntries)
+	}
+	return tmp.Name(), actualSize, md5sum, sha1sum, nil
+}
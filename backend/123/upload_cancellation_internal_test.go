@@ -0,0 +1,95 @@
+package _123
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/123/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUploadSlicesCancellationStopsWorkersAndSavesProgress cancels the
+// context partway through a multi-slice upload and checks that:
+//   - uploadSlices returns the cancellation error promptly (rather than
+//     waiting for every already-dispatched slice's HTTP call to time out
+//     on its own), proving idle workers stop picking up new work as soon
+//     as ctx is done instead of draining the rest of the plan
+//   - exactly one resume record is persisted, capturing the slices that
+//     had already succeeded before cancellation
+func TestUploadSlicesCancellationStopsWorkersAndSavesProgress(t *testing.T) {
+	const numSlices = 10
+	const successesBeforeCancel = 3
+
+	var requestCount int32
+	block := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requestCount, 1)
+		if n > successesBeforeCancel {
+			<-block // hold every request past the first few until the test cancels
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	progressDir, err := ioutil.TempDir("", "123-cancel-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(progressDir)
+
+	f := &Fs{
+		opt:   Options{UploadConcurrency: 4, ProgressDir: progressDir},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+
+	sliceSize := int64(4)
+	content := make([]byte, numSlices*sliceSize)
+	r := newBytesReaderAt(content)
+
+	session := &api.UploadCreateResponse{}
+	session.Data.PreuploadID = "preupload-cancel"
+	session.Data.SliceSize = sliceSize
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Wait until at least one request is blocked (i.e. more than
+		// successesBeforeCancel have been attempted) before cancelling,
+		// so there's guaranteed to be both succeeded and in-flight work.
+		for atomic.LoadInt32(&requestCount) <= successesBeforeCancel {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		close(block)
+	}()
+
+	start := time.Now()
+	err = f.uploadSlices(ctx, session, r, int64(len(content)), "cancelled.txt", "etag-cancel", 0, 0)
+	elapsed := time.Since(start)
+	assert.Error(t, err)
+	assert.True(t, elapsed < 500*time.Millisecond,
+		"cancellation should stop queued slices being dispatched rather than waiting them out")
+
+	keys, err := f.getAllResumeKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1, "exactly one resume record should have been saved")
+
+	rec, err := f.loadResumeRecord(keys[0])
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, "cancelled.txt", rec.Remote)
+	assert.Equal(t, "preupload-cancel", rec.PreuploadID)
+	assert.Greater(t, rec.UploadedTo, int64(0))
+}
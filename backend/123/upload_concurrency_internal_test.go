@@ -0,0 +1,55 @@
+package _123
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClampUploadConcurrency(t *testing.T) {
+	assert.Equal(t, defaultUploadConcurrency(), clampUploadConcurrency(0))
+	assert.Equal(t, defaultUploadConcurrency(), clampUploadConcurrency(-1))
+	assert.Equal(t, 4, clampUploadConcurrency(4))
+	assert.Equal(t, maxUploadConcurrencyLimit, clampUploadConcurrency(1000))
+}
+
+func TestEffectiveUploadConcurrencyClampsConfiguredValue(t *testing.T) {
+	f := &Fs{opt: Options{UploadConcurrency: 1000}}
+	assert.Equal(t, maxUploadConcurrencyLimit, f.effectiveUploadConcurrency())
+}
+
+func TestDefaultUploadConcurrencyFollowsTransfers(t *testing.T) {
+	orig := fs.Config.Transfers
+	defer func() { fs.Config.Transfers = orig }()
+
+	fs.Config.Transfers = 6
+	assert.Equal(t, 6, defaultUploadConcurrency())
+
+	fs.Config.Transfers = 0
+	assert.Equal(t, 1, defaultUploadConcurrency())
+}
+
+func TestAdjustUploadConcurrencyGrowsOnHealthyThroughput(t *testing.T) {
+	got := adjustUploadConcurrency(4, 4*2*minHealthyUploadThroughputPerWorker, 1, 16)
+	assert.Equal(t, 5, got)
+}
+
+func TestAdjustUploadConcurrencyShrinksOnUnhealthyThroughput(t *testing.T) {
+	got := adjustUploadConcurrency(4, 4*minHealthyUploadThroughputPerWorker/2, 1, 16)
+	assert.Equal(t, 3, got)
+}
+
+func TestAdjustUploadConcurrencyLeavesModerateThroughputAlone(t *testing.T) {
+	got := adjustUploadConcurrency(4, 4*minHealthyUploadThroughputPerWorker, 1, 16)
+	assert.Equal(t, 4, got)
+}
+
+func TestAdjustUploadConcurrencyRespectsBounds(t *testing.T) {
+	assert.Equal(t, 16, adjustUploadConcurrency(16, 1000*minHealthyUploadThroughputPerWorker, 1, 16))
+	assert.Equal(t, 1, adjustUploadConcurrency(1, minHealthyUploadThroughputPerWorker/100, 1, 16))
+}
+
+func TestAdjustUploadConcurrencyIgnoresUnmeasuredThroughput(t *testing.T) {
+	assert.Equal(t, 4, adjustUploadConcurrency(4, 0, 1, 16))
+}
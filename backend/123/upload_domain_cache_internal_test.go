@@ -0,0 +1,58 @@
+package _123
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetSelectedUploadDomainExpiresAfterTTL confirms the sticky upload
+// domain is only trusted for uploadDomainCacheTTL - once stale, it's
+// treated the same as never having been set, so callers fall back to
+// probing the full candidate list rather than trusting a domain that may
+// have since been rotated out server-side.
+func TestGetSelectedUploadDomainExpiresAfterTTL(t *testing.T) {
+	f, good := newRefreshUploadDomainTestFs(t)
+
+	f.setSelectedUploadDomain(good)
+	assert.Equal(t, good, f.getSelectedUploadDomain())
+
+	f.selectedUploadDomainMu.Lock()
+	f.selectedUploadDomainSet = time.Now().Add(-uploadDomainCacheTTL - time.Second)
+	f.selectedUploadDomainMu.Unlock()
+
+	assert.Equal(t, "", f.getSelectedUploadDomain())
+}
+
+// TestSetSelectedUploadDomainResetsTTL confirms re-selecting the same (or
+// any) domain resets the TTL clock rather than leaving the original
+// selection's age in place.
+func TestSetSelectedUploadDomainResetsTTL(t *testing.T) {
+	f, good := newRefreshUploadDomainTestFs(t)
+
+	f.selectedUploadDomainMu.Lock()
+	f.selectedUploadDomain = good
+	f.selectedUploadDomainSet = time.Now().Add(-uploadDomainCacheTTL - time.Second)
+	f.selectedUploadDomainMu.Unlock()
+	assert.Equal(t, "", f.getSelectedUploadDomain())
+
+	f.setSelectedUploadDomain(good)
+	assert.Equal(t, good, f.getSelectedUploadDomain())
+}
+
+// TestInvalidateSelectedUploadDomainOnlyClearsMatchingDomain confirms a
+// failed domain only clears the cache if it's still the currently
+// selected one, so a stale failure report (e.g. from a retry of a domain
+// that a concurrent upload has since replaced) can't discard newer,
+// good information.
+func TestInvalidateSelectedUploadDomainOnlyClearsMatchingDomain(t *testing.T) {
+	f, good := newRefreshUploadDomainTestFs(t)
+
+	f.setSelectedUploadDomain(good)
+	f.invalidateSelectedUploadDomain("http://stale.invalid")
+	assert.Equal(t, good, f.getSelectedUploadDomain())
+
+	f.invalidateSelectedUploadDomain(good)
+	assert.Equal(t, "", f.getSelectedUploadDomain())
+}
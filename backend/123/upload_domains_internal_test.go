@@ -0,0 +1,112 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadDomainCandidatesOrdering(t *testing.T) {
+	f := &Fs{}
+	got := f.uploadDomainCandidates([]string{"https://dynamic.example.com"})
+	assert.Equal(t, []string{"https://dynamic.example.com", "", defaultUploadDomains[0], defaultUploadDomains[1]}, got)
+}
+
+func TestUploadDomainCandidatesUsesConfiguredOverride(t *testing.T) {
+	f := &Fs{opt: Options{UploadDomains: "https://proxy1.example.com, https://proxy2.example.com"}}
+	got := f.uploadDomainCandidates(nil)
+	assert.Equal(t, []string{"", "https://proxy1.example.com", "https://proxy2.example.com"}, got)
+}
+
+func TestUploadDomainCandidatesDropsMalformedEntries(t *testing.T) {
+	f := &Fs{opt: Options{UploadDomains: "not a url, https://ok.example.com"}}
+	got := f.uploadDomainCandidates(nil)
+	assert.Equal(t, []string{"", "https://ok.example.com"}, got)
+}
+
+// TestUploadSliceFallsBackToConfiguredDomainWhenRootFails confirms that
+// when the configured root is unusable for a slice upload, uploadSlice
+// retries against the next candidate domain - here, a configured
+// --123-upload-domains entry - rather than failing the transfer outright.
+func TestUploadSliceFallsBackToConfiguredDomainWhenRootFails(t *testing.T) {
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer fallback.Close()
+
+	// The configured root refuses every request, simulating it being
+	// blocked on the user's network.
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	f := &Fs{
+		opt:   Options{UploadDomains: fallback.URL},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(broken.URL)
+
+	domains := f.uploadDomainCandidates(nil)
+	body := []byte("slice-body")
+	section := io.NewSectionReader(bytes.NewReader(body), 0, int64(len(body)))
+
+	err := f.uploadSlice(context.Background(), domains, "preupload-id", 0, section)
+	require.NoError(t, err)
+}
+
+// TestUploadSliceCachesDomainOnSuccessAndPrefersItNextTime locks in the
+// actual end-to-end effect of the cached selected-upload-domain: once a
+// slice upload succeeds against a given domain, uploadSlice itself (not
+// just setSelectedUploadDomain called directly, as the rest of this
+// file's tests do) records it, and a later call building the candidate
+// list for a fresh upload puts it first - avoiding a repeat domain-fetch
+// round trip for the next slice/file.
+func TestUploadSliceCachesDomainOnSuccessAndPrefersItNextTime(t *testing.T) {
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer fallback.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	f := &Fs{
+		opt:   Options{UploadDomains: fallback.URL},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(broken.URL)
+
+	domains := f.uploadDomainCandidates(nil)
+	require.Equal(t, "", f.getSelectedUploadDomain(), "nothing should be cached before the first slice upload")
+
+	body := []byte("slice-body")
+	section := io.NewSectionReader(bytes.NewReader(body), 0, int64(len(body)))
+	err := f.uploadSlice(context.Background(), domains, "preupload-id", 0, section)
+	require.NoError(t, err)
+
+	assert.Equal(t, fallback.URL, f.getSelectedUploadDomain(), "the domain that actually succeeded should now be cached")
+
+	// A later upload's candidate list, built the same way, should now be
+	// reordered to try the cached domain first rather than the broken
+	// root - the throughput win the caching exists for.
+	nextDomains := f.preferSelectedUploadDomain(f.uploadDomainCandidates(nil))
+	assert.Equal(t, fallback.URL, nextDomains[0])
+}
@@ -0,0 +1,94 @@
+package _123
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUploadVerifySizeTestFs builds a fake server that completes a full
+// non-instant multipart upload, then reports reportedSize for the
+// resulting file via /api/v1/file/detail. It counts trash calls so tests
+// can confirm a mismatch results in the bad file being removed.
+func newUploadVerifySizeTestFs(t *testing.T, reportedSize int64) (f *Fs, trashCalls func() int32) {
+	var trashCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/upload/v1/file/create":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"preuploadID": "pre-1", "reuse": false, "sliceSize": 1024},
+			})
+		case "/upload/v1/file/slice":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		case "/upload/v1/file/upload_complete":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"completed": true, "fileID": 42},
+			})
+		case "/api/v1/file/detail":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"fileID": 42, "filename": "f.bin", "size": reportedSize, "type": 0},
+			})
+		case "/api/v1/file/trash":
+			atomic.AddInt32(&trashCount, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	f = &Fs{
+		opt:           Options{UploadVerifySize: true},
+		cache:         newCacheState(),
+		cacheConfig:   DefaultCacheConfig(),
+		memoryManager: newMemoryManager(0),
+		bufPool: &sync.Pool{
+			New: func() interface{} { return make([]byte, 64*1024) },
+		},
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f, func() int32 { return atomic.LoadInt32(&trashCount) }
+}
+
+func TestUploadFailsAndCleansUpOnSizeMismatch(t *testing.T) {
+	content := []byte("this content should be ten slices but got truncated")
+	f, trashCalls := newUploadVerifySizeTestFs(t, int64(len(content)-5)) // server reports a shorter size
+	o := &Object{fs: f, remote: "f.bin"}
+
+	src := mockobject.New("f.bin").WithContent(content, mockobject.SeekModeNone)
+	err := o.upload(context.Background(), bytes.NewReader(content), src, 7, "f.bin")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "size mismatch")
+	assert.EqualValues(t, 1, trashCalls())
+}
+
+func TestUploadSucceedsWhenSizeMatches(t *testing.T) {
+	content := []byte("this content arrives complete")
+	f, trashCalls := newUploadVerifySizeTestFs(t, int64(len(content)))
+	o := &Object{fs: f, remote: "f.bin"}
+
+	src := mockobject.New("f.bin").WithContent(content, mockobject.SeekModeNone)
+	err := o.upload(context.Background(), bytes.NewReader(content), src, 7, "f.bin")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, trashCalls())
+}
@@ -0,0 +1,37 @@
+package _123
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyProgressDirWritableCreatesMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "progress")
+	f := &Fs{opt: Options{ProgressDir: dir}}
+
+	require.NoError(t, f.verifyProgressDirWritable())
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
+
+func TestVerifyProgressDirWritableFailsWhenPathIsAFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-dir")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0600))
+	f := &Fs{opt: Options{ProgressDir: path}}
+
+	err := f.verifyProgressDirWritable()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "progress_dir")
+}
+
+func TestVerifyProgressDirWritableFallsBackToSystemTempDir(t *testing.T) {
+	f := &Fs{opt: Options{ProgressDir: ""}}
+
+	assert.NoError(t, f.verifyProgressDirWritable())
+}
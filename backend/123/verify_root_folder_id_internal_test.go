@@ -0,0 +1,78 @@
+package _123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVerifyRootFolderIDTestFs(t *testing.T, handler http.HandlerFunc) *Fs {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	f := &Fs{
+		srv:   rest.NewClient(fshttp.NewClient(fs.Config)),
+		pacer: fs.NewPacer(pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	f.srv.SetRoot(server.URL)
+	return f
+}
+
+func TestVerifyRootFolderIDSkipsTrueRoot(t *testing.T) {
+	f := newVerifyRootFolderIDTestFs(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("true root (fileID 0) should never be looked up, got request to %s", r.URL.Path)
+	})
+	f.rootFolderID = 0
+
+	assert.NoError(t, f.verifyRootFolderID(context.Background()))
+}
+
+func TestVerifyRootFolderIDAcceptsExistingDirectory(t *testing.T) {
+	f := newVerifyRootFolderIDTestFs(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"fileID": 42, "filename": "folder", "type": 1},
+		})
+	})
+	f.rootFolderID = 42
+
+	assert.NoError(t, f.verifyRootFolderID(context.Background()))
+}
+
+func TestVerifyRootFolderIDRejectsFile(t *testing.T) {
+	f := newVerifyRootFolderIDTestFs(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"fileID": 42, "filename": "notadir.txt", "type": 0},
+		})
+	})
+	f.rootFolderID = 42
+
+	err := f.verifyRootFolderID(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a directory")
+}
+
+func TestVerifyRootFolderIDFailsFastOnMissingID(t *testing.T) {
+	f := newVerifyRootFolderIDTestFs(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": "not found"})
+	})
+	f.rootFolderID = 999
+
+	err := f.verifyRootFolderID(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "root_folder_id 999")
+}
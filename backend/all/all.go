@@ -2,6 +2,7 @@ package all
 
 import (
 	// Active file systems
+	_ "github.com/rclone/rclone/backend/123"
 	_ "github.com/rclone/rclone/backend/alias"
 	_ "github.com/rclone/rclone/backend/amazonclouddrive"
 	_ "github.com/rclone/rclone/backend/azureblob"